@@ -4,14 +4,30 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/NikolaB131-org/banner-service/config"
 	"github.com/NikolaB131-org/banner-service/internal/app"
+	"github.com/NikolaB131-org/banner-service/internal/app/jwt"
+	"github.com/NikolaB131-org/banner-service/internal/app/locale"
+	"github.com/NikolaB131-org/banner-service/internal/app/metrics"
+	"github.com/NikolaB131-org/banner-service/internal/app/password"
 	v1 "github.com/NikolaB131-org/banner-service/internal/controller/http/v1"
 	"github.com/NikolaB131-org/banner-service/internal/controller/http/v1/middlewares"
+	"github.com/NikolaB131-org/banner-service/internal/entity"
+	"github.com/NikolaB131-org/banner-service/internal/repository"
+	memoryRepo "github.com/NikolaB131-org/banner-service/internal/repository/memory"
 	postgresRepo "github.com/NikolaB131-org/banner-service/internal/repository/postgres"
 	redisRepo "github.com/NikolaB131-org/banner-service/internal/repository/redis"
 	"github.com/NikolaB131-org/banner-service/internal/service"
+	"github.com/NikolaB131-org/banner-service/migrations"
+	"github.com/NikolaB131-org/banner-service/pkg/migrate"
+	"github.com/NikolaB131-org/banner-service/pkg/notifier"
+	"github.com/NikolaB131-org/banner-service/pkg/oidc"
 	"github.com/NikolaB131-org/banner-service/pkg/postgres"
 	"github.com/NikolaB131-org/banner-service/pkg/redis"
 	"github.com/gin-gonic/gin"
@@ -27,6 +43,9 @@ func main() {
 	// Logger
 	app.InitLogger(config.Logger.Level)
 
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
 	// Postgres
 	pg, err := postgres.New(config.DB.Url)
 	if err != nil {
@@ -34,6 +53,26 @@ func main() {
 	}
 	defer pg.Close()
 
+	// Migrations
+	migrator, err := migrate.New(pg.Pool, migrations.FS)
+	if err != nil {
+		panic(err)
+	}
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		err = runMigrate(context.Background(), migrator, os.Args[2:])
+		if err != nil {
+			panic(err)
+		}
+		return
+	}
+	if config.DB.AutoMigrate {
+		applied, err := migrator.Up(context.Background())
+		logMigrations("applied", applied)
+		if err != nil {
+			panic(err)
+		}
+	}
+
 	// Redis
 	redisClient, err := redis.New(config.Redis.Url)
 	if err != nil {
@@ -46,35 +85,234 @@ func main() {
 		}
 	}()
 
+	// Metrics
+	metrics.RegisterPostgresPool(pg.Pool)
+	metrics.RegisterRedisPool(redisClient.Client)
+
 	// Repositories init
 	userRepository := postgresRepo.NewUserRepository(pg)
+	var sessionRepository repository.Session = postgresRepo.NewSessionRepository(pg)
+	var roleRepository repository.Role = postgresRepo.NewRoleRepository(pg)
+	if config.Auth.AccessCacheTTL > 0 {
+		localSessionRepository := memoryRepo.NewSessionRepository(
+			sessionRepository,
+			redisRepo.NewSessionInvalidationRepository(redisClient),
+			config.Auth.AccessCacheTTL,
+		)
+		go func() {
+			err := localSessionRepository.ListenInvalidations(ctx)
+			if err != nil {
+				slog.Error(fmt.Sprintf("session invalidations listener stopped: %s", err.Error()))
+			}
+		}()
+		sessionRepository = localSessionRepository
+		roleRepository = memoryRepo.NewRoleRepository(roleRepository, localSessionRepository)
+	}
 	bannerRepository := postgresRepo.NewBannerRepository(pg)
-	bannerCacheRepository := redisRepo.NewBannerRepository(redisClient, config.Redis.BannerTTL)
+	var bannerCacheRepository repository.BannerCache = redisRepo.NewBannerRepository(redisClient, config.Redis.BannerTTL, config.Redis.EarlyRefresh)
+	if config.Redis.LocalCacheSize > 0 {
+		localBannerCacheRepository := memoryRepo.NewBannerRepository(
+			bannerCacheRepository,
+			redisRepo.NewBannerInvalidationRepository(redisClient),
+			config.Redis.LocalCacheSize,
+			config.Redis.LocalCacheTTL,
+		)
+		go func() {
+			err := localBannerCacheRepository.ListenInvalidations(ctx)
+			if err != nil {
+				slog.Error(fmt.Sprintf("banner invalidations listener stopped: %s", err.Error()))
+			}
+		}()
+		bannerCacheRepository = localBannerCacheRepository
+	}
 	tagRepository := postgresRepo.NewTagRepository(pg)
 	featureRepository := postgresRepo.NewFeatureRepository(pg)
+	auditRepository := postgresRepo.NewAuditRepository(pg)
+	apiKeyRepository := postgresRepo.NewAPIKeyRepository(pg)
+	loginAttemptsRepository := redisRepo.NewLoginAttemptsRepository(redisClient)
+	passwordResetRepository := postgresRepo.NewPasswordResetRepository(pg)
+	bannerStatsRepository := postgresRepo.NewBannerStatsRepository(pg)
+	bannerDeletionJobRepository := postgresRepo.NewBannerDeletionJobRepository(pg)
+
+	// Notifier
+	userNotifier, err := notifier.New(config.Notifier.Type, config.Notifier.FilePath, config.Notifier.LogBody)
+	if err != nil {
+		panic(err)
+	}
+	if config.Notifier.Type == "log" && config.Notifier.LogBody {
+		slog.Warn("notifier writes password reset tokens to log, it must not be used in production")
+	}
+
+	// Token signing keys
+	keySet, err := jwt.NewKeySet(config.Auth)
+	if err != nil {
+		panic(err)
+	}
+
+	// Passwords
+	passwordHasher, err := password.NewHasher(config.Auth.PasswordHashing)
+	if err != nil {
+		panic(err)
+	}
+	passwordPolicy, err := password.NewPolicy(config.Auth.PasswordPolicy)
+	if err != nil {
+		panic(err)
+	}
+
+	// Banner content locales
+	bannerLocales, err := locale.New(config.Locales)
+	if err != nil {
+		panic(err)
+	}
 
 	// Services
-	authService := service.NewAuthService(userRepository, config.Auth.SignSecret, config.Auth.TokenTTL)
-	bannerService := service.NewBannerService(bannerRepository, bannerCacheRepository, tagRepository, featureRepository)
+	authService := service.NewAuthService(
+		userRepository,
+		sessionRepository,
+		keySet,
+		passwordHasher,
+		passwordPolicy,
+		config.Auth.TokenTTL,
+		config.Auth.RefreshTokenTTL,
+	)
+	loginThrottleService := service.NewLoginThrottleService(
+		loginAttemptsRepository,
+		config.Auth.LoginThrottle.MaxUsernameFailures,
+		config.Auth.LoginThrottle.MaxIPFailures,
+		config.Auth.LoginThrottle.MaxPasswordResetRequests,
+		config.Auth.LoginThrottle.FailureWindow,
+		config.Auth.LoginThrottle.Lockout,
+		config.Auth.LoginThrottle.MaxLockout,
+	)
+	passwordService := service.NewPasswordService(
+		userRepository,
+		sessionRepository,
+		passwordResetRepository,
+		authService,
+		passwordHasher,
+		passwordPolicy,
+		userNotifier,
+		config.Auth.PasswordResetTTL,
+	)
+	auditService := service.NewAuditService(auditRepository)
+	bannerService := service.NewBannerService(
+		bannerRepository,
+		bannerCacheRepository,
+		tagRepository,
+		featureRepository,
+		auditService,
+		bannerLocales,
+		bannerDeletionJobRepository,
+		config.Banner.DeletionWorkers,
+		config.Banner.DeletionQueueSize,
+		config.Banner.DeletionJobTTL,
+		config.Banner.DeletionJobLease,
+	)
+	bannerStatsService := service.NewBannerStatsService(
+		bannerStatsRepository,
+		bannerRepository,
+		config.Banner.StatsFlushInterval,
+		config.Banner.StatsFlushSize,
+	)
+	featureService := service.NewFeatureService(featureRepository, bannerCacheRepository, auditService)
+	tagService := service.NewTagService(tagRepository, bannerCacheRepository, auditService)
+	roleService := service.NewRoleService(roleRepository, auditService)
+	userService := service.NewUserService(userRepository, sessionRepository, roleRepository, auditService)
+	apiKeyService := service.NewAPIKeyService(apiKeyRepository, roleRepository, auditService)
+	var oidcService service.OIDCService
+	if config.OIDC.Enabled {
+		oidcClient, err := oidc.New(context.Background(), oidc.Config{
+			Issuer:       config.OIDC.Issuer,
+			ClientID:     config.OIDC.ClientID,
+			ClientSecret: config.OIDC.ClientSecret,
+			RedirectURL:  config.OIDC.RedirectURL,
+			Scopes:       config.OIDC.Scopes,
+		})
+		if err != nil {
+			panic(err)
+		}
+		oidcService = service.NewOIDCService(
+			oidcClient,
+			userRepository,
+			authService,
+			roleService,
+			config.OIDC.UsernameClaim,
+			config.OIDC.RolesClaim,
+			config.OIDC.RoleMapping,
+			config.OIDC.LinkExistingUsers,
+		)
+	}
+	healthService := service.NewHealthService(map[string]service.Pinger{
+		"postgres": pg,
+		"redis":    redisClient,
+	})
 
 	// Creating admin user
-	adminID, err := authService.RegisterUser(context.Background(), config.Auth.AdminUsername, config.Auth.AdminPassword)
+	if err := passwordPolicy.Validate(config.Auth.AdminUsername, config.Auth.AdminPassword); err != nil {
+		slog.Warn(fmt.Sprintf("admin password does not satisfy password policy: %s", err.Error()))
+	}
+	adminID, err := authService.CreateUser(context.Background(), config.Auth.AdminUsername, config.Auth.AdminPassword)
 	if !errors.Is(err, service.ErrUserAlreadyExists) {
 		if err != nil {
 			panic(fmt.Sprintf("unable to create admin user: %s", err.Error()))
 		}
-		err = authService.MakeAdmin(context.Background(), adminID)
+		err = roleService.AssignRole(context.Background(), adminID, entity.RoleAdmin, "")
 		if err != nil {
 			panic(fmt.Sprintf("unable to grant permissions to admin user: %s", err.Error()))
 		}
 	}
 
 	// Middlewares
-	middlewares := middlewares.New(config, userRepository)
+	middlewares := middlewares.New(config, keySet, userRepository, sessionRepository, apiKeyRepository)
 
 	// Routes
 	r := gin.New()
-	v1.NewRouter(r, middlewares, authService, bannerService)
+	r.ContextWithFallback = true // allows services to read values put to request context by middlewares
+	err = r.SetTrustedProxies(config.HTTP.TrustedProxies)
+	if err != nil {
+		panic(err)
+	}
+	v1.NewRouter(r, middlewares, authService, loginThrottleService, passwordService, bannerService, bannerStatsService, featureService, tagService, auditService, roleService, userService, apiKeyService, oidcService, healthService)
+
+	// Server
+	server := &http.Server{
+		Addr:    fmt.Sprintf(":%d", config.HTTP.Port),
+		Handler: r,
+	}
+	go func() {
+		err := server.ListenAndServe()
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error(fmt.Sprintf("http server error: %s", err.Error()))
+			stop()
+		}
+	}()
+	slog.Info(fmt.Sprintf("http server started on %s", server.Addr))
+
+	<-ctx.Done()
+	stop() // second signal terminates process immediately
 
-	r.Run(fmt.Sprintf(":%d", config.HTTP.Port))
+	// Graceful shutdown
+	slog.Info("shutting down")
+	healthService.SetShuttingDown()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), config.HTTP.ShutdownTimeout)
+	defer cancel()
+
+	err = server.Shutdown(shutdownCtx)
+	if err != nil {
+		slog.Error(fmt.Sprintf("failed to drain http connections: %s", err.Error()))
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		bannerService.Close()
+		bannerStatsService.Close()
+		passwordService.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+		slog.Info("shutdown completed")
+	case <-shutdownCtx.Done():
+		slog.Error("shutdown timeout exceeded, background jobs are abandoned")
+	}
 }
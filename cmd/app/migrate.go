@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/NikolaB131-org/banner-service/pkg/migrate"
+)
+
+var errMigrateUsage = errors.New("usage: app migrate up | down [steps] | version | force <version>")
+
+// runMigrate handles "migrate" subcommand
+func runMigrate(ctx context.Context, migrator *migrate.Migrator, args []string) error {
+	if len(args) == 0 {
+		return errMigrateUsage
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		logMigrations("applied", applied)
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			var err error
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return errMigrateUsage
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		logMigrations("reverted", reverted)
+		return err
+	case "version":
+		version, err := migrator.Version(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Println(version)
+		return nil
+	case "force":
+		if len(args) < 2 {
+			return errMigrateUsage
+		}
+		version, err := strconv.Atoi(args[1])
+		if err != nil {
+			return errMigrateUsage
+		}
+		return migrator.Force(ctx, version)
+	default:
+		return errMigrateUsage
+	}
+}
+
+func logMigrations(action string, migrations []migrate.Migration) {
+	for _, migration := range migrations {
+		slog.Info(fmt.Sprintf("migration %s: %d_%s", action, migration.Version, migration.Name))
+	}
+}
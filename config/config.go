@@ -12,15 +12,22 @@ import (
 
 type (
 	Config struct {
-		HTTP   `yaml:"http"`
-		Logger `yaml:"logger"`
-		Auth   `yaml:"auth"`
-		DB     `yaml:"database"`
-		Redis  `yaml:"redis"`
+		HTTP     `yaml:"http"`
+		Logger   `yaml:"logger"`
+		Auth     `yaml:"auth"`
+		OIDC     `yaml:"oidc"`
+		Notifier `yaml:"notifier"`
+		DB       `yaml:"database"`
+		Redis    `yaml:"redis"`
+		Banner   `yaml:"banner"`
+		Locales  `yaml:"locales"`
 	}
 
 	HTTP struct {
-		Port int `yaml:"port"`
+		Port            int           `yaml:"port"`
+		ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+		// Client ip is taken from X-Forwarded-For only if request comes from one of these addresses or cidrs
+		TrustedProxies []string `yaml:"trusted_proxies"`
 	}
 
 	Logger struct {
@@ -28,19 +35,121 @@ type (
 	}
 
 	Auth struct {
-		TokenTTL      time.Duration `yaml:"token_ttl"`
-		SignSecret    string        `yaml:"sign_secret"`
-		AdminUsername string        `yaml:"admin_username"`
-		AdminPassword string        `yaml:"admin_password"`
+		TokenTTL        time.Duration `yaml:"token_ttl"`
+		RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl"`
+		// AccessCacheTTL is how long session state and permissions are cached in process memory, 0 disables cache
+		AccessCacheTTL time.Duration `yaml:"access_cache_ttl"`
+		// HS256 secret, used for signing only if there are no signing keys,
+		// otherwise it just verifies tokens issued before switching to signing keys until SignSecretUntil
+		SignSecret      string       `yaml:"sign_secret"`
+		SignSecretUntil time.Time    `yaml:"sign_secret_until"` // zero disables sign secret once signing keys are configured
+		SigningKeys     []SigningKey `yaml:"signing_keys"`
+		ActiveKeyID     string       `yaml:"active_key_id"` // signs new tokens, the first signing key by default
+		AdminUsername   string       `yaml:"admin_username"`
+		AdminPassword   string       `yaml:"admin_password"`
+		LoginThrottle   `yaml:"login_throttle"`
+		PasswordPolicy  `yaml:"password_policy"`
+		PasswordHashing `yaml:"password_hashing"`
+		// PasswordResetTTL is lifetime of single-use password reset token sent by notifier
+		PasswordResetTTL time.Duration `yaml:"password_reset_ttl"`
+	}
+
+	// LoginThrottle locks out username or client ip after too many failed logins,
+	// every next failure doubles lockout duration
+	LoginThrottle struct {
+		MaxUsernameFailures int `yaml:"max_username_failures"`
+		MaxIPFailures       int `yaml:"max_ip_failures"`
+		// Password reset requests of one username within failure window, so notifier can not be flooded
+		MaxPasswordResetRequests int           `yaml:"max_password_reset_requests"`
+		FailureWindow            time.Duration `yaml:"failure_window"` // failures are forgotten after this time without new ones
+		Lockout                  time.Duration `yaml:"lockout"`
+		MaxLockout               time.Duration `yaml:"max_lockout"`
+	}
+
+	// PasswordPolicy applies to passwords chosen by users, admin password from config is not checked
+	PasswordPolicy struct {
+		MinLength int `yaml:"min_length"`
+		// One password per line, empty disables check. Relative path is resolved against config file directory
+		BreachedPasswordsFile   string `yaml:"breached_passwords_file"`
+		RejectSimilarToUsername bool   `yaml:"reject_similar_to_username"`
+	}
+
+	// PasswordHashing configures hashing of new passwords, existing hashes of another algorithm
+	// or with weaker parameters are upgraded on successful login
+	PasswordHashing struct {
+		Algorithm         string `yaml:"algorithm"` // bcrypt or argon2id
+		BcryptCost        int    `yaml:"bcrypt_cost"`
+		Argon2Memory      uint32 `yaml:"argon2_memory"` // KiB
+		Argon2Iterations  uint32 `yaml:"argon2_iterations"`
+		Argon2Parallelism uint8  `yaml:"argon2_parallelism"`
+	}
+
+	SigningKey struct {
+		ID             string `yaml:"id"`        // published as kid
+		Algorithm      string `yaml:"algorithm"` // RS256 or EdDSA
+		PrivateKeyFile string `yaml:"private_key_file"`
+		PublicKeyFile  string `yaml:"public_key_file"` // enough for retired keys which only verify tokens
+	}
+
+	// OIDC enables login with external identity provider (corporate SSO) by authorization code flow
+	OIDC struct {
+		Enabled       bool     `yaml:"enabled"`
+		Issuer        string   `yaml:"issuer"`
+		ClientID      string   `yaml:"client_id"`
+		ClientSecret  string   `yaml:"client_secret"`
+		RedirectURL   string   `yaml:"redirect_url"` // must point to /v1/auth/oidc/callback
+		Scopes        []string `yaml:"scopes"`
+		UsernameClaim string   `yaml:"username_claim"`
+		RolesClaim    string   `yaml:"roles_claim"`
+		// RoleMapping maps values of roles claim to service roles, mapped roles are granted and revoked on every login
+		RoleMapping map[string]string `yaml:"role_mapping"`
+		// LinkExistingUsers allows logging in to existing local user with the same username,
+		// enable only if provider guarantees usernames can not be chosen freely
+		LinkExistingUsers bool `yaml:"link_existing_users"`
+	}
+
+	// Notifier delivers messages to users (password reset tokens)
+	Notifier struct {
+		Type     string `yaml:"type"`      // possible values: log, file
+		FilePath string `yaml:"file_path"` // messages are appended to this file as json lines if type is file
+		// LogBody writes message bodies (password reset tokens) to log if type is log, only for local development
+		LogBody bool `yaml:"log_body"`
 	}
 
 	DB struct {
-		Url string `yaml:"url"`
+		Url         string `yaml:"url"`
+		AutoMigrate bool   `yaml:"auto_migrate"` // apply pending migrations on startup
 	}
 
 	Redis struct {
-		Url       string        `yaml:"url"`
-		BannerTTL time.Duration `yaml:"banner_ttl"`
+		Url          string        `yaml:"url"`
+		BannerTTL    time.Duration `yaml:"banner_ttl"`
+		EarlyRefresh time.Duration `yaml:"early_refresh"`
+		// In-process cache in front of redis, size 0 disables it
+		LocalCacheSize int           `yaml:"local_cache_size"`
+		LocalCacheTTL  time.Duration `yaml:"local_cache_ttl"`
+	}
+
+	Banner struct {
+		DeletionWorkers   int `yaml:"deletion_workers"`
+		DeletionQueueSize int `yaml:"deletion_queue_size"`
+		// Status of deletion jobs is available for this long after they are enqueued
+		DeletionJobTTL time.Duration `yaml:"deletion_job_ttl"`
+		// Replica renews lease of its unfinished deletion jobs, jobs with expired lease are taken over by any replica
+		DeletionJobLease time.Duration `yaml:"deletion_job_lease"`
+		// Impressions and clicks are counted in memory and written to database every interval
+		// or when counters of this many banner/day/variant combinations are pending
+		StatsFlushInterval time.Duration `yaml:"stats_flush_interval"`
+		StatsFlushSize     int           `yaml:"stats_flush_size"`
+	}
+
+	// Locales of banner content, locale requested by user is matched against supported ones
+	Locales struct {
+		Default   string   `yaml:"default"`
+		Supported []string `yaml:"supported"` // default locale is always supported
+		// Fallback lists locales tried in order when banner has no content for the key locale,
+		// before its base language and the default locale
+		Fallback map[string][]string `yaml:"fallback"`
 	}
 )
 
@@ -63,18 +172,66 @@ func NewConfig(path *string) (*Config, error) {
 	// Default values
 	config := Config{
 		HTTP: HTTP{
-			Port: 3000,
+			Port:            3000,
+			ShutdownTimeout: 15 * time.Second,
 		},
 		Logger: Logger{
 			Level: "debug",
 		},
 		Auth: Auth{
-			TokenTTL:      30 * time.Minute,
-			AdminUsername: "admin",
-			AdminPassword: "admin",
+			TokenTTL:        30 * time.Minute,
+			RefreshTokenTTL: 30 * 24 * time.Hour,
+			AccessCacheTTL:  5 * time.Second,
+			AdminUsername:   "admin",
+			AdminPassword:   "admin",
+			LoginThrottle: LoginThrottle{
+				MaxUsernameFailures:      5,
+				MaxIPFailures:            20,
+				MaxPasswordResetRequests: 3,
+				FailureWindow:            15 * time.Minute,
+				Lockout:                  time.Minute,
+				MaxLockout:               time.Hour,
+			},
+			PasswordPolicy: PasswordPolicy{
+				MinLength:               8,
+				RejectSimilarToUsername: true,
+			},
+			PasswordHashing: PasswordHashing{
+				Algorithm:         "bcrypt",
+				BcryptCost:        10,
+				Argon2Memory:      64 * 1024,
+				Argon2Iterations:  3,
+				Argon2Parallelism: 2,
+			},
+			PasswordResetTTL: time.Hour,
+		},
+		OIDC: OIDC{
+			Scopes:        []string{"openid", "profile"},
+			UsernameClaim: "preferred_username",
+			RolesClaim:    "groups",
+		},
+		Notifier: Notifier{
+			Type: "log",
+		},
+		DB: DB{
+			AutoMigrate: true,
 		},
 		Redis: Redis{
-			BannerTTL: 10 * time.Minute,
+			BannerTTL:      10 * time.Minute,
+			EarlyRefresh:   time.Second,
+			LocalCacheSize: 10000,
+			LocalCacheTTL:  5 * time.Second,
+		},
+		Banner: Banner{
+			DeletionWorkers:    4,
+			DeletionQueueSize:  100,
+			DeletionJobTTL:     24 * time.Hour,
+			DeletionJobLease:   30 * time.Second,
+			StatsFlushInterval: 10 * time.Second,
+			StatsFlushSize:     1000,
+		},
+		Locales: Locales{
+			Default: "en",
 		},
 	}
 
@@ -93,6 +250,15 @@ func NewConfig(path *string) (*Config, error) {
 		config.HTTP.Port = httpPortInt
 	}
 
+	httpShutdownTimeout, ok := os.LookupEnv("HTTP_SHUTDOWN_TIMEOUT")
+	if ok {
+		httpShutdownTimeoutParsed, err := time.ParseDuration(httpShutdownTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("environment variable HTTP_SHUTDOWN_TIMEOUT parsing error: %w", err)
+		}
+		config.HTTP.ShutdownTimeout = httpShutdownTimeoutParsed
+	}
+
 	loggerLevel, ok := os.LookupEnv("LOGGER_LEVEL")
 	if ok {
 		config.Logger.Level = loggerLevel
@@ -107,6 +273,24 @@ func NewConfig(path *string) (*Config, error) {
 		config.Auth.TokenTTL = tokenTTLParsed
 	}
 
+	authRefreshTokenTTL, ok := os.LookupEnv("AUTH_REFRESH_TOKEN_TTL")
+	if ok {
+		refreshTokenTTLParsed, err := time.ParseDuration(authRefreshTokenTTL)
+		if err != nil {
+			return nil, fmt.Errorf("environment variable AUTH_REFRESH_TOKEN_TTL parsing error: %w", err)
+		}
+		config.Auth.RefreshTokenTTL = refreshTokenTTLParsed
+	}
+
+	authAccessCacheTTL, ok := os.LookupEnv("AUTH_ACCESS_CACHE_TTL")
+	if ok {
+		authAccessCacheTTLParsed, err := time.ParseDuration(authAccessCacheTTL)
+		if err != nil {
+			return nil, fmt.Errorf("environment variable AUTH_ACCESS_CACHE_TTL parsing error: %w", err)
+		}
+		config.Auth.AccessCacheTTL = authAccessCacheTTLParsed
+	}
+
 	authAdminUsername, ok := os.LookupEnv("AUTH_ADMIN_USERNAME")
 	if ok {
 		config.Auth.AdminUsername = authAdminUsername
@@ -122,11 +306,146 @@ func NewConfig(path *string) (*Config, error) {
 		config.Auth.SignSecret = authSignSecret
 	}
 
+	authSignSecretUntil, ok := os.LookupEnv("AUTH_SIGN_SECRET_UNTIL")
+	if ok {
+		authSignSecretUntilParsed, err := time.Parse(time.RFC3339, authSignSecretUntil)
+		if err != nil {
+			return nil, fmt.Errorf("environment variable AUTH_SIGN_SECRET_UNTIL parsing error: %w", err)
+		}
+		config.Auth.SignSecretUntil = authSignSecretUntilParsed
+	}
+
+	authLoginMaxUsernameFailures, ok := os.LookupEnv("AUTH_LOGIN_MAX_USERNAME_FAILURES")
+	if ok {
+		authLoginMaxUsernameFailuresInt, err := strconv.Atoi(authLoginMaxUsernameFailures)
+		if err != nil {
+			return nil, fmt.Errorf("environment variable AUTH_LOGIN_MAX_USERNAME_FAILURES converting error: %w", err)
+		}
+		config.Auth.LoginThrottle.MaxUsernameFailures = authLoginMaxUsernameFailuresInt
+	}
+
+	authLoginMaxIPFailures, ok := os.LookupEnv("AUTH_LOGIN_MAX_IP_FAILURES")
+	if ok {
+		authLoginMaxIPFailuresInt, err := strconv.Atoi(authLoginMaxIPFailures)
+		if err != nil {
+			return nil, fmt.Errorf("environment variable AUTH_LOGIN_MAX_IP_FAILURES converting error: %w", err)
+		}
+		config.Auth.LoginThrottle.MaxIPFailures = authLoginMaxIPFailuresInt
+	}
+
+	authMaxPasswordResetRequests, ok := os.LookupEnv("AUTH_MAX_PASSWORD_RESET_REQUESTS")
+	if ok {
+		authMaxPasswordResetRequestsInt, err := strconv.Atoi(authMaxPasswordResetRequests)
+		if err != nil {
+			return nil, fmt.Errorf("environment variable AUTH_MAX_PASSWORD_RESET_REQUESTS converting error: %w", err)
+		}
+		config.Auth.LoginThrottle.MaxPasswordResetRequests = authMaxPasswordResetRequestsInt
+	}
+
+	authLoginLockout, ok := os.LookupEnv("AUTH_LOGIN_LOCKOUT")
+	if ok {
+		authLoginLockoutParsed, err := time.ParseDuration(authLoginLockout)
+		if err != nil {
+			return nil, fmt.Errorf("environment variable AUTH_LOGIN_LOCKOUT parsing error: %w", err)
+		}
+		config.Auth.LoginThrottle.Lockout = authLoginLockoutParsed
+	}
+
+	authPasswordMinLength, ok := os.LookupEnv("AUTH_PASSWORD_MIN_LENGTH")
+	if ok {
+		authPasswordMinLengthInt, err := strconv.Atoi(authPasswordMinLength)
+		if err != nil {
+			return nil, fmt.Errorf("environment variable AUTH_PASSWORD_MIN_LENGTH converting error: %w", err)
+		}
+		config.Auth.PasswordPolicy.MinLength = authPasswordMinLengthInt
+	}
+
+	authBreachedPasswordsFile, ok := os.LookupEnv("AUTH_BREACHED_PASSWORDS_FILE")
+	if ok {
+		config.Auth.PasswordPolicy.BreachedPasswordsFile = authBreachedPasswordsFile
+	}
+
+	authPasswordHashingAlgorithm, ok := os.LookupEnv("AUTH_PASSWORD_HASHING_ALGORITHM")
+	if ok {
+		config.Auth.PasswordHashing.Algorithm = authPasswordHashingAlgorithm
+	}
+
+	authPasswordResetTTL, ok := os.LookupEnv("AUTH_PASSWORD_RESET_TTL")
+	if ok {
+		authPasswordResetTTLParsed, err := time.ParseDuration(authPasswordResetTTL)
+		if err != nil {
+			return nil, fmt.Errorf("environment variable AUTH_PASSWORD_RESET_TTL parsing error: %w", err)
+		}
+		config.Auth.PasswordResetTTL = authPasswordResetTTLParsed
+	}
+
+	authActiveKeyID, ok := os.LookupEnv("AUTH_ACTIVE_KEY_ID")
+	if ok {
+		config.Auth.ActiveKeyID = authActiveKeyID
+	}
+
+	oidcEnabled, ok := os.LookupEnv("OIDC_ENABLED")
+	if ok {
+		oidcEnabledParsed, err := strconv.ParseBool(oidcEnabled)
+		if err != nil {
+			return nil, fmt.Errorf("environment variable OIDC_ENABLED parsing error: %w", err)
+		}
+		config.OIDC.Enabled = oidcEnabledParsed
+	}
+
+	oidcIssuer, ok := os.LookupEnv("OIDC_ISSUER")
+	if ok {
+		config.OIDC.Issuer = oidcIssuer
+	}
+
+	oidcClientID, ok := os.LookupEnv("OIDC_CLIENT_ID")
+	if ok {
+		config.OIDC.ClientID = oidcClientID
+	}
+
+	oidcClientSecret, ok := os.LookupEnv("OIDC_CLIENT_SECRET")
+	if ok {
+		config.OIDC.ClientSecret = oidcClientSecret
+	}
+
+	oidcRedirectURL, ok := os.LookupEnv("OIDC_REDIRECT_URL")
+	if ok {
+		config.OIDC.RedirectURL = oidcRedirectURL
+	}
+
+	notifierType, ok := os.LookupEnv("NOTIFIER_TYPE")
+	if ok {
+		config.Notifier.Type = notifierType
+	}
+
+	notifierFilePath, ok := os.LookupEnv("NOTIFIER_FILE_PATH")
+	if ok {
+		config.Notifier.FilePath = notifierFilePath
+	}
+
+	notifierLogBody, ok := os.LookupEnv("NOTIFIER_LOG_BODY")
+	if ok {
+		notifierLogBodyParsed, err := strconv.ParseBool(notifierLogBody)
+		if err != nil {
+			return nil, fmt.Errorf("environment variable NOTIFIER_LOG_BODY parsing error: %w", err)
+		}
+		config.Notifier.LogBody = notifierLogBodyParsed
+	}
+
 	dbUrl, ok := os.LookupEnv("DB_URL")
 	if ok {
 		config.DB.Url = dbUrl
 	}
 
+	dbAutoMigrate, ok := os.LookupEnv("DB_AUTO_MIGRATE")
+	if ok {
+		dbAutoMigrateParsed, err := strconv.ParseBool(dbAutoMigrate)
+		if err != nil {
+			return nil, fmt.Errorf("environment variable DB_AUTO_MIGRATE parsing error: %w", err)
+		}
+		config.DB.AutoMigrate = dbAutoMigrateParsed
+	}
+
 	redisUrl, ok := os.LookupEnv("REDIS_URL")
 	if ok {
 		config.Redis.Url = redisUrl
@@ -141,5 +460,87 @@ func NewConfig(path *string) (*Config, error) {
 		config.Redis.BannerTTL = redisBannerTTLParsed
 	}
 
+	redisEarlyRefresh, ok := os.LookupEnv("REDIS_EARLY_REFRESH")
+	if ok {
+		redisEarlyRefreshParsed, err := time.ParseDuration(redisEarlyRefresh)
+		if err != nil {
+			return nil, fmt.Errorf("environment variable REDIS_EARLY_REFRESH parsing error: %w", err)
+		}
+		config.Redis.EarlyRefresh = redisEarlyRefreshParsed
+	}
+
+	redisLocalCacheSize, ok := os.LookupEnv("REDIS_LOCAL_CACHE_SIZE")
+	if ok {
+		redisLocalCacheSizeInt, err := strconv.Atoi(redisLocalCacheSize)
+		if err != nil {
+			return nil, fmt.Errorf("environment variable REDIS_LOCAL_CACHE_SIZE converting error: %w", err)
+		}
+		config.Redis.LocalCacheSize = redisLocalCacheSizeInt
+	}
+
+	redisLocalCacheTTL, ok := os.LookupEnv("REDIS_LOCAL_CACHE_TTL")
+	if ok {
+		redisLocalCacheTTLParsed, err := time.ParseDuration(redisLocalCacheTTL)
+		if err != nil {
+			return nil, fmt.Errorf("environment variable REDIS_LOCAL_CACHE_TTL parsing error: %w", err)
+		}
+		config.Redis.LocalCacheTTL = redisLocalCacheTTLParsed
+	}
+
+	bannerDeletionWorkers, ok := os.LookupEnv("BANNER_DELETION_WORKERS")
+	if ok {
+		bannerDeletionWorkersInt, err := strconv.Atoi(bannerDeletionWorkers)
+		if err != nil {
+			return nil, fmt.Errorf("environment variable BANNER_DELETION_WORKERS converting error: %w", err)
+		}
+		config.Banner.DeletionWorkers = bannerDeletionWorkersInt
+	}
+
+	bannerDeletionQueueSize, ok := os.LookupEnv("BANNER_DELETION_QUEUE_SIZE")
+	if ok {
+		bannerDeletionQueueSizeInt, err := strconv.Atoi(bannerDeletionQueueSize)
+		if err != nil {
+			return nil, fmt.Errorf("environment variable BANNER_DELETION_QUEUE_SIZE converting error: %w", err)
+		}
+		config.Banner.DeletionQueueSize = bannerDeletionQueueSizeInt
+	}
+
+	bannerDeletionJobTTL, ok := os.LookupEnv("BANNER_DELETION_JOB_TTL")
+	if ok {
+		bannerDeletionJobTTLParsed, err := time.ParseDuration(bannerDeletionJobTTL)
+		if err != nil {
+			return nil, fmt.Errorf("environment variable BANNER_DELETION_JOB_TTL parsing error: %w", err)
+		}
+		config.Banner.DeletionJobTTL = bannerDeletionJobTTLParsed
+	}
+
+	bannerDeletionJobLease, ok := os.LookupEnv("BANNER_DELETION_JOB_LEASE")
+	if ok {
+		bannerDeletionJobLeaseParsed, err := time.ParseDuration(bannerDeletionJobLease)
+		if err != nil {
+			return nil, fmt.Errorf("environment variable BANNER_DELETION_JOB_LEASE parsing error: %w", err)
+		}
+		config.Banner.DeletionJobLease = bannerDeletionJobLeaseParsed
+	}
+
+	bannerStatsFlushInterval, ok := os.LookupEnv("BANNER_STATS_FLUSH_INTERVAL")
+	if ok {
+		bannerStatsFlushIntervalParsed, err := time.ParseDuration(bannerStatsFlushInterval)
+		if err != nil {
+			return nil, fmt.Errorf("environment variable BANNER_STATS_FLUSH_INTERVAL parsing error: %w", err)
+		}
+		config.Banner.StatsFlushInterval = bannerStatsFlushIntervalParsed
+	}
+
+	localesDefault, ok := os.LookupEnv("LOCALES_DEFAULT")
+	if ok {
+		config.Locales.Default = localesDefault
+	}
+
+	breachedPasswordsFile := config.Auth.PasswordPolicy.BreachedPasswordsFile
+	if breachedPasswordsFile != "" && !filepath.IsAbs(breachedPasswordsFile) {
+		config.Auth.PasswordPolicy.BreachedPasswordsFile = filepath.Join(filepath.Dir(yamlFilePath), breachedPasswordsFile)
+	}
+
 	return &config, nil
 }
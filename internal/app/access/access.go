@@ -0,0 +1,25 @@
+package access
+
+import (
+	"context"
+
+	"github.com/NikolaB131-org/banner-service/internal/entity"
+)
+
+type ctxKey struct{}
+
+func NewContext(ctx context.Context, access entity.Access) context.Context {
+	return context.WithValue(ctx, ctxKey{}, access)
+}
+
+// System is access of trusted internal callers (startup, background jobs), they are not restricted.
+// Calls without access in context are denied, so it must be set explicitly
+func System() entity.Access {
+	return entity.Access{IsSystem: true}
+}
+
+// FromContext returns access stored in ctx, ok is false if there is none
+func FromContext(ctx context.Context) (entity.Access, bool) {
+	access, ok := ctx.Value(ctxKey{}).(entity.Access)
+	return access, ok
+}
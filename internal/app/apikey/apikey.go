@@ -0,0 +1,36 @@
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+)
+
+const (
+	// keyPrefix makes keys recognizable, e.g. by secret scanners
+	keyPrefix = "bnr_"
+	// prefixLength is length of key beginning which is stored in plain text to let admins identify keys
+	prefixLength = len(keyPrefix) + 8
+)
+
+// Generate returns a new random key and its public prefix
+func Generate() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	key := keyPrefix + base64.RawURLEncoding.EncodeToString(b)
+	return key, key[:prefixLength], nil
+}
+
+// IsWellFormed cheaply rejects garbage before it reaches database
+func IsWellFormed(key string) bool {
+	return strings.HasPrefix(key, keyPrefix) && len(key) > prefixLength
+}
+
+// Hash is used to store only key hashes, plain sha256 is enough for random keys
+func Hash(key string) []byte {
+	hash := sha256.Sum256([]byte(key))
+	return hash[:]
+}
@@ -1,44 +1,267 @@
 package jwt
 
 import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"math/big"
+	"os"
 	"time"
 
+	"github.com/NikolaB131-org/banner-service/config"
 	"github.com/golang-jwt/jwt/v5"
 )
 
-type JWTClaims struct {
-	jwt.RegisteredClaims
-	UserID   string `json:"id"`
-	Username string `json:"username"`
+type (
+	JWTClaims struct {
+		jwt.RegisteredClaims
+		UserID    string `json:"id"`
+		Username  string `json:"username"`
+		SessionID string `json:"sid"`
+	}
+
+	// KeySet signs tokens with the active key and verifies tokens signed by any of its keys,
+	// so tokens issued before rotation stay valid until they expire while the old key is kept in config
+	KeySet struct {
+		activeKey *key
+		keys      []*key // in config order
+		// hmacSecret signs tokens if there are no asymmetric keys, otherwise it verifies tokens without kid,
+		// issued before switching to asymmetric keys, until hmacUntil
+		hmacSecret []byte
+		hmacUntil  time.Time
+	}
+
+	key struct {
+		id         string
+		method     jwt.SigningMethod
+		privateKey crypto.PrivateKey // nil for retired keys
+		publicKey  crypto.PublicKey
+	}
+
+	JWKS struct {
+		Keys []JWK `json:"keys"`
+	}
+
+	// JWK is a public key in RFC 7517 format
+	JWK struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		Use string `json:"use"`
+		Alg string `json:"alg"`
+		// RSA
+		N string `json:"n,omitempty"`
+		E string `json:"e,omitempty"`
+		// Ed25519
+		Crv string `json:"crv,omitempty"`
+		X   string `json:"x,omitempty"`
+	}
+)
+
+var (
+	ErrUnknownKey     = errors.New("token is signed by unknown key")
+	ErrInvalidToken   = errors.New("invalid token")
+	ErrNoSigningKey   = errors.New("neither signing keys nor sign secret are configured")
+	ErrExampleSecret  = errors.New("sign secret of example config must not be used, set a random one")
+	ErrKeyUnsupported = errors.New("unsupported signing key algorithm")
+)
+
+// exampleSignSecret was shipped in config.yml, tokens signed by it can be forged by anyone
+const exampleSignSecret = "abracadabra"
+
+// NewKeySet loads signing keys from files listed in config,
+// without signing keys tokens are signed by HS256 with sign secret
+func NewKeySet(auth config.Auth) (*KeySet, error) {
+	if auth.SignSecret == exampleSignSecret {
+		return nil, ErrExampleSecret
+	}
+
+	keySet := &KeySet{hmacUntil: auth.SignSecretUntil}
+	if auth.SignSecret != "" {
+		keySet.hmacSecret = []byte(auth.SignSecret)
+	}
+
+	for _, keyConfig := range auth.SigningKeys {
+		if keyConfig.ID == "" {
+			return nil, fmt.Errorf("signing key id is required")
+		}
+		if keySet.key(keyConfig.ID) != nil {
+			return nil, fmt.Errorf("signing key id %s is used more than once", keyConfig.ID)
+		}
+		k, err := loadKey(keyConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load signing key %s: %w", keyConfig.ID, err)
+		}
+		keySet.keys = append(keySet.keys, k)
+	}
+
+	if len(keySet.keys) > 0 {
+		// Tokens without kid are verified by sign secret only if it is explicitly kept for a while
+		if keySet.hmacUntil.IsZero() {
+			keySet.hmacSecret = nil
+		}
+		activeKeyID := auth.ActiveKeyID
+		if activeKeyID == "" {
+			activeKeyID = keySet.keys[0].id
+		}
+		keySet.activeKey = keySet.key(activeKeyID)
+		if keySet.activeKey == nil {
+			return nil, fmt.Errorf("active signing key %s is not configured", activeKeyID)
+		}
+		if keySet.activeKey.privateKey == nil {
+			return nil, fmt.Errorf("active signing key %s has no private key", activeKeyID)
+		}
+	} else if keySet.hmacSecret == nil {
+		return nil, ErrNoSigningKey
+	}
+
+	return keySet, nil
 }
 
-func Generate(signSecret string, tokenTTL time.Duration, id string, username string) (string, error) {
+func (s *KeySet) Generate(tokenTTL time.Duration, id string, username string, sessionID string) (string, error) {
 	claims := JWTClaims{
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(tokenTTL)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
-		UserID:   id,
-		Username: username,
+		UserID:    id,
+		Username:  username,
+		SessionID: sessionID,
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signedToken, err := token.SignedString([]byte(signSecret))
-	return signedToken, err
+
+	if s.activeKey == nil {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		return token.SignedString(s.hmacSecret)
+	}
+
+	token := jwt.NewWithClaims(s.activeKey.method, claims)
+	token.Header["kid"] = s.activeKey.id
+	return token.SignedString(s.activeKey.privateKey)
 }
 
-func Parse(token string, signSecret string) (*JWTClaims, error) {
-	parsedToken, err := jwt.ParseWithClaims(token, &JWTClaims{}, func(t *jwt.Token) (any, error) {
-		return []byte(signSecret), nil
-	})
+func (s *KeySet) Parse(token string) (*JWTClaims, error) {
+	parsedToken, err := jwt.ParseWithClaims(token, &JWTClaims{}, s.verificationKey)
 	if err != nil {
 		return nil, err
 	}
 
 	claims, ok := parsedToken.Claims.(*JWTClaims)
 	if !parsedToken.Valid || !ok {
-		return nil, fmt.Errorf("invalid token")
+		return nil, ErrInvalidToken
 	}
 
 	return claims, nil
 }
+
+// JWKS returns public keys of all asymmetric keys, including retired ones which still verify tokens
+func (s *KeySet) JWKS() JWKS {
+	jwks := JWKS{Keys: make([]JWK, 0, len(s.keys))}
+	for _, k := range s.keys {
+		jwk := JWK{Kid: k.id, Use: "sig", Alg: k.method.Alg()}
+		switch publicKey := k.publicKey.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(publicKey)
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+	return jwks
+}
+
+// verificationKey picks key by kid header and checks that token algorithm matches the key,
+// otherwise public key could be used as HMAC secret by a forged token
+func (s *KeySet) verificationKey(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		if s.hmacSecret == nil || token.Method != jwt.SigningMethodHS256 {
+			return nil, ErrUnknownKey
+		}
+		if s.activeKey != nil && time.Now().After(s.hmacUntil) {
+			return nil, ErrUnknownKey
+		}
+		return s.hmacSecret, nil
+	}
+
+	k := s.key(kid)
+	if k == nil {
+		return nil, ErrUnknownKey
+	}
+	if token.Method.Alg() != k.method.Alg() {
+		return nil, fmt.Errorf("%w: algorithm %s does not match key", ErrInvalidToken, token.Method.Alg())
+	}
+	return k.publicKey, nil
+}
+
+func (s *KeySet) key(id string) *key {
+	for _, k := range s.keys {
+		if k.id == id {
+			return k
+		}
+	}
+	return nil
+}
+
+func loadKey(keyConfig config.SigningKey) (*key, error) {
+	k := &key{id: keyConfig.ID}
+
+	var parsePrivate func([]byte) (crypto.PrivateKey, crypto.PublicKey, error)
+	var parsePublic func([]byte) (crypto.PublicKey, error)
+	switch keyConfig.Algorithm {
+	case jwt.SigningMethodRS256.Alg():
+		k.method = jwt.SigningMethodRS256
+		parsePrivate = func(data []byte) (crypto.PrivateKey, crypto.PublicKey, error) {
+			privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(data)
+			if err != nil {
+				return nil, nil, err
+			}
+			return privateKey, &privateKey.PublicKey, nil
+		}
+		parsePublic = func(data []byte) (crypto.PublicKey, error) {
+			return jwt.ParseRSAPublicKeyFromPEM(data)
+		}
+	case jwt.SigningMethodEdDSA.Alg():
+		k.method = jwt.SigningMethodEdDSA
+		parsePrivate = func(data []byte) (crypto.PrivateKey, crypto.PublicKey, error) {
+			privateKey, err := jwt.ParseEdPrivateKeyFromPEM(data)
+			if err != nil {
+				return nil, nil, err
+			}
+			return privateKey, privateKey.(ed25519.PrivateKey).Public(), nil
+		}
+		parsePublic = jwt.ParseEdPublicKeyFromPEM
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrKeyUnsupported, keyConfig.Algorithm)
+	}
+
+	switch {
+	case keyConfig.PrivateKeyFile != "":
+		data, err := os.ReadFile(keyConfig.PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		k.privateKey, k.publicKey, err = parsePrivate(data)
+		if err != nil {
+			return nil, err
+		}
+	case keyConfig.PublicKeyFile != "":
+		data, err := os.ReadFile(keyConfig.PublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		k.publicKey, err = parsePublic(data)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("either private_key_file or public_key_file is required")
+	}
+
+	return k, nil
+}
@@ -0,0 +1,201 @@
+package locale
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/NikolaB131-org/banner-service/config"
+)
+
+// maxAcceptLanguageTags limits work spent on Accept-Language header sent by client
+const maxAcceptLanguageTags = 16
+
+var ErrInvalidLocale = errors.New("invalid locale")
+
+// Locales matches locales requested by users against supported ones
+// and builds chains of locales to look banner content up in
+type Locales struct {
+	defaultLocale string
+	supported     map[string]bool
+	fallback      map[string][]string
+}
+
+func New(locales config.Locales) (*Locales, error) {
+	defaultLocale, err := Normalize(locales.Default)
+	if err != nil {
+		return nil, fmt.Errorf("default locale: %w", err)
+	}
+	l := &Locales{
+		defaultLocale: defaultLocale,
+		supported:     map[string]bool{defaultLocale: true},
+		fallback:      make(map[string][]string, len(locales.Fallback)),
+	}
+
+	for _, tag := range locales.Supported {
+		locale, err := Normalize(tag)
+		if err != nil {
+			return nil, fmt.Errorf("supported locale: %w", err)
+		}
+		l.supported[locale] = true
+	}
+
+	for tag, fallbackTags := range locales.Fallback {
+		locale, err := Normalize(tag)
+		if err != nil {
+			return nil, fmt.Errorf("fallback locale: %w", err)
+		}
+		if !l.supported[locale] {
+			return nil, fmt.Errorf("fallback is configured for unsupported locale %s", locale)
+		}
+		for _, fallbackTag := range fallbackTags {
+			fallbackLocale, err := Normalize(fallbackTag)
+			if err != nil {
+				return nil, fmt.Errorf("fallback locale: %w", err)
+			}
+			if !l.supported[fallbackLocale] {
+				return nil, fmt.Errorf("fallback locale %s of %s is not supported", fallbackLocale, locale)
+			}
+			l.fallback[locale] = append(l.fallback[locale], fallbackLocale)
+		}
+	}
+
+	return l, nil
+}
+
+func (l *Locales) Default() string {
+	return l.defaultLocale
+}
+
+// IsSupported expects normalized locale
+func (l *Locales) IsSupported(locale string) bool {
+	return l.supported[locale]
+}
+
+// Match returns the first preferred locale which is supported itself or by its base language,
+// the default locale if there is no such one. Invalid preferred locales are skipped
+func (l *Locales) Match(preferred []string) string {
+	for _, tag := range preferred {
+		locale, err := Normalize(tag)
+		if err != nil {
+			continue
+		}
+		if l.supported[locale] {
+			return locale
+		}
+		if base := baseLanguage(locale); l.supported[base] {
+			return base
+		}
+	}
+	return l.defaultLocale
+}
+
+// Chain returns locales to look content up in for supported locale: the locale itself,
+// its configured fallbacks, its base language and the default locale
+func (l *Locales) Chain(locale string) []string {
+	chain := []string{locale}
+	add := func(locale string) {
+		if l.supported[locale] && !slices.Contains(chain, locale) {
+			chain = append(chain, locale)
+		}
+	}
+	for _, fallbackLocale := range l.fallback[locale] {
+		add(fallbackLocale)
+	}
+	add(baseLanguage(locale))
+	add(l.defaultLocale)
+	return chain
+}
+
+// Normalize brings BCP 47 language tag to canonical case (pt_br -> pt-BR, zh-hant-tw -> zh-Hant-TW)
+func Normalize(tag string) (string, error) {
+	subtags := strings.Split(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"), "-")
+
+	language := subtags[0]
+	if len(language) < 2 || len(language) > 3 || !isAlphanumeric(language, false) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidLocale, tag)
+	}
+	subtags[0] = strings.ToLower(language)
+
+	for i, subtag := range subtags[1:] {
+		if subtag == "" || len(subtag) > 8 || !isAlphanumeric(subtag, true) {
+			return "", fmt.Errorf("%w: %q", ErrInvalidLocale, tag)
+		}
+		switch {
+		case len(subtag) == 4 && isAlphanumeric(subtag, false): // script
+			subtags[i+1] = strings.ToUpper(subtag[:1]) + strings.ToLower(subtag[1:])
+		case len(subtag) == 2: // region
+			subtags[i+1] = strings.ToUpper(subtag)
+		default:
+			subtags[i+1] = strings.ToLower(subtag)
+		}
+	}
+
+	return strings.Join(subtags, "-"), nil
+}
+
+// ParseAcceptLanguage returns language tags of Accept-Language header ordered by preference,
+// wildcard and tags with zero or invalid quality are skipped
+func ParseAcceptLanguage(header string) []string {
+	type weightedTag struct {
+		tag     string
+		quality float64
+	}
+
+	parts := strings.SplitN(header, ",", maxAcceptLanguageTags+1)
+	if len(parts) > maxAcceptLanguageTags {
+		parts = parts[:maxAcceptLanguageTags]
+	}
+
+	var tags []weightedTag
+	for _, part := range parts {
+		tag, params, _ := strings.Cut(part, ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		if quality <= 0 {
+			continue
+		}
+
+		tags = append(tags, weightedTag{tag: tag, quality: quality})
+	}
+
+	slices.SortStableFunc(tags, func(a, b weightedTag) int {
+		return cmp.Compare(b.quality, a.quality)
+	})
+
+	result := make([]string, 0, len(tags))
+	for _, t := range tags {
+		result = append(result, t.tag)
+	}
+	return result
+}
+
+func baseLanguage(locale string) string {
+	base, _, _ := strings.Cut(locale, "-")
+	return base
+}
+
+func isAlphanumeric(s string, allowDigits bool) bool {
+	for _, r := range s {
+		isLetter := (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+		isDigit := r >= '0' && r <= '9'
+		if !isLetter && !(allowDigits && isDigit) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,120 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/redis/go-redis/v9"
+)
+
+const namespace = "banner_service"
+
+const (
+	CacheHit   = "hit"
+	CacheMiss  = "miss"
+	CacheError = "error"
+)
+
+var (
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Number of handled HTTP requests.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of handled HTTP requests.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	BannerCacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "banner_cache_requests_total",
+		Help:      "Number of user banner cache lookups by result.",
+	}, []string{"result"})
+
+	BannerLocalCacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "banner_local_cache_requests_total",
+		Help:      "Number of user banner in-process cache lookups by result.",
+	}, []string{"result"})
+)
+
+// RegisterPostgresPool exposes pgxpool stats, they are read on every scrape
+func RegisterPostgresPool(pool *pgxpool.Pool) {
+	prometheus.MustRegister(&postgresPoolCollector{pool: pool})
+}
+
+// RegisterRedisPool exposes redis client pool stats, they are read on every scrape
+func RegisterRedisPool(client *redis.Client) {
+	prometheus.MustRegister(&redisPoolCollector{client: client})
+}
+
+var (
+	postgresAcquiredConns = prometheus.NewDesc(namespace+"_postgres_pool_acquired_connections", "Number of currently acquired connections.", nil, nil)
+	postgresIdleConns     = prometheus.NewDesc(namespace+"_postgres_pool_idle_connections", "Number of currently idle connections.", nil, nil)
+	postgresTotalConns    = prometheus.NewDesc(namespace+"_postgres_pool_total_connections", "Total number of connections in the pool.", nil, nil)
+	postgresMaxConns      = prometheus.NewDesc(namespace+"_postgres_pool_max_connections", "Maximum size of the pool.", nil, nil)
+	postgresAcquires      = prometheus.NewDesc(namespace+"_postgres_pool_acquires_total", "Number of successful acquires from the pool.", nil, nil)
+	postgresEmptyAcquires = prometheus.NewDesc(namespace+"_postgres_pool_empty_acquires_total", "Number of acquires that had to wait for a connection.", nil, nil)
+	postgresAcquireWait   = prometheus.NewDesc(namespace+"_postgres_pool_acquire_wait_seconds_total", "Total time spent waiting for a connection.", nil, nil)
+)
+
+type postgresPoolCollector struct {
+	pool *pgxpool.Pool
+}
+
+func (c *postgresPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- postgresAcquiredConns
+	ch <- postgresIdleConns
+	ch <- postgresTotalConns
+	ch <- postgresMaxConns
+	ch <- postgresAcquires
+	ch <- postgresEmptyAcquires
+	ch <- postgresAcquireWait
+}
+
+func (c *postgresPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+	ch <- prometheus.MustNewConstMetric(postgresAcquiredConns, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(postgresIdleConns, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(postgresTotalConns, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(postgresMaxConns, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(postgresAcquires, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(postgresEmptyAcquires, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(postgresAcquireWait, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+}
+
+var (
+	redisHits       = prometheus.NewDesc(namespace+"_redis_pool_hits_total", "Number of times free connection was found in the pool.", nil, nil)
+	redisMisses     = prometheus.NewDesc(namespace+"_redis_pool_misses_total", "Number of times free connection was not found in the pool.", nil, nil)
+	redisTimeouts   = prometheus.NewDesc(namespace+"_redis_pool_timeouts_total", "Number of times a wait timeout occurred.", nil, nil)
+	redisTotalConns = prometheus.NewDesc(namespace+"_redis_pool_total_connections", "Number of total connections in the pool.", nil, nil)
+	redisIdleConns  = prometheus.NewDesc(namespace+"_redis_pool_idle_connections", "Number of idle connections in the pool.", nil, nil)
+	redisStaleConns = prometheus.NewDesc(namespace+"_redis_pool_stale_connections_total", "Number of stale connections removed from the pool.", nil, nil)
+)
+
+type redisPoolCollector struct {
+	client *redis.Client
+}
+
+func (c *redisPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- redisHits
+	ch <- redisMisses
+	ch <- redisTimeouts
+	ch <- redisTotalConns
+	ch <- redisIdleConns
+	ch <- redisStaleConns
+}
+
+func (c *redisPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.client.PoolStats()
+	ch <- prometheus.MustNewConstMetric(redisHits, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(redisMisses, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(redisTimeouts, prometheus.CounterValue, float64(stats.Timeouts))
+	ch <- prometheus.MustNewConstMetric(redisTotalConns, prometheus.GaugeValue, float64(stats.TotalConns))
+	ch <- prometheus.MustNewConstMetric(redisIdleConns, prometheus.GaugeValue, float64(stats.IdleConns))
+	ch <- prometheus.MustNewConstMetric(redisStaleConns, prometheus.CounterValue, float64(stats.StaleConns))
+}
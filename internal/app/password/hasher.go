@@ -0,0 +1,181 @@
+package password
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/NikolaB131-org/banner-service/config"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	AlgorithmBcrypt   = "bcrypt"
+	AlgorithmArgon2id = "argon2id"
+
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+	// argon2Prefix starts hashes in PHC string format: $argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>
+	argon2Prefix = "$" + AlgorithmArgon2id + "$"
+)
+
+var (
+	ErrUnknownAlgorithm = errors.New("unknown password hashing algorithm")
+	ErrMalformedHash    = errors.New("malformed password hash")
+)
+
+type (
+	// Hasher hashes passwords with configured algorithm and verifies hashes of both supported algorithms,
+	// so hashes created before changing configuration keep working until they are upgraded on login
+	Hasher struct {
+		algorithm  string
+		bcryptCost int
+		argon2     argon2Params
+		// dummyHash is verified instead of missing hash, so checking unknown user takes the same time
+		dummyHash func() []byte
+	}
+
+	argon2Params struct {
+		memory      uint32 // KiB
+		iterations  uint32
+		parallelism uint8
+		keyLength   uint32
+	}
+)
+
+func NewHasher(hashing config.PasswordHashing) (*Hasher, error) {
+	h := &Hasher{
+		algorithm:  hashing.Algorithm,
+		bcryptCost: hashing.BcryptCost,
+		argon2: argon2Params{
+			memory:      hashing.Argon2Memory,
+			iterations:  hashing.Argon2Iterations,
+			parallelism: hashing.Argon2Parallelism,
+			keyLength:   argon2KeyLength,
+		},
+	}
+
+	switch h.algorithm {
+	case AlgorithmBcrypt:
+		if h.bcryptCost < bcrypt.MinCost || h.bcryptCost > bcrypt.MaxCost {
+			return nil, fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+	case AlgorithmArgon2id:
+		if h.argon2.memory == 0 || h.argon2.iterations == 0 || h.argon2.parallelism == 0 {
+			return nil, fmt.Errorf("argon2 memory, iterations and parallelism must be positive")
+		}
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownAlgorithm, h.algorithm)
+	}
+
+	h.dummyHash = sync.OnceValue(func() []byte {
+		hash, err := h.Hash("dummy password")
+		if err != nil {
+			panic(fmt.Sprintf("failed to generate dummy password hash: %s", err.Error()))
+		}
+		return hash
+	})
+
+	return h, nil
+}
+
+func (h *Hasher) Hash(password string) ([]byte, error) {
+	if h.algorithm == AlgorithmBcrypt {
+		return bcrypt.GenerateFromPassword([]byte(password), h.bcryptCost)
+	}
+
+	salt := make([]byte, argon2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	key := argon2.IDKey([]byte(password), salt, h.argon2.iterations, h.argon2.memory, h.argon2.parallelism, h.argon2.keyLength)
+
+	return []byte(fmt.Sprintf(
+		"%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2Prefix, argon2.Version, h.argon2.memory, h.argon2.iterations, h.argon2.parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key),
+	)), nil
+}
+
+// Verify reports whether password matches hash. Empty hash (unknown user or user without password)
+// never matches, but takes the same time as a real check
+func (h *Hasher) Verify(hash []byte, password string) bool {
+	if len(hash) == 0 {
+		_ = h.verify(h.dummyHash(), password)
+		return false
+	}
+	return h.verify(hash, password)
+}
+
+// NeedsRehash reports whether hash was created by another algorithm or with weaker parameters than configured
+func (h *Hasher) NeedsRehash(hash []byte) bool {
+	if len(hash) == 0 {
+		return false
+	}
+
+	if !bytes.HasPrefix(hash, []byte(argon2Prefix)) {
+		if h.algorithm != AlgorithmBcrypt {
+			return true
+		}
+		cost, err := bcrypt.Cost(hash)
+		return err != nil || cost < h.bcryptCost
+	}
+
+	if h.algorithm != AlgorithmArgon2id {
+		return true
+	}
+	params, _, _, err := parseArgon2(hash)
+	return err != nil ||
+		params.memory < h.argon2.memory ||
+		params.iterations < h.argon2.iterations ||
+		params.parallelism < h.argon2.parallelism ||
+		params.keyLength < h.argon2.keyLength
+}
+
+func (h *Hasher) verify(hash []byte, password string) bool {
+	if !bytes.HasPrefix(hash, []byte(argon2Prefix)) {
+		return bcrypt.CompareHashAndPassword(hash, []byte(password)) == nil
+	}
+
+	params, salt, key, err := parseArgon2(hash)
+	if err != nil {
+		return false
+	}
+	otherKey := argon2.IDKey([]byte(password), salt, params.iterations, params.memory, params.parallelism, params.keyLength)
+	return subtle.ConstantTimeCompare(key, otherKey) == 1
+}
+
+func parseArgon2(hash []byte) (argon2Params, []byte, []byte, error) {
+	// "", "argon2id", "v=19", "m=65536,t=3,p=2", salt, key
+	parts := strings.Split(string(hash), "$")
+	if len(parts) != 6 {
+		return argon2Params{}, nil, nil, ErrMalformedHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return argon2Params{}, nil, nil, ErrMalformedHash
+	}
+	var params argon2Params
+	_, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.iterations, &params.parallelism)
+	if err != nil {
+		return argon2Params{}, nil, nil, ErrMalformedHash
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return argon2Params{}, nil, nil, ErrMalformedHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return argon2Params{}, nil, nil, ErrMalformedHash
+	}
+	params.keyLength = uint32(len(key))
+
+	return params, salt, key, nil
+}
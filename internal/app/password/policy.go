@@ -0,0 +1,115 @@
+package password
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/NikolaB131-org/banner-service/config"
+)
+
+// maxLength is in bytes, bcrypt does not accept longer passwords
+const maxLength = 72
+
+var (
+	ErrTooShort          = errors.New("password is too short")
+	ErrTooLong           = fmt.Errorf("password must not be longer than %d bytes", maxLength)
+	ErrBreached          = errors.New("password is found in list of breached passwords")
+	ErrSimilarToUsername = errors.New("password is too similar to username")
+)
+
+// Policy validates passwords chosen by users
+type Policy struct {
+	minLength               int
+	breached                map[string]struct{}
+	rejectSimilarToUsername bool
+}
+
+// NewPolicy loads breached passwords list, one password per line
+func NewPolicy(policy config.PasswordPolicy) (*Policy, error) {
+	p := &Policy{
+		minLength:               policy.MinLength,
+		breached:                make(map[string]struct{}),
+		rejectSimilarToUsername: policy.RejectSimilarToUsername,
+	}
+
+	if policy.BreachedPasswordsFile != "" {
+		file, err := os.Open(policy.BreachedPasswordsFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open breached passwords file: %w", err)
+		}
+		defer file.Close()
+
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line != "" {
+				p.breached[strings.ToLower(line)] = struct{}{}
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read breached passwords file: %w", err)
+		}
+	}
+
+	return p, nil
+}
+
+func (p *Policy) Validate(username string, password string) error {
+	if utf8.RuneCountInString(password) < p.minLength {
+		return fmt.Errorf("%w, minimum length is %d", ErrTooShort, p.minLength)
+	}
+	if len(password) > maxLength {
+		return ErrTooLong
+	}
+	// Lists contain passwords in many case variations, matching them case-insensitively is stricter
+	if _, ok := p.breached[strings.ToLower(password)]; ok {
+		return ErrBreached
+	}
+	if p.rejectSimilarToUsername && isSimilar(strings.ToLower(username), strings.ToLower(password)) {
+		return ErrSimilarToUsername
+	}
+
+	return nil
+}
+
+// isSimilar rejects passwords containing username, reversed username or differing from it by a couple of edits
+func isSimilar(username string, password string) bool {
+	if username == "" {
+		return false
+	}
+
+	reversed := []rune(username)
+	for i, j := 0, len(reversed)-1; i < j; i, j = i+1, j-1 {
+		reversed[i], reversed[j] = reversed[j], reversed[i]
+	}
+
+	return strings.Contains(password, username) ||
+		strings.Contains(password, string(reversed)) ||
+		strings.Contains(username, password) ||
+		levenshtein(username, password) <= 2
+}
+
+func levenshtein(a string, b string) int {
+	ar, br := []rune(a), []rune(b)
+	prev := make([]int, len(br)+1)
+	curr := make([]int, len(br)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ar); i++ {
+		curr[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(br)]
+}
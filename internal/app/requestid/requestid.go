@@ -0,0 +1,15 @@
+package requestid
+
+import "context"
+
+type ctxKey struct{}
+
+func NewContext(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, requestID)
+}
+
+// FromContext returns request id stored in ctx or empty string if there is none
+func FromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(ctxKey{}).(string)
+	return requestID
+}
@@ -0,0 +1,103 @@
+package v1
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/NikolaB131-org/banner-service/internal/controller/http/v1/middlewares"
+	"github.com/NikolaB131-org/banner-service/internal/entity"
+	"github.com/NikolaB131-org/banner-service/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	APIKeyRoutes struct {
+		apiKeyService service.APIKeyService
+	}
+
+	APIKeyUri struct {
+		ID string `uri:"id" binding:"required,uuid"`
+	}
+
+	APIKeyCreateBody struct {
+		Name      string     `json:"name" binding:"required"`
+		Scopes    []string   `json:"scopes"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	}
+)
+
+func newAPIKeyRoutes(g *gin.RouterGroup, middlewares middlewares.Middlewares, apiKeyService service.APIKeyService) {
+	apiKeyR := APIKeyRoutes{apiKeyService: apiKeyService}
+
+	apiKey := g.Group("/api_key", middlewares.OnlyAuth(), middlewares.RequirePermission(entity.PermissionUserManage))
+	{
+		apiKey.GET("/", apiKeyR.getAll)
+		apiKey.POST("/", apiKeyR.create)
+		apiKey.DELETE("/:id", apiKeyR.revoke)
+	}
+}
+
+func (r *APIKeyRoutes) getAll(c *gin.Context) {
+	keys, err := r.apiKeyService.GetAPIKeys(c)
+	if err != nil {
+		slog.Error(err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed get api keys"})
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+func (r *APIKeyRoutes) create(c *gin.Context) {
+	var body APIKeyCreateBody
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "body parsing error"})
+		return
+	}
+	if body.Scopes == nil {
+		body.Scopes = []string{}
+	}
+
+	key, plainKey, err := r.apiKeyService.Create(c, body.Name, body.Scopes, body.ExpiresAt, c.GetString("actor_id"))
+	if err != nil {
+		slog.Error(err.Error())
+		switch {
+		case errors.Is(err, service.ErrUnknownPermission),
+			errors.Is(err, service.ErrAPIKeyScopeNotAllowed),
+			errors.Is(err, service.ErrAPIKeyExpiresInPast):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create api key"})
+		}
+		return
+	}
+
+	// Plain key is shown only once, it can not be restored later
+	c.JSON(http.StatusCreated, gin.H{"api_key_id": key.ID, "key": plainKey, "prefix": key.Prefix})
+}
+
+func (r *APIKeyRoutes) revoke(c *gin.Context) {
+	var uri APIKeyUri
+
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "specified id is not a uuid"})
+		return
+	}
+
+	err := r.apiKeyService.Revoke(c, uri.ID, c.GetString("actor_id"))
+	if err != nil {
+		slog.Error(err.Error())
+		switch {
+		case errors.Is(err, service.ErrAPIKeyNotFound):
+			c.Status(http.StatusNotFound)
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke api key"})
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
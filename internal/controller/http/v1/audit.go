@@ -0,0 +1,86 @@
+package v1
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/NikolaB131-org/banner-service/internal/controller/http/v1/middlewares"
+	"github.com/NikolaB131-org/banner-service/internal/entity"
+	"github.com/NikolaB131-org/banner-service/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	AuditRoutes struct {
+		auditService service.AuditService
+	}
+
+	AuditGetQuery struct {
+		ActorID   *string    `form:"actor_id"`
+		BannerID  *int       `form:"banner_id"`
+		FeatureID *int       `form:"feature_id"`
+		TagID     *int       `form:"tag_id"`
+		From      *time.Time `form:"from"`
+		To        *time.Time `form:"to"`
+		Cursor    string     `form:"cursor"`
+		Limit     *int       `form:"limit"`
+	}
+)
+
+func newAuditRoutes(g *gin.RouterGroup, middlewares middlewares.Middlewares, auditService service.AuditService) {
+	auditR := AuditRoutes{auditService: auditService}
+
+	audit := g.Group("/audit", middlewares.OnlyAuth(), middlewares.RequirePermission(entity.PermissionAuditRead))
+	{
+		audit.GET("/", auditR.get)
+	}
+}
+
+func (r *AuditRoutes) get(c *gin.Context) {
+	var query AuditGetQuery
+
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "query parsing error"})
+		return
+	}
+
+	filter := entity.AuditFilter{
+		ActorID: query.ActorID,
+		From:    query.From,
+		To:      query.To,
+	}
+	entityFilters := 0
+	for entityType, entityID := range map[string]*int{
+		entity.AuditEntityBanner:  query.BannerID,
+		entity.AuditEntityFeature: query.FeatureID,
+		entity.AuditEntityTag:     query.TagID,
+	} {
+		if entityID != nil {
+			id := strconv.Itoa(*entityID)
+			filter.EntityType = &entityType
+			filter.EntityID = &id
+			entityFilters++
+		}
+	}
+	if entityFilters > 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "only one of banner_id, feature_id and tag_id can be specified"})
+		return
+	}
+
+	records, nextCursor, err := r.auditService.GetRecords(c, filter, query.Cursor, query.Limit)
+	if err != nil {
+		slog.Error(err.Error())
+		switch {
+		case errors.Is(err, service.ErrInvalidCursor):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get audit records"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"records": records, "next_cursor": nextCursor})
+}
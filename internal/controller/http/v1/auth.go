@@ -3,14 +3,19 @@ package v1
 import (
 	"errors"
 	"log/slog"
+	"math"
 	"net/http"
+	"strconv"
 
+	"github.com/NikolaB131-org/banner-service/internal/controller/http/v1/middlewares"
 	"github.com/NikolaB131-org/banner-service/internal/service"
 	"github.com/gin-gonic/gin"
 )
 
 type AuthRoutes struct {
-	authService service.AuthService
+	authService          service.AuthService
+	loginThrottleService service.LoginThrottleService
+	passwordService      service.PasswordService
 }
 
 type AuthBody struct {
@@ -18,13 +23,42 @@ type AuthBody struct {
 	Password string `json:"password"`
 }
 
-func newAuthRoutes(g *gin.RouterGroup, authService service.AuthService) {
-	authR := AuthRoutes{authService: authService}
+type RefreshBody struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type ChangePasswordBody struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+type PasswordResetBody struct {
+	Username string `json:"username" binding:"required"`
+}
+
+type PasswordResetConfirmBody struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+func newAuthRoutes(
+	g *gin.RouterGroup,
+	middlewares middlewares.Middlewares,
+	authService service.AuthService,
+	loginThrottleService service.LoginThrottleService,
+	passwordService service.PasswordService,
+) {
+	authR := AuthRoutes{authService: authService, loginThrottleService: loginThrottleService, passwordService: passwordService}
 
 	auth := g.Group("/auth")
 	{
 		auth.POST("/login", authR.login)
 		auth.POST("/register", authR.register)
+		auth.POST("/refresh", authR.refresh)
+		auth.POST("/logout", middlewares.OnlyAuth(), authR.logout)
+		auth.POST("/password", middlewares.OnlyAuth(), authR.changePassword)
+		auth.POST("/password_reset", authR.requestPasswordReset)
+		auth.POST("/password_reset/confirm", authR.resetPassword)
 	}
 }
 
@@ -44,14 +78,73 @@ func (r *AuthRoutes) login(c *gin.Context) {
 		return
 	}
 
-	token, err := r.authService.Login(c, body.Username, body.Password)
+	// Attempt is counted before password is verified, so concurrent guesses can not pass the limit
+	ip := c.ClientIP()
+	err := r.loginThrottleService.Reserve(c, body.Username, ip)
+	if err != nil {
+		respondThrottled(c, err)
+		return
+	}
+
+	token, refreshToken, err := r.authService.Login(c, body.Username, body.Password)
+	if err != nil {
+		// Only wrong credentials stay counted as failure
+		if !errors.Is(err, service.ErrInvalidCredentials) {
+			if err := r.loginThrottleService.Release(c, body.Username, ip); err != nil {
+				slog.Error(err.Error())
+			}
+		}
+		switch {
+		case errors.Is(err, service.ErrInvalidCredentials):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrUserDisabled) || errors.Is(err, service.ErrPasswordResetRequired):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			slog.Error(err.Error())
+			c.Status(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if err := r.loginThrottleService.RegisterSuccess(c, body.Username, ip); err != nil {
+		slog.Error(err.Error())
+	}
+
+	c.JSON(http.StatusOK, gin.H{"token": token, "refresh_token": refreshToken})
+}
+
+func (r *AuthRoutes) refresh(c *gin.Context) {
+	var body RefreshBody
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "refresh_token is required"})
+		return
+	}
+
+	token, refreshToken, err := r.authService.Refresh(c, body.RefreshToken)
+	if err != nil {
+		slog.Error(err.Error())
+		switch {
+		case errors.Is(err, service.ErrInvalidToken) || errors.Is(err, service.ErrTokenReused):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		default:
+			c.Status(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"token": token, "refresh_token": refreshToken})
+}
+
+func (r *AuthRoutes) logout(c *gin.Context) {
+	err := r.authService.Logout(c, c.GetString("session_id"))
 	if err != nil {
 		slog.Error(err.Error())
 		c.Status(http.StatusInternalServerError)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"token": token})
+	c.Status(http.StatusNoContent)
 }
 
 func (r *AuthRoutes) register(c *gin.Context) {
@@ -74,6 +167,8 @@ func (r *AuthRoutes) register(c *gin.Context) {
 	if err != nil {
 		slog.Error(err.Error())
 		switch {
+		case errors.Is(err, service.ErrWeakPassword):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		case errors.Is(err, service.ErrUserAlreadyExists):
 			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
 		default:
@@ -84,3 +179,119 @@ func (r *AuthRoutes) register(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"user_id": id})
 }
+
+func (r *AuthRoutes) changePassword(c *gin.Context) {
+	var body ChangePasswordBody
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "current_password and new_password are required"})
+		return
+	}
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "password can be changed only with user token"})
+		return
+	}
+
+	ip := c.ClientIP()
+	err := r.loginThrottleService.ReservePasswordChange(c, userID, ip)
+	if err != nil {
+		respondThrottled(c, err)
+		return
+	}
+
+	token, refreshToken, err := r.passwordService.ChangePassword(c, userID, body.CurrentPassword, body.NewPassword)
+	if err != nil {
+		slog.Error(err.Error())
+		if !errors.Is(err, service.ErrInvalidCredentials) {
+			if err := r.loginThrottleService.ReleasePasswordChange(c, userID, ip); err != nil {
+				slog.Error(err.Error())
+			}
+		}
+		switch {
+		case errors.Is(err, service.ErrInvalidCredentials):
+			c.JSON(http.StatusForbidden, gin.H{"error": "current password is invalid"})
+		case errors.Is(err, service.ErrWeakPassword):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.Status(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if err := r.loginThrottleService.RegisterPasswordChangeSuccess(c, userID, ip); err != nil {
+		slog.Error(err.Error())
+	}
+
+	c.JSON(http.StatusOK, gin.H{"token": token, "refresh_token": refreshToken})
+}
+
+func (r *AuthRoutes) requestPasswordReset(c *gin.Context) {
+	var body PasswordResetBody
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "username is required"})
+		return
+	}
+
+	// Requests are counted for unknown usernames too, so lockout does not disclose which accounts exist
+	err := r.loginThrottleService.RegisterPasswordResetRequest(c, body.Username, c.ClientIP())
+	if err != nil {
+		respondThrottled(c, err)
+		return
+	}
+
+	err = r.passwordService.RequestReset(c, body.Username)
+	if err != nil {
+		slog.Error(err.Error())
+		switch {
+		case errors.Is(err, service.ErrPasswordResetUnavailable):
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		default:
+			c.Status(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+func (r *AuthRoutes) resetPassword(c *gin.Context) {
+	var body PasswordResetConfirmBody
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "token and new_password are required"})
+		return
+	}
+
+	err := r.passwordService.ResetPassword(c, body.Token, body.NewPassword)
+	if err != nil {
+		slog.Error(err.Error())
+		switch {
+		case errors.Is(err, service.ErrWeakPassword):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrInvalidResetToken) || errors.Is(err, service.ErrUserNotFound):
+			c.JSON(http.StatusBadRequest, gin.H{"error": service.ErrInvalidResetToken.Error()})
+		default:
+			c.Status(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// respondThrottled responds with Retry-After to LoginLockedError, other errors of throttle are internal
+func respondThrottled(c *gin.Context, err error) {
+	var lockedErr *service.LoginLockedError
+	switch {
+	case errors.As(err, &lockedErr):
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(lockedErr.RetryAfter.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	default:
+		slog.Error(err.Error())
+		c.Status(http.StatusInternalServerError)
+	}
+}
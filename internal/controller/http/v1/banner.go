@@ -5,48 +5,102 @@ import (
 	"log/slog"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/NikolaB131-org/banner-service/internal/controller/http/v1/middlewares"
+	"github.com/NikolaB131-org/banner-service/internal/entity"
 	"github.com/NikolaB131-org/banner-service/internal/service"
 	"github.com/gin-gonic/gin"
 )
 
 type (
 	BannerRoutes struct {
-		bannerService service.BannerService
+		bannerService      service.BannerService
+		bannerStatsService service.BannerStatsService
 	}
 
 	BannerGetQuery struct {
-		FeatureID *int `form:"feature_id"`
-		TagID     *int `form:"tag_id"`
-		Limit     *int `form:"limit"`
-		Offset    *int `form:"offset"`
+		FeatureID *int       `form:"feature_id"`
+		TagID     *int       `form:"tag_id"`
+		ActiveAt  *time.Time `form:"active_at"`
+		Limit     *int       `form:"limit"`
+		Offset    *int       `form:"offset"`
 	}
 
 	BannerCreateBody struct {
-		TagIDs    []int          `json:"tag_ids" binding:"required"`
-		FeatureID *int           `json:"feature_id" binding:"required"`
-		Content   map[string]any `json:"content" binding:"required"`
-		IsActive  *bool          `json:"is_active" binding:"required"`
+		TagIDs      []int          `json:"tag_ids" binding:"required"`
+		FeatureID   *int           `json:"feature_id" binding:"required"`
+		Content     map[string]any `json:"content" binding:"required"`
+		IsActive    *bool          `json:"is_active" binding:"required"`
+		ActiveFrom  *time.Time     `json:"active_from"`
+		ActiveUntil *time.Time     `json:"active_until"`
 	}
 
 	BannerUpdateBody struct {
-		TagIDs    []int          `json:"tag_ids"`
-		FeatureID *int           `json:"feature_id"`
-		Content   map[string]any `json:"content"`
-		IsActive  *bool          `json:"is_active"`
+		TagIDs      []int                      `json:"tag_ids"`
+		FeatureID   *int                       `json:"feature_id"`
+		Content     map[string]any             `json:"content"`
+		IsActive    *bool                      `json:"is_active"`
+		ActiveFrom  entity.Nullable[time.Time] `json:"active_from"`
+		ActiveUntil entity.Nullable[time.Time] `json:"active_until"`
+	}
+
+	BannerDeleteQuery struct {
+		FeatureID *int `form:"feature_id"`
+		TagID     *int `form:"tag_id"`
+	}
+
+	BannerRevisionsGetQuery struct {
+		Limit  *int `form:"limit"`
+		Offset *int `form:"offset"`
+	}
+
+	BannerRollbackBody struct {
+		Version *int `json:"version" binding:"required"`
+	}
+
+	BannerStatsQuery struct {
+		From *time.Time `form:"from" time_format:"2006-01-02" time_utc:"1"`
+		To   *time.Time `form:"to" time_format:"2006-01-02" time_utc:"1"`
+	}
+
+	BannerExperimentBody struct {
+		Name     string                 `json:"name" binding:"required"`
+		Variants []entity.BannerVariant `json:"variants" binding:"required"`
 	}
 )
 
-func newBannerRoutes(g *gin.RouterGroup, middlewares middlewares.Middlewares, bannerService service.BannerService) {
-	bannerR := BannerRoutes{bannerService: bannerService}
+func newBannerRoutes(
+	g *gin.RouterGroup,
+	middlewares middlewares.Middlewares,
+	bannerService service.BannerService,
+	bannerStatsService service.BannerStatsService,
+) {
+	bannerR := BannerRoutes{bannerService: bannerService, bannerStatsService: bannerStatsService}
 
-	banner := g.Group("/banner", middlewares.OnlyAuth(), middlewares.OnlyAdmin())
+	// Feature of banner is checked by banner service
+	read := middlewares.RequireScopedPermission(entity.PermissionBannerRead)
+	write := middlewares.RequireScopedPermission(entity.PermissionBannerWrite)
+	// Rollback restores is_active and activation window of revision as well,
+	// experiment changes content served to users
+	publish := middlewares.RequireScopedPermission(entity.PermissionBannerWrite, entity.PermissionBannerPublish)
+
+	banner := g.Group("/banner", middlewares.OnlyAuth())
 	{
-		banner.GET("/", bannerR.get)
-		banner.POST("/", bannerR.create)
-		banner.PATCH("/:id", bannerR.update)
-		banner.DELETE("/:id", bannerR.deleteById)
+		banner.GET("/", read, bannerR.get)
+		banner.POST("/", write, bannerR.create)
+		banner.PATCH("/:id", write, bannerR.update)
+		banner.DELETE("/:id", write, bannerR.deleteById)
+		banner.DELETE("/", write, bannerR.deleteAsync)
+		banner.GET("/jobs/:id", write, bannerR.getDeletionJob)
+		banner.GET("/:id/revisions", read, bannerR.getRevisions)
+		banner.POST("/:id/rollback", publish, bannerR.rollback)
+		banner.GET("/:id/experiment", read, bannerR.getExperiment)
+		banner.PUT("/:id/experiment", publish, bannerR.setExperiment)
+		banner.DELETE("/:id/experiment", publish, bannerR.deleteExperiment)
+		banner.GET("/:id/stats", read, bannerR.getStats)
+		banner.PUT("/:id/locales/:locale", write, bannerR.setLocale)
+		banner.DELETE("/:id/locales/:locale", write, bannerR.deleteLocale)
 	}
 }
 
@@ -58,10 +112,15 @@ func (r *BannerRoutes) get(c *gin.Context) {
 		return
 	}
 
-	banners, err := r.bannerService.GetBanners(c, query.FeatureID, query.TagID, query.Limit, query.Offset)
+	banners, err := r.bannerService.GetBanners(c, query.FeatureID, query.TagID, query.ActiveAt, query.Limit, query.Offset)
 	if err != nil {
 		slog.Error(err.Error())
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed get banners"})
+		switch {
+		case errors.Is(err, service.ErrBannerAccessDenied):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed get banners"})
+		}
 		return
 	}
 
@@ -84,14 +143,27 @@ func (r *BannerRoutes) create(c *gin.Context) {
 		return
 	}
 
-	id, err := r.bannerService.Create(c, body.TagIDs, *body.FeatureID, body.Content, *body.IsActive)
+	id, err := r.bannerService.Create(
+		c,
+		body.TagIDs,
+		*body.FeatureID,
+		body.Content,
+		*body.IsActive,
+		body.ActiveFrom,
+		body.ActiveUntil,
+		c.GetString("actor_id"),
+	)
 	if err != nil {
 		slog.Error(err.Error())
 		switch {
-		case errors.Is(err, service.ErrBannerFeatureNotExists) || errors.Is(err, service.ErrBannerTagNotExists):
+		case errors.Is(err, service.ErrBannerFeatureNotExists) ||
+			errors.Is(err, service.ErrBannerTagNotExists) ||
+			errors.Is(err, service.ErrBannerInvalidWindow):
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		case errors.Is(err, service.ErrBannerAlreadyExists):
 			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrBannerAccessDenied):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 		default:
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create banner"})
 		}
@@ -123,14 +195,28 @@ func (r *BannerRoutes) update(c *gin.Context) {
 		return
 	}
 
-	err = r.bannerService.Update(c, id, body.TagIDs, body.FeatureID, body.Content, body.IsActive)
+	err = r.bannerService.Update(
+		c,
+		id,
+		body.TagIDs,
+		body.FeatureID,
+		body.Content,
+		body.IsActive,
+		body.ActiveFrom,
+		body.ActiveUntil,
+		c.GetString("actor_id"),
+	)
 	if err != nil {
 		slog.Error(err.Error())
 		switch {
 		case errors.Is(err, service.ErrBannerNotFound):
 			c.Status(http.StatusNotFound)
+		case errors.Is(err, service.ErrBannerInvalidWindow) || errors.Is(err, service.ErrBannerReferenceInvalid):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		case errors.Is(err, service.ErrBannerAlreadyExists):
 			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrBannerAccessDenied):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 		default:
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update banner"})
 		}
@@ -147,12 +233,14 @@ func (r *BannerRoutes) deleteById(c *gin.Context) {
 		return
 	}
 
-	err = r.bannerService.DeleteByID(c, id)
+	err = r.bannerService.DeleteByID(c, id, c.GetString("actor_id"))
 	if err != nil {
 		slog.Error(err.Error())
 		switch {
 		case errors.Is(err, service.ErrBannerNotFound):
 			c.Status(http.StatusNotFound)
+		case errors.Is(err, service.ErrBannerAccessDenied):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 		default:
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete banner"})
 		}
@@ -161,3 +249,286 @@ func (r *BannerRoutes) deleteById(c *gin.Context) {
 
 	c.Status(http.StatusNoContent)
 }
+
+func (r *BannerRoutes) getRevisions(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "specified id is not a number"})
+		return
+	}
+
+	var query BannerRevisionsGetQuery
+
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "query parsing error"})
+		return
+	}
+
+	revisions, err := r.bannerService.GetRevisions(c, id, query.Limit, query.Offset)
+	if err != nil {
+		slog.Error(err.Error())
+		switch {
+		case errors.Is(err, service.ErrBannerNotFound):
+			c.Status(http.StatusNotFound)
+		case errors.Is(err, service.ErrBannerAccessDenied):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get banner revisions"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, revisions)
+}
+
+func (r *BannerRoutes) rollback(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "specified id is not a number"})
+		return
+	}
+
+	var body BannerRollbackBody
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "body parsing error"})
+		return
+	}
+
+	err = r.bannerService.Rollback(c, id, *body.Version, c.GetString("actor_id"))
+	if err != nil {
+		slog.Error(err.Error())
+		switch {
+		case errors.Is(err, service.ErrBannerNotFound) || errors.Is(err, service.ErrBannerRevisionNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrBannerAlreadyExists) || errors.Is(err, service.ErrBannerRevisionConflict):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrBannerAccessDenied):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to rollback banner"})
+		}
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (r *BannerRoutes) deleteAsync(c *gin.Context) {
+	var query BannerDeleteQuery
+
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "query parsing error"})
+		return
+	}
+
+	jobID, err := r.bannerService.DeleteAsync(c, query.FeatureID, query.TagID, c.GetString("actor_id"))
+	if err != nil {
+		slog.Error(err.Error())
+		switch {
+		case errors.Is(err, service.ErrDeletionFilterNotExists):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrDeletionQueueFull):
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrBannerAccessDenied):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to enqueue banners deletion"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"job_id": jobID})
+}
+
+func (r *BannerRoutes) getDeletionJob(c *gin.Context) {
+	job, err := r.bannerService.DeletionJob(c, c.Param("id"))
+	if err != nil {
+		slog.Error(err.Error())
+		switch {
+		case errors.Is(err, service.ErrDeletionJobNotFound):
+			c.Status(http.StatusNotFound)
+		case errors.Is(err, service.ErrBannerAccessDenied):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get deletion job"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+func (r *BannerRoutes) getExperiment(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "specified id is not a number"})
+		return
+	}
+
+	experiment, err := r.bannerService.GetExperiment(c, id)
+	if err != nil {
+		slog.Error(err.Error())
+		switch {
+		case errors.Is(err, service.ErrBannerNotFound) || errors.Is(err, service.ErrExperimentNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrBannerAccessDenied):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get banner experiment"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, experiment)
+}
+
+func (r *BannerRoutes) setExperiment(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "specified id is not a number"})
+		return
+	}
+
+	var body BannerExperimentBody
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "body parsing error"})
+		return
+	}
+
+	experiment := entity.BannerExperiment{Name: body.Name, Variants: body.Variants}
+	err = r.bannerService.SetExperiment(c, id, experiment, c.GetString("actor_id"))
+	if err != nil {
+		slog.Error(err.Error())
+		switch {
+		case errors.Is(err, service.ErrBannerNotFound):
+			c.Status(http.StatusNotFound)
+		case errors.Is(err, service.ErrExperimentInvalid):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrBannerAccessDenied):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to set banner experiment"})
+		}
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (r *BannerRoutes) deleteExperiment(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "specified id is not a number"})
+		return
+	}
+
+	err = r.bannerService.DeleteExperiment(c, id, c.GetString("actor_id"))
+	if err != nil {
+		slog.Error(err.Error())
+		switch {
+		case errors.Is(err, service.ErrBannerNotFound) || errors.Is(err, service.ErrExperimentNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrBannerAccessDenied):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete banner experiment"})
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (r *BannerRoutes) getStats(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "specified id is not a number"})
+		return
+	}
+
+	var query BannerStatsQuery
+
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "query parsing error"})
+		return
+	}
+
+	stats, err := r.bannerStatsService.GetStats(c, id, query.From, query.To)
+	if err != nil {
+		slog.Error(err.Error())
+		switch {
+		case errors.Is(err, service.ErrBannerNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrBannerStatsInvalidRange):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrBannerAccessDenied):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get banner stats"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"banner_id": id, "days": stats})
+}
+
+func (r *BannerRoutes) setLocale(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "specified id is not a number"})
+		return
+	}
+
+	var content map[string]any
+
+	if err := c.ShouldBindJSON(&content); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "body parsing error"})
+		return
+	}
+
+	err = r.bannerService.SetLocale(c, id, c.Param("locale"), content, c.GetString("actor_id"))
+	if err != nil {
+		slog.Error(err.Error())
+		switch {
+		case errors.Is(err, service.ErrBannerNotFound):
+			c.Status(http.StatusNotFound)
+		case errors.Is(err, service.ErrLocaleInvalid):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrBannerAccessDenied):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to set banner locale"})
+		}
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (r *BannerRoutes) deleteLocale(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "specified id is not a number"})
+		return
+	}
+
+	err = r.bannerService.DeleteLocale(c, id, c.Param("locale"), c.GetString("actor_id"))
+	if err != nil {
+		slog.Error(err.Error())
+		switch {
+		case errors.Is(err, service.ErrBannerNotFound) || errors.Is(err, service.ErrLocaleNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrLocaleInvalid):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrBannerAccessDenied):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete banner locale"})
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
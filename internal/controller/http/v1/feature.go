@@ -0,0 +1,176 @@
+package v1
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/NikolaB131-org/banner-service/internal/controller/http/v1/middlewares"
+	"github.com/NikolaB131-org/banner-service/internal/entity"
+	"github.com/NikolaB131-org/banner-service/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	FeatureRoutes struct {
+		featureService service.FeatureService
+	}
+
+	FeatureGetQuery struct {
+		Limit  *int `form:"limit"`
+		Offset *int `form:"offset"`
+	}
+
+	FeatureCreateBody struct {
+		Name        string `json:"name" binding:"required"`
+		Description string `json:"description"`
+	}
+
+	FeatureUpdateBody struct {
+		Name        *string `json:"name"`
+		Description *string `json:"description"`
+	}
+
+	FeatureDeleteQuery struct {
+		Cascade bool `form:"cascade"`
+	}
+)
+
+func newFeatureRoutes(g *gin.RouterGroup, middlewares middlewares.Middlewares, featureService service.FeatureService) {
+	featureR := FeatureRoutes{featureService: featureService}
+
+	read := middlewares.RequirePermission(entity.PermissionBannerRead)
+	write := middlewares.RequirePermission(entity.PermissionBannerWrite)
+
+	feature := g.Group("/feature", middlewares.OnlyAuth())
+	{
+		feature.GET("/", read, featureR.getAll)
+		feature.GET("/:id", read, featureR.get)
+		feature.POST("/", write, featureR.create)
+		feature.PATCH("/:id", write, featureR.update)
+		feature.DELETE("/:id", write, featureR.deleteById)
+	}
+}
+
+func (r *FeatureRoutes) getAll(c *gin.Context) {
+	var query FeatureGetQuery
+
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "query parsing error"})
+		return
+	}
+
+	features, err := r.featureService.GetFeatures(c, query.Limit, query.Offset)
+	if err != nil {
+		slog.Error(err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed get features"})
+		return
+	}
+
+	c.JSON(http.StatusOK, features)
+}
+
+func (r *FeatureRoutes) get(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "specified id is not a number"})
+		return
+	}
+
+	feature, err := r.featureService.GetFeature(c, id)
+	if err != nil {
+		slog.Error(err.Error())
+		switch {
+		case errors.Is(err, service.ErrFeatureNotFound):
+			c.Status(http.StatusNotFound)
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed get feature"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, feature)
+}
+
+func (r *FeatureRoutes) create(c *gin.Context) {
+	var body FeatureCreateBody
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "body parsing error"})
+		return
+	}
+
+	id, err := r.featureService.Create(c, body.Name, body.Description, c.GetString("actor_id"))
+	if err != nil {
+		slog.Error(err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create feature"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"feature_id": id})
+}
+
+func (r *FeatureRoutes) update(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "specified id is not a number"})
+		return
+	}
+
+	var body FeatureUpdateBody
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "body parsing error"})
+		return
+	}
+	if body.Name != nil && *body.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name must not be empty"})
+		return
+	}
+
+	err = r.featureService.Update(c, id, body.Name, body.Description, c.GetString("actor_id"))
+	if err != nil {
+		slog.Error(err.Error())
+		switch {
+		case errors.Is(err, service.ErrFeatureNotFound):
+			c.Status(http.StatusNotFound)
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update feature"})
+		}
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (r *FeatureRoutes) deleteById(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "specified id is not a number"})
+		return
+	}
+
+	var query FeatureDeleteQuery
+
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "query parsing error"})
+		return
+	}
+
+	err = r.featureService.DeleteByID(c, id, query.Cascade, c.GetString("actor_id"))
+	if err != nil {
+		slog.Error(err.Error())
+		switch {
+		case errors.Is(err, service.ErrFeatureNotFound):
+			c.Status(http.StatusNotFound)
+		case errors.Is(err, service.ErrFeatureInUse):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete feature"})
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,52 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/NikolaB131-org/banner-service/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type HealthRoutes struct {
+	healthService service.HealthService
+}
+
+const readinessTimeout = 2 * time.Second
+
+func newHealthRoutes(r gin.IRouter, healthService service.HealthService) {
+	healthR := HealthRoutes{healthService: healthService}
+
+	r.GET("/healthz", healthR.liveness)
+	r.GET("/readyz", healthR.readiness)
+}
+
+func (r *HealthRoutes) liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+func (r *HealthRoutes) readiness(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c, readinessTimeout)
+	defer cancel()
+
+	status := http.StatusOK
+	checks := make(map[string]string)
+	for name, err := range r.healthService.Ready(ctx) {
+		if err != nil {
+			slog.Error(fmt.Sprintf("readiness check %s failed: %s", name, err.Error()))
+			status = http.StatusServiceUnavailable
+			checks[name] = "unavailable"
+			continue
+		}
+		checks[name] = "ok"
+	}
+
+	if status != http.StatusOK {
+		c.JSON(status, gin.H{"status": "unavailable", "checks": checks})
+		return
+	}
+	c.JSON(status, gin.H{"status": "ok", "checks": checks})
+}
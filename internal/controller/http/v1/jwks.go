@@ -0,0 +1,24 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/NikolaB131-org/banner-service/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type JWKSRoutes struct {
+	authService service.AuthService
+}
+
+func newJWKSRoutes(r gin.IRouter, authService service.AuthService) {
+	jwksR := JWKSRoutes{authService: authService}
+
+	r.GET("/.well-known/jwks.json", jwksR.get)
+}
+
+func (r *JWKSRoutes) get(c *gin.Context) {
+	// Short caching lets verifiers pick up a newly added key soon after rotation
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, r.authService.JWKS())
+}
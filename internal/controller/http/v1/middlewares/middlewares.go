@@ -1,34 +1,103 @@
 package middlewares
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"log/slog"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/NikolaB131-org/banner-service/config"
+	"github.com/NikolaB131-org/banner-service/internal/app/access"
+	"github.com/NikolaB131-org/banner-service/internal/app/apikey"
 	"github.com/NikolaB131-org/banner-service/internal/app/jwt"
+	"github.com/NikolaB131-org/banner-service/internal/app/metrics"
+	"github.com/NikolaB131-org/banner-service/internal/app/requestid"
+	"github.com/NikolaB131-org/banner-service/internal/entity"
 	"github.com/NikolaB131-org/banner-service/internal/repository"
 	"github.com/gin-gonic/gin"
 )
 
 type Middlewares struct {
-	config         *config.Config
-	userRepository repository.User
+	config            *config.Config
+	keySet            *jwt.KeySet
+	userRepository    repository.User
+	sessionRepository repository.Session
+	apiKeyRepository  repository.APIKey
 }
 
 var (
 	ErrParsingJWT = "error while parsing JWT token"
 )
 
-func New(config *config.Config, userRepository repository.User) Middlewares {
+const (
+	requestIDHeader = "X-Request-ID"
+	apiKeyHeader    = "X-API-Key"
+)
+
+func New(
+	config *config.Config,
+	keySet *jwt.KeySet,
+	userRepository repository.User,
+	sessionRepository repository.Session,
+	apiKeyRepository repository.APIKey,
+) Middlewares {
 	return Middlewares{
-		config:         config,
-		userRepository: userRepository,
+		config:            config,
+		keySet:            keySet,
+		userRepository:    userRepository,
+		sessionRepository: sessionRepository,
+		apiKeyRepository:  apiKeyRepository,
+	}
+}
+
+// RequestID takes request id from X-Request-ID header or generates a new one,
+// and puts it to request context and response header
+func (m *Middlewares) RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestIDHeader)
+		if id == "" || len(id) > 64 {
+			b := make([]byte, 16)
+			if _, err := rand.Read(b); err != nil {
+				c.AbortWithStatus(http.StatusInternalServerError)
+				return
+			}
+			id = hex.EncodeToString(b)
+		}
+
+		c.Request = c.Request.WithContext(requestid.NewContext(c.Request.Context(), id))
+		c.Header(requestIDHeader, id)
+		c.Next()
+	}
+}
+
+// Metrics records request count and latency labeled by route pattern, not by raw path, to keep cardinality low
+func (m *Middlewares) Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(c.Writer.Status())
+		metrics.HTTPRequests.WithLabelValues(c.Request.Method, route, status).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(c.Request.Method, route, status).Observe(time.Since(start).Seconds())
 	}
 }
 
+// OnlyAuth authenticates user by Bearer token or machine client by X-API-Key header
 func (m *Middlewares) OnlyAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if key := c.GetHeader(apiKeyHeader); key != "" {
+			m.authAPIKey(c, key)
+			return
+		}
+
 		authorizationHeader := c.GetHeader("Authorization")
 		if authorizationHeader == "" {
 			c.AbortWithStatus(http.StatusUnauthorized)
@@ -43,37 +112,102 @@ func (m *Middlewares) OnlyAuth() gin.HandlerFunc {
 			return
 		}
 
-		claims, err := jwt.Parse(token, m.config.Auth.SignSecret)
+		claims, err := m.keySet.Parse(token)
 		if err != nil {
 			slog.Warn(ErrParsingJWT)
 			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "token parsing error"})
 			return
 		}
 
-		user, err := m.userRepository.User(c, claims.Username)
+		if claims.SessionID == "" {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		// Session state and permissions are read at once and cached briefly, see memory.SessionRepository
+		sessionAccess, err := m.sessionRepository.SessionAccess(c, claims.SessionID)
+		if errors.Is(err, repository.ErrNotFound) || (err == nil && sessionAccess.UserID != claims.UserID) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "session is revoked or expired"})
+			return
+		}
 		if err != nil {
+			slog.Error(err.Error())
 			c.AbortWithStatus(http.StatusInternalServerError)
 			return
 		}
 
+		c.Request = c.Request.WithContext(access.NewContext(
+			c.Request.Context(),
+			entity.NewAccess(sessionAccess.Permissions, sessionAccess.FeaturePermissions),
+		))
 		c.Set("user_id", claims.UserID)
+		c.Set("actor_id", claims.UserID)
+		c.Set("session_id", claims.SessionID)
 		c.Set("username", claims.Username)
-		c.Set("user_role", user.Role)
 		c.Next()
 	}
 }
 
-func (m *Middlewares) OnlyAdmin() gin.HandlerFunc {
+// authAPIKey grants access limited to key scopes. Actions are attributed to the owner of the key (actor_id),
+// user_id is left empty, so key requests are not treated as requests of that user (e.g. in banner experiments)
+func (m *Middlewares) authAPIKey(c *gin.Context, key string) {
+	if !apikey.IsWellFormed(key) {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+
+	apiKey, err := m.apiKeyRepository.APIKeyByHash(c, apikey.Hash(key))
+	if errors.Is(err, repository.ErrNotFound) {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		slog.Error(err.Error())
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	if !apiKey.IsActiveAt(time.Now()) {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "api key is revoked or expired"})
+		return
+	}
+
+	err = m.apiKeyRepository.TouchAPIKey(c, apiKey.ID)
+	if err != nil {
+		// Not worth failing the request
+		slog.Warn(err.Error())
+	}
+
+	c.Request = c.Request.WithContext(access.NewContext(c.Request.Context(), entity.NewAccess(apiKey.Scopes, nil)))
+	c.Set("api_key_id", apiKey.ID)
+	if apiKey.CreatedBy != nil {
+		c.Set("actor_id", *apiKey.CreatedBy)
+	}
+	c.Next()
+}
+
+// RequirePermission allows request only if authenticated user has all of the permissions globally, must be used after OnlyAuth
+func (m *Middlewares) RequirePermission(permissions ...string) gin.HandlerFunc {
+	return m.requirePermission(entity.Access.Has, permissions)
+}
+
+// RequireScopedPermission also allows users having permissions only for some features,
+// service layer is responsible for checking the exact feature
+func (m *Middlewares) RequireScopedPermission(permissions ...string) gin.HandlerFunc {
+	return m.requirePermission(entity.Access.HasForAnyFeature, permissions)
+}
+
+func (m *Middlewares) requirePermission(has func(entity.Access, string) bool, permissions []string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		role, exists := c.Get("user_role")
-		if !exists {
+		userAccess, ok := access.FromContext(c.Request.Context())
+		if !ok {
 			c.AbortWithStatus(http.StatusUnauthorized)
 			return
 		}
 
-		if role != "admin" {
-			c.AbortWithStatus(http.StatusForbidden)
-			return
+		for _, permission := range permissions {
+			if !has(userAccess, permission) {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
 		}
 
 		c.Next()
@@ -0,0 +1,98 @@
+package v1
+
+import (
+	"crypto/subtle"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/NikolaB131-org/banner-service/internal/entity"
+	"github.com/NikolaB131-org/banner-service/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	OIDCRoutes struct {
+		oidcService service.OIDCService
+	}
+
+	OIDCCallbackQuery struct {
+		Code             string `form:"code"`
+		State            string `form:"state"`
+		Error            string `form:"error"`
+		ErrorDescription string `form:"error_description"`
+	}
+)
+
+const (
+	// oidcRequestCookie keeps state, nonce and PKCE verifier between login redirect and callback
+	oidcRequestCookie     = "oidc_request"
+	oidcRequestCookiePath = "/v1/auth/oidc"
+	oidcRequestMaxAge     = 10 * 60 // seconds given to user to log in at identity provider
+)
+
+func newOIDCRoutes(g *gin.RouterGroup, oidcService service.OIDCService) {
+	oidcR := OIDCRoutes{oidcService: oidcService}
+
+	oidc := g.Group("/auth/oidc")
+	{
+		oidc.GET("/login", oidcR.login)
+		oidc.GET("/callback", oidcR.callback)
+	}
+}
+
+func (r *OIDCRoutes) login(c *gin.Context) {
+	url, request, err := r.oidcService.AuthURL()
+	if err != nil {
+		slog.Error(err.Error())
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+
+	// Lax is required, cookie must be sent on top level redirect back from identity provider
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcRequestCookie, strings.Join([]string{request.State, request.Nonce, request.Verifier}, "."),
+		oidcRequestMaxAge, oidcRequestCookiePath, "", c.Request.TLS != nil, true)
+	c.Redirect(http.StatusFound, url)
+}
+
+func (r *OIDCRoutes) callback(c *gin.Context) {
+	var query OIDCCallbackQuery
+
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "query parsing error"})
+		return
+	}
+	if query.Error != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": query.Error, "error_description": query.ErrorDescription})
+		return
+	}
+
+	cookie, err := c.Cookie(oidcRequestCookie)
+	c.SetCookie(oidcRequestCookie, "", -1, oidcRequestCookiePath, "", c.Request.TLS != nil, true) // request is single use
+	parts := strings.Split(cookie, ".")
+	if err != nil || len(parts) != 3 || query.Code == "" ||
+		subtle.ConstantTimeCompare([]byte(parts[0]), []byte(query.State)) != 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "login request is expired or state does not match"})
+		return
+	}
+
+	token, refreshToken, err := r.oidcService.Login(c, query.Code, entity.OIDCAuthRequest{State: parts[0], Nonce: parts[1], Verifier: parts[2]})
+	if err != nil {
+		slog.Error(err.Error())
+		switch {
+		case errors.Is(err, service.ErrOIDCExchange):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": service.ErrOIDCExchange.Error()})
+		case errors.Is(err, service.ErrUserDisabled):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrOIDCUsernameTaken), errors.Is(err, service.ErrOIDCInvalidUsername):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.Status(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"token": token, "refresh_token": refreshToken})
+}
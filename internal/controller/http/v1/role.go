@@ -0,0 +1,82 @@
+package v1
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/NikolaB131-org/banner-service/internal/controller/http/v1/middlewares"
+	"github.com/NikolaB131-org/banner-service/internal/entity"
+	"github.com/NikolaB131-org/banner-service/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	RoleRoutes struct {
+		roleService service.RoleService
+	}
+
+	RolePermissionsUpdateBody struct {
+		Permissions []string `json:"permissions" binding:"required"`
+	}
+)
+
+func newRoleRoutes(g *gin.RouterGroup, middlewares middlewares.Middlewares, roleService service.RoleService) {
+	roleR := RoleRoutes{roleService: roleService}
+
+	role := g.Group("/role", middlewares.OnlyAuth(), middlewares.RequirePermission(entity.PermissionUserManage))
+	{
+		role.GET("/", roleR.getAll)
+		role.GET("/permissions", roleR.getPermissions)
+		role.PUT("/:name/permissions", roleR.updatePermissions)
+	}
+}
+
+func (r *RoleRoutes) getAll(c *gin.Context) {
+	roles, err := r.roleService.GetRoles(c)
+	if err != nil {
+		slog.Error(err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed get roles"})
+		return
+	}
+
+	c.JSON(http.StatusOK, roles)
+}
+
+func (r *RoleRoutes) getPermissions(c *gin.Context) {
+	permissions, err := r.roleService.GetPermissions(c)
+	if err != nil {
+		slog.Error(err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed get permissions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, permissions)
+}
+
+func (r *RoleRoutes) updatePermissions(c *gin.Context) {
+	var body RolePermissionsUpdateBody
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "body parsing error"})
+		return
+	}
+
+	err := r.roleService.SetRolePermissions(c, c.Param("name"), body.Permissions, c.GetString("actor_id"))
+	if err != nil {
+		slog.Error(err.Error())
+		switch {
+		case errors.Is(err, service.ErrRoleNotFound):
+			c.Status(http.StatusNotFound)
+		case errors.Is(err, service.ErrUnknownPermission):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrAdminRoleImmutable):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update role permissions"})
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -4,13 +4,44 @@ import (
 	"github.com/NikolaB131-org/banner-service/internal/controller/http/v1/middlewares"
 	"github.com/NikolaB131-org/banner-service/internal/service"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
-func NewRouter(r *gin.Engine, middlewares middlewares.Middlewares, authService service.AuthService, bannerService service.BannerService) {
+func NewRouter(
+	r *gin.Engine,
+	middlewares middlewares.Middlewares,
+	authService service.AuthService,
+	loginThrottleService service.LoginThrottleService,
+	passwordService service.PasswordService,
+	bannerService service.BannerService,
+	bannerStatsService service.BannerStatsService,
+	featureService service.FeatureService,
+	tagService service.TagService,
+	auditService service.AuditService,
+	roleService service.RoleService,
+	userService service.UserService,
+	apiKeyService service.APIKeyService,
+	oidcService service.OIDCService, // nil if login with identity provider is disabled
+	healthService service.HealthService,
+) {
+	r.Use(middlewares.RequestID(), middlewares.Metrics())
+	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	newHealthRoutes(r, healthService)
+	newJWKSRoutes(r, authService)
+
 	v1 := r.Group("/v1")
 	{
-		newAuthRoutes(v1, authService)
-		newBannerRoutes(v1, middlewares, bannerService)
-		newUserBannerRoutes(v1, middlewares, bannerService)
+		newAuthRoutes(v1, middlewares, authService, loginThrottleService, passwordService)
+		if oidcService != nil {
+			newOIDCRoutes(v1, oidcService)
+		}
+		newBannerRoutes(v1, middlewares, bannerService, bannerStatsService)
+		newUserBannerRoutes(v1, middlewares, bannerService, bannerStatsService)
+		newFeatureRoutes(v1, middlewares, featureService)
+		newTagRoutes(v1, middlewares, tagService)
+		newAuditRoutes(v1, middlewares, auditService)
+		newRoleRoutes(v1, middlewares, roleService)
+		newUserRoutes(v1, middlewares, userService, roleService)
+		newAPIKeyRoutes(v1, middlewares, apiKeyService)
 	}
 }
@@ -0,0 +1,176 @@
+package v1
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/NikolaB131-org/banner-service/internal/controller/http/v1/middlewares"
+	"github.com/NikolaB131-org/banner-service/internal/entity"
+	"github.com/NikolaB131-org/banner-service/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	TagRoutes struct {
+		tagService service.TagService
+	}
+
+	TagGetQuery struct {
+		Limit  *int `form:"limit"`
+		Offset *int `form:"offset"`
+	}
+
+	TagCreateBody struct {
+		Name        string `json:"name" binding:"required"`
+		Description string `json:"description"`
+	}
+
+	TagUpdateBody struct {
+		Name        *string `json:"name"`
+		Description *string `json:"description"`
+	}
+
+	TagDeleteQuery struct {
+		Cascade bool `form:"cascade"`
+	}
+)
+
+func newTagRoutes(g *gin.RouterGroup, middlewares middlewares.Middlewares, tagService service.TagService) {
+	tagR := TagRoutes{tagService: tagService}
+
+	read := middlewares.RequirePermission(entity.PermissionBannerRead)
+	write := middlewares.RequirePermission(entity.PermissionBannerWrite)
+
+	tag := g.Group("/tag", middlewares.OnlyAuth())
+	{
+		tag.GET("/", read, tagR.getAll)
+		tag.GET("/:id", read, tagR.get)
+		tag.POST("/", write, tagR.create)
+		tag.PATCH("/:id", write, tagR.update)
+		tag.DELETE("/:id", write, tagR.deleteById)
+	}
+}
+
+func (r *TagRoutes) getAll(c *gin.Context) {
+	var query TagGetQuery
+
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "query parsing error"})
+		return
+	}
+
+	tags, err := r.tagService.GetTags(c, query.Limit, query.Offset)
+	if err != nil {
+		slog.Error(err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed get tags"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tags)
+}
+
+func (r *TagRoutes) get(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "specified id is not a number"})
+		return
+	}
+
+	tag, err := r.tagService.GetTag(c, id)
+	if err != nil {
+		slog.Error(err.Error())
+		switch {
+		case errors.Is(err, service.ErrTagNotFound):
+			c.Status(http.StatusNotFound)
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed get tag"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, tag)
+}
+
+func (r *TagRoutes) create(c *gin.Context) {
+	var body TagCreateBody
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "body parsing error"})
+		return
+	}
+
+	id, err := r.tagService.Create(c, body.Name, body.Description, c.GetString("actor_id"))
+	if err != nil {
+		slog.Error(err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create tag"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"tag_id": id})
+}
+
+func (r *TagRoutes) update(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "specified id is not a number"})
+		return
+	}
+
+	var body TagUpdateBody
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "body parsing error"})
+		return
+	}
+	if body.Name != nil && *body.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name must not be empty"})
+		return
+	}
+
+	err = r.tagService.Update(c, id, body.Name, body.Description, c.GetString("actor_id"))
+	if err != nil {
+		slog.Error(err.Error())
+		switch {
+		case errors.Is(err, service.ErrTagNotFound):
+			c.Status(http.StatusNotFound)
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update tag"})
+		}
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (r *TagRoutes) deleteById(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "specified id is not a number"})
+		return
+	}
+
+	var query TagDeleteQuery
+
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "query parsing error"})
+		return
+	}
+
+	err = r.tagService.DeleteByID(c, id, query.Cascade, c.GetString("actor_id"))
+	if err != nil {
+		slog.Error(err.Error())
+		switch {
+		case errors.Is(err, service.ErrTagNotFound):
+			c.Status(http.StatusNotFound)
+		case errors.Is(err, service.ErrTagInUse):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete tag"})
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
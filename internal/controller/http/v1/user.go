@@ -0,0 +1,323 @@
+package v1
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/NikolaB131-org/banner-service/internal/controller/http/v1/middlewares"
+	"github.com/NikolaB131-org/banner-service/internal/entity"
+	"github.com/NikolaB131-org/banner-service/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	UserRoutes struct {
+		userService service.UserService
+		roleService service.RoleService
+	}
+
+	UserUri struct {
+		ID string `uri:"id" binding:"required,uuid"`
+	}
+
+	UserGetQuery struct {
+		Limit  *int `form:"limit"`
+		Offset *int `form:"offset"`
+	}
+
+	UserRoleAssignBody struct {
+		Role string `json:"role" binding:"required"`
+	}
+
+	UserFeatureGrantUri struct {
+		ID        string `uri:"id" binding:"required,uuid"`
+		FeatureID int    `uri:"feature_id" binding:"required"`
+		Role      string `uri:"role" binding:"required"`
+	}
+
+	UserFeatureGrantBody struct {
+		FeatureID *int   `json:"feature_id" binding:"required"`
+		Role      string `json:"role" binding:"required"`
+	}
+)
+
+func newUserRoutes(g *gin.RouterGroup, middlewares middlewares.Middlewares, userService service.UserService, roleService service.RoleService) {
+	userR := UserRoutes{userService: userService, roleService: roleService}
+
+	user := g.Group("/user", middlewares.OnlyAuth(), middlewares.RequirePermission(entity.PermissionUserManage))
+	{
+		user.GET("/", userR.getAll)
+		user.GET("/:id", userR.get)
+		user.DELETE("/:id", userR.deleteById)
+		user.POST("/:id/disable", userR.disable)
+		user.POST("/:id/enable", userR.enable)
+		user.POST("/:id/force_password_reset", userR.forcePasswordReset)
+		user.GET("/:id/roles", userR.getRoles)
+		user.POST("/:id/roles", userR.assignRole)
+		user.DELETE("/:id/roles/:role", userR.revokeRole)
+		user.GET("/:id/feature_grants", userR.getFeatureGrants)
+		user.POST("/:id/feature_grants", userR.grantFeatureRole)
+		user.DELETE("/:id/feature_grants/:feature_id/:role", userR.revokeFeatureRole)
+	}
+}
+
+func (r *UserRoutes) getAll(c *gin.Context) {
+	var query UserGetQuery
+
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "query parsing error"})
+		return
+	}
+
+	users, err := r.userService.GetUsers(c, query.Limit, query.Offset)
+	if err != nil {
+		slog.Error(err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed get users"})
+		return
+	}
+
+	c.JSON(http.StatusOK, users)
+}
+
+func (r *UserRoutes) get(c *gin.Context) {
+	var uri UserUri
+
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "specified id is not a uuid"})
+		return
+	}
+
+	user, err := r.userService.GetUser(c, uri.ID)
+	if err != nil {
+		slog.Error(err.Error())
+		switch {
+		case errors.Is(err, service.ErrUserNotFound):
+			c.Status(http.StatusNotFound)
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed get user"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+func (r *UserRoutes) deleteById(c *gin.Context) {
+	var uri UserUri
+
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "specified id is not a uuid"})
+		return
+	}
+
+	err := r.userService.Delete(c, uri.ID, c.GetString("actor_id"))
+	if err != nil {
+		slog.Error(err.Error())
+		r.handleUserChangeError(c, err, "failed to delete user")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (r *UserRoutes) disable(c *gin.Context) {
+	r.setDisabled(c, true)
+}
+
+func (r *UserRoutes) enable(c *gin.Context) {
+	r.setDisabled(c, false)
+}
+
+func (r *UserRoutes) setDisabled(c *gin.Context, isDisabled bool) {
+	var uri UserUri
+
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "specified id is not a uuid"})
+		return
+	}
+
+	err := r.userService.SetDisabled(c, uri.ID, isDisabled, c.GetString("actor_id"))
+	if err != nil {
+		slog.Error(err.Error())
+		r.handleUserChangeError(c, err, "failed to update user")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (r *UserRoutes) forcePasswordReset(c *gin.Context) {
+	var uri UserUri
+
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "specified id is not a uuid"})
+		return
+	}
+
+	err := r.userService.ForcePasswordReset(c, uri.ID, c.GetString("actor_id"))
+	if err != nil {
+		slog.Error(err.Error())
+		r.handleUserChangeError(c, err, "failed to force password reset")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (r *UserRoutes) handleUserChangeError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		c.Status(http.StatusNotFound)
+	case errors.Is(err, service.ErrSelfModification) || errors.Is(err, service.ErrLastAdminDisabled):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
+
+func (r *UserRoutes) getRoles(c *gin.Context) {
+	var uri UserUri
+
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "specified id is not a uuid"})
+		return
+	}
+
+	roles, err := r.roleService.GetUserRoles(c, uri.ID)
+	if err != nil {
+		slog.Error(err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed get user roles"})
+		return
+	}
+
+	c.JSON(http.StatusOK, roles)
+}
+
+func (r *UserRoutes) assignRole(c *gin.Context) {
+	var uri UserUri
+
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "specified id is not a uuid"})
+		return
+	}
+
+	var body UserRoleAssignBody
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "body parsing error"})
+		return
+	}
+
+	err := r.roleService.AssignRole(c, uri.ID, body.Role, c.GetString("actor_id"))
+	if err != nil {
+		slog.Error(err.Error())
+		switch {
+		case errors.Is(err, service.ErrRoleNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to assign role"})
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (r *UserRoutes) revokeRole(c *gin.Context) {
+	var uri UserUri
+
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "specified id is not a uuid"})
+		return
+	}
+
+	err := r.roleService.RevokeRole(c, uri.ID, c.Param("role"), c.GetString("actor_id"))
+	if err != nil {
+		slog.Error(err.Error())
+		switch {
+		case errors.Is(err, service.ErrRoleNotAssigned):
+			c.Status(http.StatusNotFound)
+		case errors.Is(err, service.ErrLastAdminRoleRevoked):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke role"})
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (r *UserRoutes) getFeatureGrants(c *gin.Context) {
+	var uri UserUri
+
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "specified id is not a uuid"})
+		return
+	}
+
+	grants, err := r.roleService.GetFeatureGrants(c, uri.ID)
+	if err != nil {
+		slog.Error(err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed get feature grants"})
+		return
+	}
+
+	c.JSON(http.StatusOK, grants)
+}
+
+func (r *UserRoutes) grantFeatureRole(c *gin.Context) {
+	var uri UserUri
+
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "specified id is not a uuid"})
+		return
+	}
+
+	var body UserFeatureGrantBody
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "body parsing error"})
+		return
+	}
+
+	err := r.roleService.GrantFeatureRole(c, uri.ID, *body.FeatureID, body.Role, c.GetString("actor_id"))
+	if err != nil {
+		slog.Error(err.Error())
+		switch {
+		case errors.Is(err, service.ErrRoleNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "role, user or feature not found"})
+		case errors.Is(err, service.ErrRoleNotFeatureScoped):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to grant feature role"})
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (r *UserRoutes) revokeFeatureRole(c *gin.Context) {
+	var uri UserFeatureGrantUri
+
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "uri parsing error"})
+		return
+	}
+
+	err := r.roleService.RevokeFeatureRole(c, uri.ID, uri.FeatureID, uri.Role, c.GetString("actor_id"))
+	if err != nil {
+		slog.Error(err.Error())
+		switch {
+		case errors.Is(err, service.ErrFeatureGrantNotFound):
+			c.Status(http.StatusNotFound)
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke feature role"})
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -4,7 +4,10 @@ import (
 	"errors"
 	"log/slog"
 	"net/http"
+	"time"
 
+	"github.com/NikolaB131-org/banner-service/internal/app/access"
+	"github.com/NikolaB131-org/banner-service/internal/app/locale"
 	"github.com/NikolaB131-org/banner-service/internal/controller/http/v1/middlewares"
 	"github.com/NikolaB131-org/banner-service/internal/entity"
 	"github.com/NikolaB131-org/banner-service/internal/service"
@@ -13,22 +16,35 @@ import (
 
 type (
 	UserBannerRoutes struct {
-		bannerService service.BannerService
+		bannerService      service.BannerService
+		bannerStatsService service.BannerStatsService
 	}
 
 	UserBannerGetQuery struct {
-		FeatureID       *int  `form:"feature_id"`
-		TagID           *int  `form:"tag_id"`
-		UseLastRevision *bool `form:"use_last_revision"`
+		FeatureID       *int    `form:"feature_id"`
+		TagID           *int    `form:"tag_id"`
+		UseLastRevision *bool   `form:"use_last_revision"`
+		Locale          *string `form:"locale"`
+	}
+
+	UserBannerClickBody struct {
+		FeatureID *int `json:"feature_id" binding:"required"`
+		TagID     *int `json:"tag_id" binding:"required"`
 	}
 )
 
-func newUserBannerRoutes(g *gin.RouterGroup, middlewares middlewares.Middlewares, bannerService service.BannerService) {
-	userBannerR := UserBannerRoutes{bannerService: bannerService}
+func newUserBannerRoutes(
+	g *gin.RouterGroup,
+	middlewares middlewares.Middlewares,
+	bannerService service.BannerService,
+	bannerStatsService service.BannerStatsService,
+) {
+	userBannerR := UserBannerRoutes{bannerService: bannerService, bannerStatsService: bannerStatsService}
 
 	userBanner := g.Group("/user_banner", middlewares.OnlyAuth())
 	{
 		userBanner.GET("/", userBannerR.get)
+		userBanner.POST("/click", userBannerR.click)
 	}
 }
 
@@ -48,12 +64,18 @@ func (r *UserBannerRoutes) get(c *gin.Context) {
 		return
 	}
 
-	var banner entity.Banner
+	// Explicit locale is preferred over Accept-Language, which is used if locale is not supported
+	preferredLocales := locale.ParseAcceptLanguage(c.GetHeader("Accept-Language"))
+	if query.Locale != nil {
+		preferredLocales = append([]string{*query.Locale}, preferredLocales...)
+	}
+
+	var banner entity.ServedBanner
 	var err error
 	if query.UseLastRevision != nil {
-		banner, err = r.bannerService.GetBanner(c, *query.FeatureID, *query.TagID, *query.UseLastRevision)
+		banner, err = r.bannerService.GetBanner(c, *query.FeatureID, *query.TagID, *query.UseLastRevision, c.GetString("user_id"), preferredLocales)
 	} else {
-		banner, err = r.bannerService.GetBanner(c, *query.FeatureID, *query.TagID, false)
+		banner, err = r.bannerService.GetBanner(c, *query.FeatureID, *query.TagID, false, c.GetString("user_id"), preferredLocales)
 	}
 	if err != nil {
 		slog.Error(err.Error())
@@ -66,11 +88,57 @@ func (r *UserBannerRoutes) get(c *gin.Context) {
 		return
 	}
 
-	userRole, _ := c.Get("user_role")
-	if userRole == "user" && !banner.IsActive {
+	if !isBannerVisible(c, banner) {
 		c.Status(http.StatusForbidden)
 		return
 	}
 
+	// Lets clients log exposures of experiment variants without changing content format
+	if banner.Variant != "" {
+		c.Header("X-Banner-Experiment", banner.Experiment)
+		c.Header("X-Banner-Variant", banner.Variant)
+	}
+	if banner.Locale != "" {
+		c.Header("Content-Language", banner.Locale)
+	}
+
+	r.bannerStatsService.TrackImpression(banner)
 	c.JSON(http.StatusOK, banner.Content)
 }
+
+// click counts click on banner served for feature and tag, the banner and variant are resolved
+// the same way as for get, so click is attributed to the content user has seen
+func (r *UserBannerRoutes) click(c *gin.Context) {
+	var body UserBannerClickBody
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "body parsing error"})
+		return
+	}
+
+	banner, err := r.bannerService.GetBanner(c, *body.FeatureID, *body.TagID, false, c.GetString("user_id"), nil)
+	if err != nil {
+		slog.Error(err.Error())
+		switch {
+		case errors.Is(err, service.ErrBannerNotFound):
+			c.Status(http.StatusNotFound)
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get banner"})
+		}
+		return
+	}
+
+	if !isBannerVisible(c, banner) {
+		c.Status(http.StatusForbidden)
+		return
+	}
+
+	r.bannerStatsService.TrackClick(banner)
+	c.Status(http.StatusNoContent)
+}
+
+// isBannerVisible reports whether banner may be served, inactive banners are visible only to users able to read them
+func isBannerVisible(c *gin.Context, banner entity.ServedBanner) bool {
+	userAccess, _ := access.FromContext(c.Request.Context())
+	return userAccess.HasForFeature(entity.PermissionBannerRead, banner.FeatureID) || banner.IsActiveAt(time.Now())
+}
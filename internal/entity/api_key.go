@@ -0,0 +1,21 @@
+package entity
+
+import "time"
+
+type APIKey struct {
+	ID   string `db:"id" json:"id"`
+	Name string `db:"name" json:"name"`
+	// Prefix is the beginning of the key stored in plain text, so key can be identified without knowing it
+	Prefix     string     `db:"prefix" json:"prefix"`
+	KeyHash    []byte     `db:"key_hash" json:"-"`
+	Scopes     []string   `db:"scopes" json:"scopes"`
+	CreatedBy  *string    `db:"created_by" json:"created_by"`
+	ExpiresAt  *time.Time `db:"expires_at" json:"expires_at"`
+	LastUsedAt *time.Time `db:"last_used_at" json:"last_used_at"`
+	RevokedAt  *time.Time `db:"revoked_at" json:"revoked_at"`
+	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+}
+
+func (k APIKey) IsActiveAt(t time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || t.Before(*k.ExpiresAt))
+}
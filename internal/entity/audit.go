@@ -0,0 +1,55 @@
+package entity
+
+import "time"
+
+const (
+	AuditActionCreate   = "create"
+	AuditActionUpdate   = "update"
+	AuditActionRollback = "rollback"
+	AuditActionDelete   = "delete"
+	// Actions with banner experiments
+	AuditActionSetExperiment    = "set_experiment"
+	AuditActionDeleteExperiment = "delete_experiment"
+	// Actions with localized banner content
+	AuditActionSetLocale    = "set_locale"
+	AuditActionDeleteLocale = "delete_locale"
+	// Actions with user roles
+	AuditActionAssignRole = "assign_role"
+	AuditActionRevokeRole = "revoke_role"
+	// Actions with user feature grants
+	AuditActionGrantFeatureRole  = "grant_feature_role"
+	AuditActionRevokeFeatureRole = "revoke_feature_role"
+	// Actions with user accounts
+	AuditActionDisableUser        = "disable_user"
+	AuditActionEnableUser         = "enable_user"
+	AuditActionForcePasswordReset = "force_password_reset"
+	// Actions with api keys
+	AuditActionRevokeAPIKey = "revoke_api_key"
+
+	AuditEntityBanner  = "banner"
+	AuditEntityFeature = "feature"
+	AuditEntityTag     = "tag"
+	AuditEntityUser    = "user"
+	AuditEntityRole    = "role"
+	AuditEntityAPIKey  = "api_key"
+)
+
+type AuditRecord struct {
+	ID         int64          `db:"id" json:"id"`
+	ActorID    *string        `db:"actor_id" json:"actor_id"`
+	Action     string         `db:"action" json:"action"`
+	EntityType string         `db:"entity_type" json:"entity_type"`
+	EntityID   string         `db:"entity_id" json:"entity_id"`
+	Changes    map[string]any `db:"changes" json:"changes"`
+	RequestID  string         `db:"request_id" json:"request_id"`
+	CreatedAt  time.Time      `db:"created_at" json:"created_at"`
+}
+
+type AuditFilter struct {
+	ActorID    *string
+	EntityType *string
+	EntityID   *string
+	From       *time.Time
+	To         *time.Time
+	BeforeID   *int64
+}
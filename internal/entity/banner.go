@@ -1,13 +1,177 @@
 package entity
 
-import "time"
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"time"
+)
 
 type Banner struct {
-	ID        int            `db:"id" json:"banner_id"`
-	TagIDs    []int          `db:"tag_ids" json:"tag_ids"`
-	FeatureID int            `db:"feature_id" json:"feature_id"`
-	Content   map[string]any `db:"content" json:"content"`
-	IsActive  bool           `db:"is_active" json:"is_active"`
-	CreatedAt time.Time      `db:"created_at" json:"created_at"`
-	UpdatedAt time.Time      `db:"updated_at" json:"updated_at"`
+	ID          int            `db:"id" json:"banner_id"`
+	TagIDs      []int          `db:"tag_ids" json:"tag_ids"`
+	FeatureID   int            `db:"feature_id" json:"feature_id"`
+	Content     map[string]any `db:"content" json:"content"`
+	IsActive    bool           `db:"is_active" json:"is_active"`
+	ActiveFrom  *time.Time     `db:"active_from" json:"active_from"`
+	ActiveUntil *time.Time     `db:"active_until" json:"active_until"`
+	CreatedAt   time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time      `db:"updated_at" json:"updated_at"`
+	// Experiment splits users between content variants, nil if banner serves Content to everyone
+	Experiment *BannerExperiment `db:"experiment" json:"experiment,omitempty"`
+	// Locales is localized content by locale, Content is served if there is none for requested locale
+	Locales map[string]map[string]any `db:"locales" json:"locales,omitempty"`
+}
+
+type BannerExperiment struct {
+	Name     string          `json:"name"`
+	Variants []BannerVariant `json:"variants"` // ordered by name
+}
+
+type BannerVariant struct {
+	Name    string         `json:"name"`
+	Content map[string]any `json:"content"`
+	Weight  int            `json:"weight"` // share of users is weight divided by sum of weights of all variants
+}
+
+// ServedBanner is banner with content chosen for a particular user,
+// Experiment and Variant are empty if user is not in experiment, Locale is empty if content is not localized
+type ServedBanner struct {
+	Banner
+	Experiment string
+	Variant    string
+	Locale     string
+}
+
+// ServeTo replaces content with variant of user if banner has experiment,
+// otherwise with content of the first locale of chain banner is localized to.
+// Variants are not localized, experiment is expected to be run for a single locale
+func (b Banner) ServeTo(userID string, chain []string) ServedBanner {
+	if b.Experiment != nil {
+		if variant, ok := b.Experiment.Variant(userID); ok {
+			b.Content = variant.Content
+			return ServedBanner{Banner: b, Experiment: b.Experiment.Name, Variant: variant.Name}
+		}
+	}
+
+	locale := b.ContentLocale(chain)
+	if locale == "" {
+		return ServedBanner{Banner: b}
+	}
+	b.Content = b.Locales[locale]
+	return ServedBanner{Banner: b, Locale: locale}
+}
+
+// ContentLocale returns the first locale of chain banner has content for, empty string if there is none
+func (b Banner) ContentLocale(chain []string) string {
+	for _, locale := range chain {
+		if _, ok := b.Locales[locale]; ok {
+			return locale
+		}
+	}
+	return ""
+}
+
+// ForLocale keeps only localized content served for chain, so banner cached per locale stays small
+func (b Banner) ForLocale(chain []string) Banner {
+	locale := b.ContentLocale(chain)
+	if locale == "" {
+		b.Locales = nil
+		return b
+	}
+	b.Locales = map[string]map[string]any{locale: b.Locales[locale]}
+	return b
+}
+
+// Variant picks variant of user by weights. Assignment depends only on experiment name and user id,
+// so user keeps the variant while weights are unchanged and gets the same variant on all banners of experiment.
+// Anonymous callers (api keys) are not in experiment
+func (e BannerExperiment) Variant(userID string) (BannerVariant, bool) {
+	totalWeight := 0
+	for _, variant := range e.Variants {
+		totalWeight += variant.Weight
+	}
+	if userID == "" || totalWeight == 0 {
+		return BannerVariant{}, false
+	}
+
+	// Low bits of simple hashes like fnv depend only on low bits of input bytes, sha256 is mixed well enough
+	hash := sha256.Sum256([]byte(e.Name + "\x00" + userID))
+	bucket := int(binary.BigEndian.Uint64(hash[:8]) % uint64(totalWeight))
+
+	for _, variant := range e.Variants {
+		if bucket < variant.Weight {
+			return variant, true
+		}
+		bucket -= variant.Weight
+	}
+	return BannerVariant{}, false
+}
+
+// IsActiveAt reports whether banner is active and t is inside its activation window
+func (b Banner) IsActiveAt(t time.Time) bool {
+	if !b.IsActive {
+		return false
+	}
+	if b.ActiveFrom != nil && t.Before(*b.ActiveFrom) {
+		return false
+	}
+	if b.ActiveUntil != nil && !t.Before(*b.ActiveUntil) {
+		return false
+	}
+	return true
+}
+
+// NextWindowBoundary returns closest activation window boundary after t, or nil if there is none
+func (b Banner) NextWindowBoundary(t time.Time) *time.Time {
+	if b.ActiveFrom != nil && b.ActiveFrom.After(t) {
+		return b.ActiveFrom
+	}
+	if b.ActiveUntil != nil && b.ActiveUntil.After(t) {
+		return b.ActiveUntil
+	}
+	return nil
+}
+
+type BannerRevision struct {
+	BannerID    int            `db:"banner_id" json:"banner_id"`
+	Version     int            `db:"version" json:"version"`
+	TagIDs      []int          `db:"tag_ids" json:"tag_ids"`
+	FeatureID   int            `db:"feature_id" json:"feature_id"`
+	Content     map[string]any `db:"content" json:"content"`
+	IsActive    bool           `db:"is_active" json:"is_active"`
+	ActiveFrom  *time.Time     `db:"active_from" json:"active_from"`
+	ActiveUntil *time.Time     `db:"active_until" json:"active_until"`
+	AuthorID    *string        `db:"author_id" json:"author_id"`
+	CreatedAt   time.Time      `db:"created_at" json:"created_at"`
+	// Experiment and Locales are restored by rollback as well
+	Experiment *BannerExperiment `db:"experiment" json:"experiment,omitempty"`
+	// Locales is nil for revisions saved before localized content was stored in revisions
+	Locales map[string]map[string]any `db:"locales" json:"locales,omitempty"`
+}
+
+const (
+	BannerDeletionJobQueued  = "queued"
+	BannerDeletionJobRunning = "running"
+	BannerDeletionJobDone    = "done"
+	BannerDeletionJobFailed  = "failed"
+)
+
+type BannerDeletionJob struct {
+	ID         string     `db:"id" json:"job_id"`
+	Status     string     `db:"status" json:"status"`
+	FeatureID  *int       `db:"feature_id" json:"feature_id"`
+	TagID      *int       `db:"tag_id" json:"tag_id"`
+	Deleted    int        `db:"deleted" json:"deleted"`
+	Error      string     `db:"error" json:"error,omitempty"`
+	CreatedBy  string     `db:"created_by" json:"created_by"`
+	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+	FinishedAt *time.Time `db:"finished_at" json:"finished_at"`
+}
+
+// BannerInvalidation describes cached banner entries which became stale,
+// either all entries of banner BannerID or keys of FeatureID with TagIDs
+type BannerInvalidation struct {
+	BannerID  *int  `json:"banner_id,omitempty"`
+	FeatureID *int  `json:"feature_id,omitempty"`
+	TagIDs    []int `json:"tag_ids,omitempty"`
 }
@@ -0,0 +1,36 @@
+package entity
+
+import "time"
+
+// BannerCounters are events of banner variant during a day (UTC)
+type BannerCounters struct {
+	BannerID    int       `db:"banner_id"`
+	Day         time.Time `db:"day"`
+	Variant     string    `db:"variant"` // empty if content was served outside of experiment
+	Impressions int64     `db:"impressions"`
+	Clicks      int64     `db:"clicks"`
+}
+
+type BannerDayStats struct {
+	Date        string  `json:"date"` // YYYY-MM-DD
+	Impressions int64   `json:"impressions"`
+	Clicks      int64   `json:"clicks"`
+	CTR         float64 `json:"ctr"`
+	// Variants are present only for days when banner had experiment
+	Variants []BannerVariantStats `json:"variants,omitempty"`
+}
+
+type BannerVariantStats struct {
+	Variant     string  `json:"variant"`
+	Impressions int64   `json:"impressions"`
+	Clicks      int64   `json:"clicks"`
+	CTR         float64 `json:"ctr"`
+}
+
+// CTR is share of impressions followed by click, 0 if there were no impressions
+func CTR(impressions int64, clicks int64) float64 {
+	if impressions == 0 {
+		return 0
+	}
+	return float64(clicks) / float64(impressions)
+}
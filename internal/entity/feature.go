@@ -0,0 +1,11 @@
+package entity
+
+import "time"
+
+type Feature struct {
+	ID          int       `db:"id" json:"feature_id"`
+	Name        string    `db:"name" json:"name"`
+	Description string    `db:"description" json:"description"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+}
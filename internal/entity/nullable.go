@@ -0,0 +1,29 @@
+package entity
+
+import "encoding/json"
+
+// Nullable distinguishes absent JSON field (Set is false) from explicit null (Set is true and Value is nil)
+type Nullable[T any] struct {
+	Set   bool
+	Value *T
+}
+
+func NewNullable[T any](value *T) Nullable[T] {
+	return Nullable[T]{Set: true, Value: value}
+}
+
+func (n *Nullable[T]) UnmarshalJSON(data []byte) error {
+	n.Set = true
+	if string(data) == "null" {
+		n.Value = nil
+		return nil
+	}
+
+	var value T
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	n.Value = &value
+
+	return nil
+}
@@ -0,0 +1,8 @@
+package entity
+
+// OIDCAuthRequest holds values generated for a single login attempt, which client keeps until provider redirects back
+type OIDCAuthRequest struct {
+	State    string
+	Nonce    string
+	Verifier string // PKCE code verifier
+}
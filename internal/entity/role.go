@@ -0,0 +1,123 @@
+package entity
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	PermissionBannerRead    = "banner:read"
+	PermissionBannerWrite   = "banner:write"
+	PermissionBannerPublish = "banner:publish"
+	PermissionAuditRead     = "audit:read"
+	PermissionUserManage    = "user:manage"
+)
+
+const (
+	RoleViewer    = "viewer"
+	RoleEditor    = "editor"
+	RolePublisher = "publisher"
+	RoleAdmin     = "admin"
+)
+
+type (
+	Role struct {
+		Name        string   `json:"name" db:"name"`
+		Description string   `json:"description" db:"description"`
+		Permissions []string `json:"permissions" db:"permissions"`
+	}
+
+	Permission struct {
+		Name        string `json:"name" db:"name"`
+		Description string `json:"description" db:"description"`
+	}
+)
+
+// FeatureGrant gives user permissions of Role only for banners of FeatureID
+type FeatureGrant struct {
+	FeatureID int       `json:"feature_id" db:"feature_id"`
+	Role      string    `json:"role" db:"role"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// IsFeatureScoped reports whether permission may be granted for a single feature
+func IsFeatureScoped(permission string) bool {
+	return strings.HasPrefix(permission, "banner:")
+}
+
+// Access holds permissions of authenticated user, global ones apply to every feature
+type Access struct {
+	Permissions        map[string]struct{}
+	FeaturePermissions map[int]map[string]struct{}
+	// IsSystem grants every permission, it is set only for trusted internal callers
+	IsSystem bool
+}
+
+func NewAccess(permissions []string, featurePermissions map[int][]string) Access {
+	access := Access{
+		Permissions:        make(map[string]struct{}, len(permissions)),
+		FeaturePermissions: make(map[int]map[string]struct{}, len(featurePermissions)),
+	}
+	for _, permission := range permissions {
+		access.Permissions[permission] = struct{}{}
+	}
+	for featureID, permissions := range featurePermissions {
+		for _, permission := range permissions {
+			if !IsFeatureScoped(permission) {
+				continue
+			}
+			if access.FeaturePermissions[featureID] == nil {
+				access.FeaturePermissions[featureID] = make(map[string]struct{})
+			}
+			access.FeaturePermissions[featureID][permission] = struct{}{}
+		}
+	}
+	return access
+}
+
+// Has reports whether permission is granted globally
+func (a Access) Has(permission string) bool {
+	if a.IsSystem {
+		return true
+	}
+	_, ok := a.Permissions[permission]
+	return ok
+}
+
+// HasForFeature reports whether permission is granted globally or for featureID
+func (a Access) HasForFeature(permission string, featureID int) bool {
+	if a.Has(permission) {
+		return true
+	}
+	_, ok := a.FeaturePermissions[featureID][permission]
+	return ok
+}
+
+// HasForAnyFeature reports whether permission is granted globally or for at least one feature
+func (a Access) HasForAnyFeature(permission string) bool {
+	if a.Has(permission) {
+		return true
+	}
+	for _, permissions := range a.FeaturePermissions {
+		if _, ok := permissions[permission]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Features returns ids of features permission is granted for, nil if it is granted globally
+func (a Access) Features(permission string) []int {
+	if a.Has(permission) {
+		return nil
+	}
+	featureIDs := []int{}
+	for featureID, permissions := range a.FeaturePermissions {
+		if _, ok := permissions[permission]; ok {
+			featureIDs = append(featureIDs, featureID)
+		}
+	}
+	slices.Sort(featureIDs)
+	return featureIDs
+}
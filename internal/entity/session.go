@@ -0,0 +1,27 @@
+package entity
+
+import "time"
+
+// SessionAccess is what authenticated request needs to know about its active session
+type SessionAccess struct {
+	UserID             string           `db:"user_id" json:"user_id"`
+	Permissions        []string         `db:"permissions" json:"permissions"`
+	FeaturePermissions map[int][]string `db:"feature_permissions" json:"feature_permissions"`
+}
+
+// SessionInvalidation drops cached access of session, of all sessions of user or of all sessions at once
+type SessionInvalidation struct {
+	SessionID string `json:"session_id,omitempty"`
+	UserID    string `json:"user_id,omitempty"`
+	All       bool   `json:"all,omitempty"`
+}
+
+type RefreshToken struct {
+	SessionID        string     `db:"session_id"`
+	UserID           string     `db:"user_id"`
+	Username         string     `db:"username"`
+	ExpiresAt        time.Time  `db:"expires_at"`
+	UsedAt           *time.Time `db:"used_at"`
+	SessionExpiresAt time.Time  `db:"session_expires_at"`
+	SessionRevokedAt *time.Time `db:"session_revoked_at"`
+}
@@ -3,9 +3,12 @@ package entity
 import "time"
 
 type User struct {
-	ID           string    `db:"id"`
-	Username     string    `db:"username"`
-	PasswordHash []byte    `db:"password_hash"`
-	Role         string    `db:"role"`
-	CreatedAt    time.Time `db:"created_at"`
+	ID           string     `db:"id" json:"id"`
+	Username     string     `db:"username" json:"username"`
+	PasswordHash []byte     `db:"password_hash" json:"-"`
+	Roles        []string   `db:"roles" json:"roles"`
+	DisabledAt   *time.Time `db:"disabled_at" json:"disabled_at"`
+	// PasswordResetRequired forbids login until password is reset
+	PasswordResetRequired bool      `db:"password_reset_required" json:"password_reset_required"`
+	CreatedAt             time.Time `db:"created_at" json:"created_at"`
 }
@@ -0,0 +1,199 @@
+package memory
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/NikolaB131-org/banner-service/internal/app/metrics"
+	"github.com/NikolaB131-org/banner-service/internal/entity"
+	"github.com/NikolaB131-org/banner-service/internal/repository"
+)
+
+type (
+	// BannerRepository is a bounded in-process LRU cache with ttl in front of another banner cache (redis).
+	// Invalidations are forwarded to the wrapped cache and broadcast to other instances
+	BannerRepository struct {
+		next          repository.BannerCache
+		invalidations repository.BannerInvalidation
+		size          int
+		ttl           time.Duration
+
+		mu         sync.Mutex
+		entries    map[bannerKey]*list.Element
+		lru        *list.List // front is the most recently used entry
+		generation uint64     // incremented on every invalidation
+	}
+
+	bannerKey struct {
+		featureID int
+		tagID     int
+		locale    string
+	}
+
+	bannerEntry struct {
+		key       bannerKey
+		banner    entity.Banner
+		expiresAt time.Time
+	}
+)
+
+func NewBannerRepository(next repository.BannerCache, invalidations repository.BannerInvalidation, size int, ttl time.Duration) *BannerRepository {
+	return &BannerRepository{
+		next:          next,
+		invalidations: invalidations,
+		size:          size,
+		ttl:           ttl,
+		entries:       make(map[bannerKey]*list.Element, size),
+		lru:           list.New(),
+	}
+}
+
+// ListenInvalidations drops entries invalidated by other instances, blocks until ctx is canceled
+func (r *BannerRepository) ListenInvalidations(ctx context.Context) error {
+	return r.invalidations.SubscribeInvalidations(ctx, r.invalidate)
+}
+
+func (r *BannerRepository) Banner(ctx context.Context, featureID int, tagID int, locale string) (entity.Banner, error) {
+	key := bannerKey{featureID: featureID, tagID: tagID, locale: locale}
+
+	r.mu.Lock()
+	if element, ok := r.entries[key]; ok {
+		entry := element.Value.(*bannerEntry)
+		if time.Now().Before(entry.expiresAt) {
+			r.lru.MoveToFront(element)
+			r.mu.Unlock()
+			metrics.BannerLocalCacheRequests.WithLabelValues(metrics.CacheHit).Inc()
+			return entry.banner, nil
+		}
+		r.remove(element)
+	}
+	generation := r.generation
+	r.mu.Unlock()
+
+	metrics.BannerLocalCacheRequests.WithLabelValues(metrics.CacheMiss).Inc()
+	banner, err := r.next.Banner(ctx, featureID, tagID, locale)
+	if err != nil {
+		return entity.Banner{}, err
+	}
+
+	r.mu.Lock()
+	// Invalidation happened while banner was read, it may be stale already
+	if generation == r.generation {
+		r.put(key, banner)
+	}
+	r.mu.Unlock()
+
+	return banner, nil
+}
+
+func (r *BannerRepository) SaveBanner(ctx context.Context, banner entity.Banner, locale string) error {
+	err := r.next.SaveBanner(ctx, banner, locale)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	for _, tagID := range banner.TagIDs {
+		r.put(bannerKey{featureID: banner.FeatureID, tagID: tagID, locale: locale}, banner)
+	}
+	r.mu.Unlock()
+
+	return nil
+}
+
+func (r *BannerRepository) DeleteBanner(ctx context.Context, bannerID int) error {
+	invalidation := entity.BannerInvalidation{BannerID: &bannerID}
+	r.invalidate(invalidation)
+
+	err := r.next.DeleteBanner(ctx, bannerID)
+	if err != nil {
+		return err
+	}
+
+	r.publish(ctx, invalidation)
+	return nil
+}
+
+func (r *BannerRepository) DeleteBannerKeys(ctx context.Context, featureID int, tagIDs []int) error {
+	invalidation := entity.BannerInvalidation{FeatureID: &featureID, TagIDs: tagIDs}
+	r.invalidate(invalidation)
+
+	err := r.next.DeleteBannerKeys(ctx, featureID, tagIDs)
+	if err != nil {
+		return err
+	}
+
+	r.publish(ctx, invalidation)
+	return nil
+}
+
+func (r *BannerRepository) publish(ctx context.Context, invalidation entity.BannerInvalidation) {
+	err := r.invalidations.PublishInvalidation(ctx, invalidation)
+	if err != nil {
+		// Other instances will drop their stale entries on ttl expiration anyway
+		slog.Warn(fmt.Sprintf("failed to publish banner invalidation: %s", err.Error()))
+	}
+}
+
+func (r *BannerRepository) invalidate(invalidation entity.BannerInvalidation) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.generation++
+
+	if invalidation.BannerID != nil {
+		for element := r.lru.Front(); element != nil; {
+			next := element.Next()
+			if element.Value.(*bannerEntry).banner.ID == *invalidation.BannerID {
+				r.remove(element)
+			}
+			element = next
+		}
+	}
+	// Keys of all locales are removed, they can not be looked up without knowing cached locales
+	if invalidation.FeatureID != nil && len(invalidation.TagIDs) > 0 {
+		for element := r.lru.Front(); element != nil; {
+			next := element.Next()
+			key := element.Value.(*bannerEntry).key
+			if key.featureID == *invalidation.FeatureID && slices.Contains(invalidation.TagIDs, key.tagID) {
+				r.remove(element)
+			}
+			element = next
+		}
+	}
+}
+
+// put must be called with mu held. Entry expires at the next window boundary of banner if it is earlier than ttl,
+// so scheduled banner is not served from local cache after it is deactivated or before it is activated
+func (r *BannerRepository) put(key bannerKey, banner entity.Banner) {
+	now := time.Now()
+	ttl := r.ttl
+	if boundary := banner.NextWindowBoundary(now); boundary != nil {
+		ttl = min(ttl, boundary.Sub(now))
+	}
+	expiresAt := now.Add(ttl)
+
+	if element, ok := r.entries[key]; ok {
+		entry := element.Value.(*bannerEntry)
+		entry.banner = banner
+		entry.expiresAt = expiresAt
+		r.lru.MoveToFront(element)
+		return
+	}
+
+	r.entries[key] = r.lru.PushFront(&bannerEntry{key: key, banner: banner, expiresAt: expiresAt})
+	for r.lru.Len() > r.size {
+		r.remove(r.lru.Back())
+	}
+}
+
+// remove must be called with mu held
+func (r *BannerRepository) remove(element *list.Element) {
+	r.lru.Remove(element)
+	delete(r.entries, element.Value.(*bannerEntry).key)
+}
@@ -0,0 +1,195 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/NikolaB131-org/banner-service/internal/entity"
+	"github.com/NikolaB131-org/banner-service/internal/repository"
+)
+
+// maxSessionAccessEntries bounds memory used by cached sessions, expired entries are swept when it is reached
+const maxSessionAccessEntries = 100_000
+
+type (
+	// SessionRepository caches access of active sessions in process memory for a short ttl in front of another
+	// session repository, so authenticated requests do not query database every time. Revocations are broadcast
+	// to other instances, ttl bounds the time revoked session stays usable if broadcast message is lost
+	SessionRepository struct {
+		repository.Session
+		invalidations repository.SessionInvalidation
+		ttl           time.Duration
+
+		mu         sync.Mutex
+		entries    map[string]sessionAccessEntry
+		generation uint64 // incremented on every invalidation
+	}
+
+	sessionAccessEntry struct {
+		access    entity.SessionAccess
+		expiresAt time.Time
+	}
+
+	// RoleRepository invalidates cached access of sessions whose permissions are changed
+	RoleRepository struct {
+		repository.Role
+		sessions *SessionRepository
+	}
+)
+
+func NewSessionRepository(next repository.Session, invalidations repository.SessionInvalidation, ttl time.Duration) *SessionRepository {
+	return &SessionRepository{
+		Session:       next,
+		invalidations: invalidations,
+		ttl:           ttl,
+		entries:       make(map[string]sessionAccessEntry),
+	}
+}
+
+// ListenInvalidations drops entries invalidated by other instances, blocks until ctx is canceled
+func (r *SessionRepository) ListenInvalidations(ctx context.Context) error {
+	return r.invalidations.SubscribeInvalidations(ctx, r.invalidate)
+}
+
+func (r *SessionRepository) SessionAccess(ctx context.Context, sessionID string) (entity.SessionAccess, error) {
+	r.mu.Lock()
+	if entry, ok := r.entries[sessionID]; ok {
+		if time.Now().Before(entry.expiresAt) {
+			r.mu.Unlock()
+			return entry.access, nil
+		}
+		delete(r.entries, sessionID)
+	}
+	generation := r.generation
+	r.mu.Unlock()
+
+	access, err := r.Session.SessionAccess(ctx, sessionID)
+	if err != nil {
+		return entity.SessionAccess{}, err
+	}
+
+	r.mu.Lock()
+	// Invalidation happened while access was read, it may be stale already
+	if generation == r.generation {
+		r.put(sessionID, access)
+	}
+	r.mu.Unlock()
+
+	return access, nil
+}
+
+func (r *SessionRepository) RevokeSession(ctx context.Context, sessionID string) error {
+	err := r.Session.RevokeSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	r.Invalidate(ctx, entity.SessionInvalidation{SessionID: sessionID})
+	return nil
+}
+
+func (r *SessionRepository) RevokeUserSessions(ctx context.Context, userID string) error {
+	err := r.Session.RevokeUserSessions(ctx, userID)
+	if err != nil {
+		return err
+	}
+	r.Invalidate(ctx, entity.SessionInvalidation{UserID: userID})
+	return nil
+}
+
+// Invalidate drops cached access locally and on other instances, publish failure is only logged
+// because database is already changed at this point
+func (r *SessionRepository) Invalidate(ctx context.Context, invalidation entity.SessionInvalidation) {
+	r.invalidate(invalidation)
+
+	err := r.invalidations.PublishInvalidation(ctx, invalidation)
+	if err != nil {
+		slog.Error(fmt.Sprintf("failed to publish session invalidation: %s", err.Error()))
+	}
+}
+
+func (r *SessionRepository) invalidate(invalidation entity.SessionInvalidation) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.generation++
+	switch {
+	case invalidation.All:
+		clear(r.entries)
+	case invalidation.SessionID != "":
+		delete(r.entries, invalidation.SessionID)
+	case invalidation.UserID != "":
+		for sessionID, entry := range r.entries {
+			if entry.access.UserID == invalidation.UserID {
+				delete(r.entries, sessionID)
+			}
+		}
+	}
+}
+
+// put must be called with mu held
+func (r *SessionRepository) put(sessionID string, access entity.SessionAccess) {
+	now := time.Now()
+	if len(r.entries) >= maxSessionAccessEntries {
+		for id, entry := range r.entries {
+			if !now.Before(entry.expiresAt) {
+				delete(r.entries, id)
+			}
+		}
+		if len(r.entries) >= maxSessionAccessEntries {
+			clear(r.entries)
+		}
+	}
+	r.entries[sessionID] = sessionAccessEntry{access: access, expiresAt: now.Add(r.ttl)}
+}
+
+func NewRoleRepository(next repository.Role, sessions *SessionRepository) *RoleRepository {
+	return &RoleRepository{Role: next, sessions: sessions}
+}
+
+func (r *RoleRepository) SetRolePermissions(ctx context.Context, role string, permissions []string) error {
+	err := r.Role.SetRolePermissions(ctx, role, permissions)
+	if err != nil {
+		return err
+	}
+	r.sessions.Invalidate(ctx, entity.SessionInvalidation{All: true})
+	return nil
+}
+
+func (r *RoleRepository) AssignRole(ctx context.Context, userID string, role string) error {
+	err := r.Role.AssignRole(ctx, userID, role)
+	if err != nil {
+		return err
+	}
+	r.sessions.Invalidate(ctx, entity.SessionInvalidation{UserID: userID})
+	return nil
+}
+
+func (r *RoleRepository) RevokeRole(ctx context.Context, userID string, role string) error {
+	err := r.Role.RevokeRole(ctx, userID, role)
+	if err != nil {
+		return err
+	}
+	r.sessions.Invalidate(ctx, entity.SessionInvalidation{UserID: userID})
+	return nil
+}
+
+func (r *RoleRepository) SaveFeatureGrant(ctx context.Context, userID string, featureID int, role string) error {
+	err := r.Role.SaveFeatureGrant(ctx, userID, featureID, role)
+	if err != nil {
+		return err
+	}
+	r.sessions.Invalidate(ctx, entity.SessionInvalidation{UserID: userID})
+	return nil
+}
+
+func (r *RoleRepository) DeleteFeatureGrant(ctx context.Context, userID string, featureID int, role string) error {
+	err := r.Role.DeleteFeatureGrant(ctx, userID, featureID, role)
+	if err != nil {
+		return err
+	}
+	r.sessions.Invalidate(ctx, entity.SessionInvalidation{UserID: userID})
+	return nil
+}
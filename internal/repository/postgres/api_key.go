@@ -0,0 +1,105 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/NikolaB131-org/banner-service/internal/entity"
+	"github.com/NikolaB131-org/banner-service/internal/repository"
+	"github.com/NikolaB131-org/banner-service/pkg/postgres"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type APIKeyRepository struct {
+	Pool *pgxpool.Pool
+}
+
+const apiKeysSelect = "SELECT id, name, prefix, key_hash, scopes, created_by, expires_at, last_used_at, revoked_at, created_at FROM api_keys"
+
+func NewAPIKeyRepository(pg *postgres.Postgres) *APIKeyRepository {
+	return &APIKeyRepository{Pool: pg.Pool}
+}
+
+func (r *APIKeyRepository) SaveAPIKey(ctx context.Context, key entity.APIKey) (string, error) {
+	var id string
+
+	err := r.Pool.QueryRow(ctx, `
+		INSERT INTO api_keys (name, prefix, key_hash, scopes, created_by, expires_at)
+		VALUES (@name, @prefix, @keyHash, @scopes, NULLIF(@createdBy, '')::uuid, @expiresAt)
+		RETURNING id`,
+		pgx.NamedArgs{
+			"name":      key.Name,
+			"prefix":    key.Prefix,
+			"keyHash":   key.KeyHash,
+			"scopes":    key.Scopes,
+			"createdBy": key.CreatedBy,
+			"expiresAt": key.ExpiresAt,
+		},
+	).Scan(&id)
+	if err != nil {
+		return "", fmt.Errorf("failed to scan db row: %w", err)
+	}
+
+	return id, nil
+}
+
+func (r *APIKeyRepository) APIKeys(ctx context.Context) ([]entity.APIKey, error) {
+	rows, err := r.Pool.Query(ctx, apiKeysSelect+" ORDER BY created_at DESC")
+	if err != nil {
+		return []entity.APIKey{}, fmt.Errorf("failed query: %w", err)
+	}
+	keys, err := pgx.CollectRows(rows, pgx.RowToStructByName[entity.APIKey])
+	if err != nil {
+		return []entity.APIKey{}, fmt.Errorf("failed collecting rows: %w", err)
+	}
+
+	return keys, nil
+}
+
+func (r *APIKeyRepository) APIKeyByHash(ctx context.Context, keyHash []byte) (entity.APIKey, error) {
+	rows, err := r.Pool.Query(ctx, apiKeysSelect+" WHERE key_hash = $1", keyHash)
+	if err != nil {
+		return entity.APIKey{}, fmt.Errorf("failed query: %w", err)
+	}
+	key, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[entity.APIKey])
+	if errors.Is(err, pgx.ErrNoRows) {
+		return entity.APIKey{}, repository.ErrNotFound
+	}
+	if err != nil {
+		return entity.APIKey{}, fmt.Errorf("failed collecting row: %w", err)
+	}
+
+	return key, nil
+}
+
+// TouchAPIKey updates last usage time at most once a minute, so frequently used keys do not cause a write per request
+func (r *APIKeyRepository) TouchAPIKey(ctx context.Context, id string) error {
+	_, err := r.Pool.Exec(ctx,
+		"UPDATE api_keys SET last_used_at = now() WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < now() - INTERVAL '1 minute')",
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update api key: %w", err)
+	}
+
+	return nil
+}
+
+func (r *APIKeyRepository) RevokeAPIKey(ctx context.Context, id string) error {
+	res, err := r.Pool.Exec(ctx, "UPDATE api_keys SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL", id)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == invalidTextRepresentationCode {
+		return repository.ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	if res.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
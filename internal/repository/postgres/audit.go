@@ -0,0 +1,88 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/NikolaB131-org/banner-service/internal/entity"
+	"github.com/NikolaB131-org/banner-service/pkg/postgres"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type AuditRepository struct {
+	Pool *pgxpool.Pool
+}
+
+func NewAuditRepository(pg *postgres.Postgres) *AuditRepository {
+	return &AuditRepository{Pool: pg.Pool}
+}
+
+func (r *AuditRepository) SaveRecord(ctx context.Context, record entity.AuditRecord) error {
+	_, err := r.Pool.Exec(ctx, `
+INSERT INTO audit_log (actor_id, action, entity_type, entity_id, changes, request_id)
+VALUES(NULLIF($1, '')::uuid, $2, $3, $4, $5, $6)`,
+		record.ActorID, record.Action, record.EntityType, record.EntityID, record.Changes, record.RequestID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save audit record: %w", err)
+	}
+
+	return nil
+}
+
+// Records returns newest first records matching filter
+func (r *AuditRepository) Records(ctx context.Context, filter entity.AuditFilter, limit int) ([]entity.AuditRecord, error) {
+	var conditions []string
+	if filter.ActorID != nil {
+		conditions = append(conditions, "actor_id = @actorID")
+	}
+	if filter.EntityType != nil {
+		conditions = append(conditions, "entity_type = @entityType")
+	}
+	if filter.EntityID != nil {
+		conditions = append(conditions, "entity_id = @entityID")
+	}
+	if filter.From != nil {
+		conditions = append(conditions, "created_at >= @from")
+	}
+	if filter.To != nil {
+		conditions = append(conditions, "created_at < @to")
+	}
+	if filter.BeforeID != nil {
+		conditions = append(conditions, "id < @beforeID")
+	}
+	queryWherePart := ""
+	if len(conditions) > 0 {
+		queryWherePart = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	query := fmt.Sprintf(`
+SELECT id, actor_id, action, entity_type, entity_id, changes, request_id, created_at
+FROM audit_log
+%s
+ORDER BY id DESC
+LIMIT @limit`, queryWherePart)
+
+	rows, err := r.Pool.Query(ctx, query,
+		pgx.NamedArgs{
+			"actorID":    filter.ActorID,
+			"entityType": filter.EntityType,
+			"entityID":   filter.EntityID,
+			"from":       filter.From,
+			"to":         filter.To,
+			"beforeID":   filter.BeforeID,
+			"limit":      limit,
+		},
+	)
+	if err != nil {
+		return []entity.AuditRecord{}, fmt.Errorf("failed query: %w", err)
+	}
+	records, err := pgx.CollectRows(rows, pgx.RowToStructByName[entity.AuditRecord])
+	if err != nil {
+		return []entity.AuditRecord{}, fmt.Errorf("failed collecting rows: %w", err)
+	}
+
+	return records, nil
+}
@@ -2,12 +2,17 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/NikolaB131-org/banner-service/internal/entity"
 	"github.com/NikolaB131-org/banner-service/internal/repository"
 	"github.com/NikolaB131-org/banner-service/pkg/postgres"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -15,6 +20,51 @@ type BannerRepository struct {
 	Pool *pgxpool.Pool
 }
 
+const (
+	// bannerExperiment and bannerLocales select experiment and localized content of banner from banners table,
+	// both are NULL if banner has none
+	bannerExperiment = `(
+		SELECT json_build_object(
+			'name', e.name,
+			'variants', (
+				SELECT json_agg(json_build_object('name', v.name, 'content', v.content, 'weight', v.weight) ORDER BY v.name)
+				FROM banner_variants v WHERE v.banner_id = e.banner_id
+			)
+		)
+		FROM banner_experiments e WHERE e.banner_id = id
+	)`
+	bannerLocales = `(SELECT jsonb_object_agg(l.locale, l.content) FROM banner_locales l WHERE l.banner_id = id)`
+
+	// bannerColumns selects all entity.Banner fields from banners table
+	bannerColumns = `
+	id,
+	ARRAY(SELECT tag_id FROM banner_tags WHERE banner_id = id) AS tag_ids,
+	feature_id,
+	content,
+	is_active,
+	active_from,
+	active_until,
+	created_at,
+	updated_at,
+	` + bannerExperiment + ` AS experiment,
+	` + bannerLocales + ` AS locales`
+
+	// bannerRevisionColumns selects all entity.BannerRevision fields from banner_revisions table
+	bannerRevisionColumns = `
+	banner_id,
+	version,
+	tag_ids,
+	feature_id,
+	content,
+	is_active,
+	active_from,
+	active_until,
+	experiment,
+	locales,
+	author_id,
+	created_at`
+)
+
 func NewBannerRepository(pg *postgres.Postgres) *BannerRepository {
 	return &BannerRepository{Pool: pg.Pool}
 }
@@ -42,34 +92,23 @@ func (r *BannerRepository) IsExists(ctx context.Context, featureID int, tagID in
 	return isExists, nil
 }
 
-func (r *BannerRepository) Banners(ctx context.Context, featureID *int, tagID *int, limit *int, offset *int) ([]entity.Banner, error) {
-	queryWherePart := ""
-	if featureID != nil || tagID != nil {
-		queryWherePart = "WHERE "
-		if featureID != nil {
-			queryWherePart += "feature_id = @featureID"
-			if tagID != nil {
-				queryWherePart += " AND "
-			}
-		}
-		if tagID != nil {
-			queryWherePart += "EXISTS (SELECT 1 FROM banner_tags WHERE id = banner_id AND tag_id = @tagID)"
-		}
-	}
+func (r *BannerRepository) Banners(
+	ctx context.Context,
+	featureID *int,
+	tagID *int,
+	featureIDs []int,
+	activeAt *time.Time,
+	limit *int,
+	offset *int,
+) ([]entity.Banner, error) {
+	queryWherePart := bannersWherePart(featureID, tagID, featureIDs, activeAt)
 	queryLimitPart := ""
 	if limit != nil {
 		queryLimitPart = "LIMIT @limit"
 	}
 
 	query := fmt.Sprintf(`
-SELECT
-	id,
-	ARRAY(SELECT tag_id FROM banner_tags WHERE banner_id = id) AS tag_ids,
-	feature_id,
-	content,
-	is_active,
-	created_at,
-	updated_at
+SELECT`+bannerColumns+`
 FROM banners
 %s
 OFFSET @offset
@@ -77,10 +116,12 @@ OFFSET @offset
 
 	rows, err := r.Pool.Query(ctx, query,
 		pgx.NamedArgs{
-			"featureID": featureID,
-			"tagID":     tagID,
-			"limit":     limit,
-			"offset":    offset,
+			"featureID":  featureID,
+			"tagID":      tagID,
+			"featureIDs": featureIDs,
+			"activeAt":   activeAt,
+			"limit":      limit,
+			"offset":     offset,
 		},
 	)
 	if err != nil {
@@ -95,30 +136,44 @@ OFFSET @offset
 }
 
 func (r *BannerRepository) BannerById(ctx context.Context, id int) (entity.Banner, error) {
-	var banner entity.Banner
-	err := r.Pool.QueryRow(ctx, `
-SELECT
-	id,
-	ARRAY(SELECT tag_id FROM banner_tags WHERE banner_id = id) AS tag_ids,
-	feature_id,
-	content,
-	is_active,
-	created_at,
-	updated_at
-FROM banners WHERE id = $1`, id).Scan(&banner)
+	rows, err := r.Pool.Query(ctx, `
+SELECT`+bannerColumns+`
+FROM banners WHERE id = $1`, id)
 	if err != nil {
 		return entity.Banner{}, fmt.Errorf("failed query: %w", err)
 	}
+	banner, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[entity.Banner])
+	if errors.Is(err, pgx.ErrNoRows) {
+		return entity.Banner{}, repository.ErrNotFound
+	}
+	if err != nil {
+		return entity.Banner{}, fmt.Errorf("failed collecting row: %w", err)
+	}
 
 	return banner, nil
 }
 
-func (r *BannerRepository) SaveBanner(ctx context.Context, tagIDs []int, featureID int, content map[string]any, isActive bool) (int, error) {
+func (r *BannerRepository) SaveBanner(
+	ctx context.Context,
+	tagIDs []int,
+	featureID int,
+	content map[string]any,
+	isActive bool,
+	activeFrom *time.Time,
+	activeUntil *time.Time,
+	authorID string,
+) (int, error) {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
 	var bannerID int
 
-	err := r.Pool.QueryRow(ctx,
-		"INSERT INTO banners (feature_id, content, is_active) VALUES($1, $2, $3) RETURNING id",
-		featureID, content, isActive,
+	err = tx.QueryRow(ctx,
+		"INSERT INTO banners (feature_id, content, is_active, active_from, active_until) VALUES($1, $2, $3, $4, $5) RETURNING id",
+		featureID, content, isActive, activeFrom, activeUntil,
 	).Scan(&bannerID)
 	if err != nil {
 		return 0, fmt.Errorf("failed query: %w", err)
@@ -128,20 +183,188 @@ func (r *BannerRepository) SaveBanner(ctx context.Context, tagIDs []int, feature
 	for _, tagID := range tagIDs {
 		rows = append(rows, []any{bannerID, tagID})
 	}
-	_, err = r.Pool.CopyFrom(ctx, pgx.Identifier{"banner_tags"}, []string{"banner_id", "tag_id"}, pgx.CopyFromRows(rows))
+	_, err = tx.CopyFrom(ctx, pgx.Identifier{"banner_tags"}, []string{"banner_id", "tag_id"}, pgx.CopyFromRows(rows))
 	if err != nil {
 		return 0, fmt.Errorf("failed to insert ids to banner_tags: %w", err)
 	}
 
+	err = saveRevision(ctx, tx, bannerID, authorID)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	return bannerID, nil
 }
 
-func (r *BannerRepository) UpdateBanner(ctx context.Context, bannerID int, tagIDs []int, featureID *int, content map[string]any, isActive *bool) error {
-	tx, err := r.Pool.Begin(ctx)
+func (r *BannerRepository) UpdateBanner(
+	ctx context.Context,
+	bannerID int,
+	tagIDs []int,
+	featureID *int,
+	content map[string]any,
+	isActive *bool,
+	activeFrom entity.Nullable[time.Time],
+	activeUntil entity.Nullable[time.Time],
+	authorID string,
+	check func(oldBanner entity.Banner) error,
+) (entity.Banner, error) {
+	var oldBanner entity.Banner
+	err := pgx.BeginFunc(ctx, r.Pool, func(tx pgx.Tx) error {
+		var err error
+		oldBanner, err = lockBannerForUpdate(ctx, tx, bannerID, check)
+		if err != nil {
+			return err
+		}
+
+		err = updateBanner(ctx, tx, oldBanner, tagIDs, featureID, content, isActive, activeFrom, activeUntil)
+		if err != nil {
+			return err
+		}
+
+		return saveRevision(ctx, tx, bannerID, authorID)
+	})
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return entity.Banner{}, err
+	}
+
+	return oldBanner, nil
+}
+
+// RestoreBannerRevision brings banner back to the state of revision, including its experiment and localized content,
+// and stores the result as a new revision. Experiment and locales are kept as is for revisions saved before they were stored
+func (r *BannerRepository) RestoreBannerRevision(
+	ctx context.Context,
+	revision entity.BannerRevision,
+	authorID string,
+	check func(oldBanner entity.Banner) error,
+) (entity.Banner, error) {
+	var oldBanner entity.Banner
+	err := pgx.BeginFunc(ctx, r.Pool, func(tx pgx.Tx) error {
+		var err error
+		oldBanner, err = lockBannerForUpdate(ctx, tx, revision.BannerID, check)
+		if err != nil {
+			return err
+		}
+
+		err = updateBanner(
+			ctx,
+			tx,
+			oldBanner,
+			revision.TagIDs,
+			&revision.FeatureID,
+			revision.Content,
+			&revision.IsActive,
+			entity.NewNullable(revision.ActiveFrom),
+			entity.NewNullable(revision.ActiveUntil),
+		)
+		if err != nil {
+			return err
+		}
+
+		if revision.Locales != nil {
+			err = saveExperiment(ctx, tx, revision.BannerID, revision.Experiment)
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.Exec(ctx, "DELETE FROM banner_locales WHERE banner_id = $1", revision.BannerID)
+			if err != nil {
+				return fmt.Errorf("failed to delete banner locales: %w", err)
+			}
+			var rows [][]any
+			for locale, content := range revision.Locales {
+				rows = append(rows, []any{revision.BannerID, locale, content})
+			}
+			_, err = tx.CopyFrom(ctx, pgx.Identifier{"banner_locales"}, []string{"banner_id", "locale", "content"}, pgx.CopyFromRows(rows))
+			if err != nil {
+				return fmt.Errorf("failed to insert banner locales: %w", err)
+			}
+		}
+
+		return saveRevision(ctx, tx, revision.BannerID, authorID)
+	})
+	if err != nil {
+		return entity.Banner{}, err
+	}
+
+	return oldBanner, nil
+}
+
+// lockBannerForUpdate reads banner and locks its row until commit, so concurrent updates of the same banner (tag-only ones
+// do not update the row itself) are serialized and get distinct revision versions. Check is called with the locked state,
+// so decisions made on it (access, validation) can not be outdated by a concurrent update
+func lockBannerForUpdate(ctx context.Context, tx pgx.Tx, bannerID int, check func(oldBanner entity.Banner) error) (entity.Banner, error) {
+	rows, err := tx.Query(ctx, `
+SELECT`+bannerColumns+`
+FROM banners WHERE id = $1 FOR UPDATE`, bannerID)
+	if err != nil {
+		return entity.Banner{}, fmt.Errorf("failed to lock banner: %w", err)
+	}
+	banner, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[entity.Banner])
+	if errors.Is(err, pgx.ErrNoRows) {
+		return entity.Banner{}, repository.ErrNotFound
+	}
+	if err != nil {
+		return entity.Banner{}, fmt.Errorf("failed collecting row: %w", err)
+	}
+
+	if check != nil {
+		err = check(banner)
+		if err != nil {
+			return entity.Banner{}, err
+		}
+	}
+
+	return banner, nil
+}
+
+// updateBanner changes only fields which are set, revision is not saved. Old banner must be locked by lockBannerForUpdate
+func updateBanner(
+	ctx context.Context,
+	tx pgx.Tx,
+	oldBanner entity.Banner,
+	tagIDs []int,
+	featureID *int,
+	content map[string]any,
+	isActive *bool,
+	activeFrom entity.Nullable[time.Time],
+	activeUntil entity.Nullable[time.Time],
+) error {
+	bannerID := oldBanner.ID
+
+	// Moving banner to another feature may conflict with existing banners as well as changing its tags
+	if featureID != nil || tagIDs != nil {
+		newFeatureID := oldBanner.FeatureID
+		if featureID != nil {
+			newFeatureID = *featureID
+		}
+		newTagIDs := oldBanner.TagIDs
+		if tagIDs != nil {
+			newTagIDs = tagIDs
+		}
+
+		isExists := false
+		err := tx.QueryRow(ctx,
+			"SELECT EXISTS(SELECT 1 FROM banners INNER JOIN banner_tags ON id = banner_id WHERE id <> $1 AND feature_id = $2 AND tag_id = ANY($3))",
+			bannerID, newFeatureID, newTagIDs,
+		).Scan(&isExists)
+		if err != nil {
+			return fmt.Errorf("failed to check new banner conflicts with old: %w", err)
+		}
+		if isExists {
+			return repository.ErrAlreadyExists
+		}
+	}
+
+	err := checkBannerReferences(ctx, tx, featureID, tagIDs)
+	if err != nil {
+		return err
 	}
-	defer tx.Rollback(ctx)
 
 	update := func(dbField string, value any) error {
 		query := fmt.Sprintf("UPDATE banners SET %s = $1 WHERE id = $2", dbField)
@@ -170,35 +393,26 @@ func (r *BannerRepository) UpdateBanner(ctx context.Context, bannerID int, tagID
 			return err
 		}
 	}
+	if activeFrom.Set {
+		err := update("active_from", activeFrom.Value)
+		if err != nil {
+			return err
+		}
+	}
+	if activeUntil.Set {
+		err := update("active_until", activeUntil.Value)
+		if err != nil {
+			return err
+		}
+	}
 	if tagIDs != nil {
 		_, err = tx.Exec(ctx, "DELETE FROM banner_tags WHERE banner_id = $1", bannerID)
 		if err != nil {
 			return fmt.Errorf("failed to delete banner tags: %w", err)
 		}
 
-		var oldBanner entity.Banner
-		if featureID == nil {
-			oldBanner, err = r.BannerById(ctx, bannerID)
-			if err != nil {
-				return fmt.Errorf("failed to find old banner: %w", err)
-			}
-		}
-
 		var rows [][]any
 		for _, tagID := range tagIDs {
-			var err error
-			var isExists bool
-			if featureID == nil {
-				isExists, err = r.IsExists(ctx, oldBanner.FeatureID, tagID)
-			} else {
-				isExists, err = r.IsExists(ctx, *featureID, tagID)
-			}
-			if err != nil {
-				return fmt.Errorf("failed to check new banner conflicts with old: %w", err)
-			}
-			if isExists {
-				return repository.ErrAlreadyExists
-			}
 			rows = append(rows, []any{bannerID, tagID})
 		}
 		_, err = tx.CopyFrom(ctx, pgx.Identifier{"banner_tags"}, []string{"banner_id", "tag_id"}, pgx.CopyFromRows(rows))
@@ -207,11 +421,6 @@ func (r *BannerRepository) UpdateBanner(ctx context.Context, bannerID int, tagID
 		}
 	}
 
-	err = tx.Commit(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
-	}
-
 	return nil
 }
 
@@ -226,3 +435,276 @@ func (r *BannerRepository) DeleteBannerByID(ctx context.Context, id int) error {
 
 	return nil
 }
+
+// SaveBannerExperiment replaces experiment of banner with all its variants,
+// returns repository.ErrNotFound if banner does not exist
+func (r *BannerRepository) SaveBannerExperiment(ctx context.Context, bannerID int, experiment entity.BannerExperiment, authorID string) error {
+	return pgx.BeginFunc(ctx, r.Pool, func(tx pgx.Tx) error {
+		err := lockBanner(ctx, tx, bannerID)
+		if err != nil {
+			return err
+		}
+
+		err = saveExperiment(ctx, tx, bannerID, &experiment)
+		if err != nil {
+			return err
+		}
+
+		return saveRevision(ctx, tx, bannerID, authorID)
+	})
+}
+
+func (r *BannerRepository) DeleteBannerExperiment(ctx context.Context, bannerID int, authorID string) error {
+	return pgx.BeginFunc(ctx, r.Pool, func(tx pgx.Tx) error {
+		err := lockBanner(ctx, tx, bannerID)
+		if err != nil {
+			return err
+		}
+
+		res, err := tx.Exec(ctx, "DELETE FROM banner_experiments WHERE banner_id = $1", bannerID)
+		if err != nil {
+			return fmt.Errorf("failed to delete banner experiment: %w", err)
+		}
+		if res.RowsAffected() == 0 {
+			return repository.ErrNotFound
+		}
+
+		return saveRevision(ctx, tx, bannerID, authorID)
+	})
+}
+
+// SaveBannerLocale creates or replaces localized content, returns repository.ErrNotFound if banner does not exist
+func (r *BannerRepository) SaveBannerLocale(ctx context.Context, bannerID int, locale string, content map[string]any, authorID string) error {
+	return pgx.BeginFunc(ctx, r.Pool, func(tx pgx.Tx) error {
+		err := lockBanner(ctx, tx, bannerID)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, `
+INSERT INTO banner_locales (banner_id, locale, content) VALUES ($1, $2, $3)
+ON CONFLICT (banner_id, locale) DO UPDATE SET content = EXCLUDED.content`,
+			bannerID, locale, content,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to save banner locale: %w", err)
+		}
+
+		return saveRevision(ctx, tx, bannerID, authorID)
+	})
+}
+
+func (r *BannerRepository) DeleteBannerLocale(ctx context.Context, bannerID int, locale string, authorID string) error {
+	return pgx.BeginFunc(ctx, r.Pool, func(tx pgx.Tx) error {
+		err := lockBanner(ctx, tx, bannerID)
+		if err != nil {
+			return err
+		}
+
+		res, err := tx.Exec(ctx, "DELETE FROM banner_locales WHERE banner_id = $1 AND locale = $2", bannerID, locale)
+		if err != nil {
+			return fmt.Errorf("failed to delete banner locale: %w", err)
+		}
+		if res.RowsAffected() == 0 {
+			return repository.ErrNotFound
+		}
+
+		return saveRevision(ctx, tx, bannerID, authorID)
+	})
+}
+
+// DeleteBanners deletes at most limit banners matching filters and returns deleted ones
+func (r *BannerRepository) DeleteBanners(ctx context.Context, featureID *int, tagID *int, limit int) ([]entity.Banner, error) {
+	query := fmt.Sprintf(`
+DELETE FROM banners WHERE id IN (SELECT id FROM banners %s LIMIT @limit)
+RETURNING`+bannerColumns, bannersWherePart(featureID, tagID, nil, nil))
+
+	rows, err := r.Pool.Query(ctx, query,
+		pgx.NamedArgs{
+			"featureID": featureID,
+			"tagID":     tagID,
+			"limit":     limit,
+		},
+	)
+	if err != nil {
+		return []entity.Banner{}, fmt.Errorf("failed to delete banners: %w", err)
+	}
+	banners, err := pgx.CollectRows(rows, pgx.RowToStructByName[entity.Banner])
+	if err != nil {
+		return []entity.Banner{}, fmt.Errorf("failed collecting rows: %w", err)
+	}
+
+	return banners, nil
+}
+
+func (r *BannerRepository) BannerRevisions(ctx context.Context, bannerID int, limit *int, offset *int) ([]entity.BannerRevision, error) {
+	rows, err := r.Pool.Query(ctx, `
+SELECT`+bannerRevisionColumns+`
+FROM banner_revisions
+WHERE banner_id = @bannerID
+ORDER BY version DESC
+OFFSET @offset
+LIMIT @limit`,
+		pgx.NamedArgs{
+			"bannerID": bannerID,
+			"limit":    limit,
+			"offset":   offset,
+		},
+	)
+	if err != nil {
+		return []entity.BannerRevision{}, fmt.Errorf("failed query: %w", err)
+	}
+	revisions, err := pgx.CollectRows(rows, pgx.RowToStructByName[entity.BannerRevision])
+	if err != nil {
+		return []entity.BannerRevision{}, fmt.Errorf("failed collecting rows: %w", err)
+	}
+
+	return revisions, nil
+}
+
+func (r *BannerRepository) BannerRevision(ctx context.Context, bannerID int, version int) (entity.BannerRevision, error) {
+	rows, err := r.Pool.Query(ctx, `
+SELECT`+bannerRevisionColumns+`
+FROM banner_revisions
+WHERE banner_id = $1 AND version = $2`, bannerID, version)
+	if err != nil {
+		return entity.BannerRevision{}, fmt.Errorf("failed query: %w", err)
+	}
+	revision, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[entity.BannerRevision])
+	if errors.Is(err, pgx.ErrNoRows) {
+		return entity.BannerRevision{}, repository.ErrNotFound
+	}
+	if err != nil {
+		return entity.BannerRevision{}, fmt.Errorf("failed collecting row: %w", err)
+	}
+
+	return revision, nil
+}
+
+// bannersWherePart builds filter by optional conditions, nil featureIDs means any feature while empty means none
+func bannersWherePart(featureID *int, tagID *int, featureIDs []int, activeAt *time.Time) string {
+	var conditions []string
+	if featureID != nil {
+		conditions = append(conditions, "feature_id = @featureID")
+	}
+	if featureIDs != nil {
+		conditions = append(conditions, "feature_id = ANY(@featureIDs)")
+	}
+	if tagID != nil {
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM banner_tags WHERE id = banner_id AND tag_id = @tagID)")
+	}
+	if activeAt != nil {
+		conditions = append(conditions,
+			"is_active AND (active_from IS NULL OR active_from <= @activeAt) AND (active_until IS NULL OR active_until > @activeAt)",
+		)
+	}
+
+	if len(conditions) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(conditions, " AND ")
+}
+
+// lockBanner locks banner row until commit, so concurrent changes of the same banner are serialized
+// and get distinct revision versions, returns repository.ErrNotFound if banner does not exist
+// checkBannerReferences returns repository.ErrReferenceNotFound if feature or any of tags does not exist,
+// existing ones are locked until commit, so they can not be deleted before banner refers to them
+func checkBannerReferences(ctx context.Context, tx pgx.Tx, featureID *int, tagIDs []int) error {
+	if featureID != nil {
+		var id int
+		err := tx.QueryRow(ctx, "SELECT id FROM features WHERE id = $1 FOR KEY SHARE", *featureID).Scan(&id)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("%w: feature %d", repository.ErrReferenceNotFound, *featureID)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to lock feature: %w", err)
+		}
+	}
+
+	if len(tagIDs) > 0 {
+		rows, err := tx.Query(ctx, "SELECT id FROM tags WHERE id = ANY($1) FOR KEY SHARE", tagIDs)
+		if err != nil {
+			return fmt.Errorf("failed to lock tags: %w", err)
+		}
+		existingTagIDs, err := pgx.CollectRows(rows, pgx.RowTo[int])
+		if err != nil {
+			return fmt.Errorf("failed collecting rows: %w", err)
+		}
+		for _, tagID := range tagIDs {
+			if !slices.Contains(existingTagIDs, tagID) {
+				return fmt.Errorf("%w: tag %d", repository.ErrReferenceNotFound, tagID)
+			}
+		}
+	}
+
+	return nil
+}
+
+func lockBanner(ctx context.Context, tx pgx.Tx, bannerID int) error {
+	var id int
+	err := tx.QueryRow(ctx, "SELECT id FROM banners WHERE id = $1 FOR UPDATE", bannerID).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repository.ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to lock banner: %w", err)
+	}
+
+	return nil
+}
+
+// saveExperiment replaces experiment of banner with all its variants, nil experiment just deletes the current one
+func saveExperiment(ctx context.Context, tx pgx.Tx, bannerID int, experiment *entity.BannerExperiment) error {
+	_, err := tx.Exec(ctx, "DELETE FROM banner_experiments WHERE banner_id = $1", bannerID)
+	if err != nil {
+		return fmt.Errorf("failed to delete banner experiment: %w", err)
+	}
+	if experiment == nil {
+		return nil
+	}
+
+	_, err = tx.Exec(ctx, "INSERT INTO banner_experiments (banner_id, name) VALUES ($1, $2)", bannerID, experiment.Name)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolationCode {
+		return repository.ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to insert banner experiment: %w", err)
+	}
+
+	var rows [][]any
+	for _, variant := range experiment.Variants {
+		rows = append(rows, []any{bannerID, variant.Name, variant.Content, variant.Weight})
+	}
+	_, err = tx.CopyFrom(ctx, pgx.Identifier{"banner_variants"}, []string{"banner_id", "name", "content", "weight"}, pgx.CopyFromRows(rows))
+	if err != nil {
+		return fmt.Errorf("failed to insert banner variants: %w", err)
+	}
+
+	return nil
+}
+
+// saveRevision snapshots current banner state (as seen inside tx) as its next revision,
+// locales are stored as empty object if there are none, NULL is left for revisions saved before they were stored
+func saveRevision(ctx context.Context, tx pgx.Tx, bannerID int, authorID string) error {
+	_, err := tx.Exec(ctx, `
+INSERT INTO banner_revisions (banner_id, version, tag_ids, feature_id, content, is_active, active_from, active_until, experiment, locales, author_id)
+SELECT
+	id,
+	COALESCE((SELECT MAX(version) FROM banner_revisions WHERE banner_id = id), 0) + 1,
+	ARRAY(SELECT tag_id FROM banner_tags WHERE banner_id = id),
+	feature_id,
+	content,
+	is_active,
+	active_from,
+	active_until,
+	`+bannerExperiment+`::jsonb,
+	COALESCE(`+bannerLocales+`, '{}'),
+	NULLIF($2, '')::uuid
+FROM banners WHERE id = $1`, bannerID, authorID)
+	if err != nil {
+		return fmt.Errorf("failed to save banner revision: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,130 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/NikolaB131-org/banner-service/internal/entity"
+	"github.com/NikolaB131-org/banner-service/internal/repository"
+	"github.com/NikolaB131-org/banner-service/pkg/postgres"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type BannerDeletionJobRepository struct {
+	Pool *pgxpool.Pool
+}
+
+func NewBannerDeletionJobRepository(pg *postgres.Postgres) *BannerDeletionJobRepository {
+	return &BannerDeletionJobRepository{Pool: pg.Pool}
+}
+
+const (
+	// bannerDeletionJobColumns selects all entity.BannerDeletionJob fields from banner_deletion_jobs table
+	bannerDeletionJobColumns = `id, status, feature_id, tag_id, deleted, error, COALESCE(created_by::text, '') AS created_by, created_at, finished_at`
+	// unfinishedBannerDeletionJob matches jobs which are owned by lease
+	unfinishedBannerDeletionJob = `status IN ('queued', 'running')`
+)
+
+func (r *BannerDeletionJobRepository) SaveBannerDeletionJob(ctx context.Context, job entity.BannerDeletionJob, lease time.Duration) error {
+	_, err := r.Pool.Exec(ctx, `
+		INSERT INTO banner_deletion_jobs (id, status, feature_id, tag_id, created_by, created_at, lease_until)
+		VALUES (@id, @status, @featureID, @tagID, NULLIF(@createdBy, '')::uuid, @createdAt, now() + @lease::interval)`,
+		pgx.NamedArgs{
+			"id":        job.ID,
+			"status":    job.Status,
+			"featureID": job.FeatureID,
+			"tagID":     job.TagID,
+			"createdBy": job.CreatedBy,
+			"createdAt": job.CreatedAt,
+			"lease":     lease,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save banner deletion job: %w", err)
+	}
+
+	return nil
+}
+
+func (r *BannerDeletionJobRepository) UpdateBannerDeletionJob(ctx context.Context, job entity.BannerDeletionJob) error {
+	_, err := r.Pool.Exec(ctx,
+		"UPDATE banner_deletion_jobs SET status = $2, deleted = $3, error = $4, finished_at = $5 WHERE id = $1",
+		job.ID, job.Status, job.Deleted, job.Error, job.FinishedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update banner deletion job: %w", err)
+	}
+
+	return nil
+}
+
+func (r *BannerDeletionJobRepository) BannerDeletionJob(ctx context.Context, id string) (entity.BannerDeletionJob, error) {
+	rows, err := r.Pool.Query(ctx, `
+		SELECT `+bannerDeletionJobColumns+`
+		FROM banner_deletion_jobs WHERE id = $1`,
+		id,
+	)
+	if err != nil {
+		return entity.BannerDeletionJob{}, fmt.Errorf("failed query: %w", err)
+	}
+	job, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[entity.BannerDeletionJob])
+	if errors.Is(err, pgx.ErrNoRows) {
+		return entity.BannerDeletionJob{}, repository.ErrNotFound
+	}
+	if err != nil {
+		return entity.BannerDeletionJob{}, fmt.Errorf("failed collecting row: %w", err)
+	}
+
+	return job, nil
+}
+
+func (r *BannerDeletionJobRepository) DeleteBannerDeletionJobs(ctx context.Context, createdBefore time.Time) error {
+	_, err := r.Pool.Exec(ctx, "DELETE FROM banner_deletion_jobs WHERE created_at < $1", createdBefore)
+	if err != nil {
+		return fmt.Errorf("failed to delete banner deletion jobs: %w", err)
+	}
+
+	return nil
+}
+
+// RenewBannerDeletionJobLeases extends lease of unfinished jobs with ids, finished ones are skipped
+func (r *BannerDeletionJobRepository) RenewBannerDeletionJobLeases(ctx context.Context, ids []string, lease time.Duration) error {
+	_, err := r.Pool.Exec(ctx,
+		"UPDATE banner_deletion_jobs SET lease_until = now() + $2::interval WHERE id = ANY($1) AND "+unfinishedBannerDeletionJob,
+		ids, lease,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to renew banner deletion job leases: %w", err)
+	}
+
+	return nil
+}
+
+// ClaimStaleBannerDeletionJobs takes over up to limit unfinished jobs with expired lease, they are queued again
+// with a new lease. Rows are claimed with one update, so every job is taken over by only one replica
+func (r *BannerDeletionJobRepository) ClaimStaleBannerDeletionJobs(ctx context.Context, lease time.Duration, limit int) ([]entity.BannerDeletionJob, error) {
+	rows, err := r.Pool.Query(ctx, `
+		UPDATE banner_deletion_jobs SET status = $1, lease_until = now() + $2::interval
+		WHERE id IN (
+			SELECT id FROM banner_deletion_jobs
+			WHERE `+unfinishedBannerDeletionJob+` AND lease_until < now()
+			ORDER BY created_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+bannerDeletionJobColumns,
+		entity.BannerDeletionJobQueued, lease, limit,
+	)
+	if err != nil {
+		return []entity.BannerDeletionJob{}, fmt.Errorf("failed query: %w", err)
+	}
+	jobs, err := pgx.CollectRows(rows, pgx.RowToStructByName[entity.BannerDeletionJob])
+	if err != nil {
+		return []entity.BannerDeletionJob{}, fmt.Errorf("failed collecting rows: %w", err)
+	}
+
+	return jobs, nil
+}
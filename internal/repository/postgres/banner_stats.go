@@ -0,0 +1,82 @@
+package postgres
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/NikolaB131-org/banner-service/internal/entity"
+	"github.com/NikolaB131-org/banner-service/pkg/postgres"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type BannerStatsRepository struct {
+	Pool *pgxpool.Pool
+}
+
+func NewBannerStatsRepository(pg *postgres.Postgres) *BannerStatsRepository {
+	return &BannerStatsRepository{Pool: pg.Pool}
+}
+
+func (r *BannerStatsRepository) IncrementBannerStats(ctx context.Context, counters []entity.BannerCounters) error {
+	if len(counters) == 0 {
+		return nil
+	}
+
+	// Rows are locked in the same order by all replicas, so concurrent flushes do not deadlock
+	counters = slices.Clone(counters)
+	slices.SortFunc(counters, func(a, b entity.BannerCounters) int {
+		return cmp.Or(cmp.Compare(a.BannerID, b.BannerID), a.Day.Compare(b.Day), cmp.Compare(a.Variant, b.Variant))
+	})
+
+	bannerIDs := make([]int, len(counters))
+	days := make([]time.Time, len(counters))
+	variants := make([]string, len(counters))
+	impressions := make([]int64, len(counters))
+	clicks := make([]int64, len(counters))
+	for i, c := range counters {
+		bannerIDs[i] = c.BannerID
+		days[i] = c.Day
+		variants[i] = c.Variant
+		impressions[i] = c.Impressions
+		clicks[i] = c.Clicks
+	}
+
+	_, err := r.Pool.Exec(ctx, `
+INSERT INTO banner_stats (banner_id, day, variant, impressions, clicks)
+SELECT c.banner_id, c.day, c.variant, c.impressions, c.clicks
+FROM unnest($1::INT[], $2::DATE[], $3::VARCHAR[], $4::BIGINT[], $5::BIGINT[]) WITH ORDINALITY AS c(banner_id, day, variant, impressions, clicks, n)
+JOIN banners b ON b.id = c.banner_id
+ORDER BY c.n
+ON CONFLICT (banner_id, day, variant) DO UPDATE
+SET impressions = banner_stats.impressions + EXCLUDED.impressions, clicks = banner_stats.clicks + EXCLUDED.clicks`,
+		bannerIDs, days, variants, impressions, clicks,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to increment banner stats: %w", err)
+	}
+
+	return nil
+}
+
+func (r *BannerStatsRepository) BannerStats(ctx context.Context, bannerID int, from time.Time, to time.Time) ([]entity.BannerCounters, error) {
+	rows, err := r.Pool.Query(ctx, `
+SELECT banner_id, day, variant, impressions, clicks
+FROM banner_stats
+WHERE banner_id = $1 AND day BETWEEN $2::DATE AND $3::DATE
+ORDER BY day, variant`,
+		bannerID, from, to,
+	)
+	if err != nil {
+		return []entity.BannerCounters{}, fmt.Errorf("failed query: %w", err)
+	}
+	counters, err := pgx.CollectRows(rows, pgx.RowToStructByName[entity.BannerCounters])
+	if err != nil {
+		return []entity.BannerCounters{}, fmt.Errorf("failed collecting rows: %w", err)
+	}
+
+	return counters, nil
+}
@@ -2,9 +2,13 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
+	"github.com/NikolaB131-org/banner-service/internal/entity"
+	"github.com/NikolaB131-org/banner-service/internal/repository"
 	"github.com/NikolaB131-org/banner-service/pkg/postgres"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -25,3 +29,106 @@ func (r *FeatureRepository) IsExist(ctx context.Context, id int) (bool, error) {
 
 	return isExists, nil
 }
+
+func (r *FeatureRepository) Features(ctx context.Context, limit *int, offset *int) ([]entity.Feature, error) {
+	rows, err := r.Pool.Query(ctx,
+		"SELECT id, name, description, created_at, updated_at FROM features ORDER BY id OFFSET @offset LIMIT @limit",
+		pgx.NamedArgs{
+			"limit":  limit,
+			"offset": offset,
+		},
+	)
+	if err != nil {
+		return []entity.Feature{}, fmt.Errorf("failed query: %w", err)
+	}
+	features, err := pgx.CollectRows(rows, pgx.RowToStructByName[entity.Feature])
+	if err != nil {
+		return []entity.Feature{}, fmt.Errorf("failed collecting rows: %w", err)
+	}
+
+	return features, nil
+}
+
+func (r *FeatureRepository) FeatureByID(ctx context.Context, id int) (entity.Feature, error) {
+	rows, err := r.Pool.Query(ctx, "SELECT id, name, description, created_at, updated_at FROM features WHERE id = $1", id)
+	if err != nil {
+		return entity.Feature{}, fmt.Errorf("failed query: %w", err)
+	}
+	feature, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[entity.Feature])
+	if errors.Is(err, pgx.ErrNoRows) {
+		return entity.Feature{}, repository.ErrNotFound
+	}
+	if err != nil {
+		return entity.Feature{}, fmt.Errorf("failed collecting row: %w", err)
+	}
+
+	return feature, nil
+}
+
+func (r *FeatureRepository) SaveFeature(ctx context.Context, name string, description string) (int, error) {
+	var id int
+
+	err := r.Pool.QueryRow(ctx,
+		"INSERT INTO features (name, description) VALUES($1, $2) RETURNING id",
+		name, description,
+	).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed query: %w", err)
+	}
+
+	return id, nil
+}
+
+func (r *FeatureRepository) UpdateFeature(ctx context.Context, id int, name *string, description *string) error {
+	res, err := r.Pool.Exec(ctx,
+		"UPDATE features SET name = COALESCE($2, name), description = COALESCE($3, description) WHERE id = $1",
+		id, name, description,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update feature: %w", err)
+	}
+	if res.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+// DeleteFeature deletes feature and returns its deleted banners,
+// without cascade ErrInUse is returned if feature has any banners
+func (r *FeatureRepository) DeleteFeature(ctx context.Context, id int, cascade bool) ([]entity.Banner, error) {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return []entity.Banner{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `
+DELETE FROM banners WHERE feature_id = $1
+RETURNING`+bannerColumns, id)
+	if err != nil {
+		return []entity.Banner{}, fmt.Errorf("failed to delete feature banners: %w", err)
+	}
+	banners, err := pgx.CollectRows(rows, pgx.RowToStructByName[entity.Banner])
+	if err != nil {
+		return []entity.Banner{}, fmt.Errorf("failed collecting rows: %w", err)
+	}
+	if len(banners) > 0 && !cascade {
+		return []entity.Banner{}, repository.ErrInUse
+	}
+
+	res, err := tx.Exec(ctx, "DELETE FROM features WHERE id = $1", id)
+	if err != nil {
+		return []entity.Banner{}, fmt.Errorf("failed to delete feature: %w", err)
+	}
+	if res.RowsAffected() == 0 {
+		return []entity.Banner{}, repository.ErrNotFound
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return []entity.Banner{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return banners, nil
+}
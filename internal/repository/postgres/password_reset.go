@@ -0,0 +1,87 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/NikolaB131-org/banner-service/internal/repository"
+	"github.com/NikolaB131-org/banner-service/pkg/postgres"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PasswordResetRepository struct {
+	Pool *pgxpool.Pool
+}
+
+func NewPasswordResetRepository(pg *postgres.Postgres) *PasswordResetRepository {
+	return &PasswordResetRepository{Pool: pg.Pool}
+}
+
+func (r *PasswordResetRepository) SavePasswordResetToken(ctx context.Context, userID string, tokenHash []byte, expiresAt time.Time) error {
+	_, err := r.Pool.Exec(ctx,
+		"INSERT INTO password_reset_tokens (token_hash, user_id, expires_at) VALUES ($1, $2, $3)",
+		tokenHash, userID, expiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save password reset token: %w", err)
+	}
+
+	return nil
+}
+
+// PasswordResetTokenUserID returns user id of valid unused token without using it
+func (r *PasswordResetRepository) PasswordResetTokenUserID(ctx context.Context, tokenHash []byte) (string, error) {
+	var userID string
+
+	err := r.Pool.QueryRow(ctx,
+		"SELECT user_id FROM password_reset_tokens WHERE token_hash = $1 AND used_at IS NULL AND expires_at > now()",
+		tokenHash,
+	).Scan(&userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", repository.ErrNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to scan db row: %w", err)
+	}
+
+	return userID, nil
+}
+
+// UsePasswordResetToken marks token and all other unused tokens of its user as used and returns user id,
+// returns repository.ErrNotFound if token does not exist, is already used or expired
+func (r *PasswordResetRepository) UsePasswordResetToken(ctx context.Context, tokenHash []byte) (string, error) {
+	var userID string
+
+	err := pgx.BeginFunc(ctx, r.Pool, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx, `
+			UPDATE password_reset_tokens SET used_at = now()
+			WHERE token_hash = $1 AND used_at IS NULL AND expires_at > now()
+			RETURNING user_id`,
+			tokenHash,
+		).Scan(&userID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repository.ErrNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to scan db row: %w", err)
+		}
+
+		_, err = tx.Exec(ctx,
+			"UPDATE password_reset_tokens SET used_at = now() WHERE user_id = $1 AND used_at IS NULL",
+			userID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to invalidate password reset tokens: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return userID, nil
+}
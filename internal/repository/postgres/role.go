@@ -0,0 +1,240 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/NikolaB131-org/banner-service/internal/entity"
+	"github.com/NikolaB131-org/banner-service/internal/repository"
+	"github.com/NikolaB131-org/banner-service/pkg/postgres"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type RoleRepository struct {
+	Pool *pgxpool.Pool
+}
+
+const (
+	foreignKeyViolationCode = "23503"
+	uniqueViolationCode     = "23505"
+	// invalidTextRepresentationCode is returned when malformed id is cast to uuid
+	invalidTextRepresentationCode = "22P02"
+)
+
+func NewRoleRepository(pg *postgres.Postgres) *RoleRepository {
+	return &RoleRepository{Pool: pg.Pool}
+}
+
+func (r *RoleRepository) Roles(ctx context.Context) ([]entity.Role, error) {
+	rows, err := r.Pool.Query(ctx, `
+		SELECT r.name, r.description, COALESCE(array_agg(rp.permission ORDER BY rp.permission) FILTER (WHERE rp.permission IS NOT NULL), '{}') AS permissions
+		FROM roles r
+		LEFT JOIN role_permissions rp ON rp.role = r.name
+		GROUP BY r.name
+		ORDER BY r.name`,
+	)
+	if err != nil {
+		return []entity.Role{}, fmt.Errorf("failed query: %w", err)
+	}
+	roles, err := pgx.CollectRows(rows, pgx.RowToStructByName[entity.Role])
+	if err != nil {
+		return []entity.Role{}, fmt.Errorf("failed collecting rows: %w", err)
+	}
+
+	return roles, nil
+}
+
+func (r *RoleRepository) Permissions(ctx context.Context) ([]entity.Permission, error) {
+	rows, err := r.Pool.Query(ctx, "SELECT name, description FROM permissions ORDER BY name")
+	if err != nil {
+		return []entity.Permission{}, fmt.Errorf("failed query: %w", err)
+	}
+	permissions, err := pgx.CollectRows(rows, pgx.RowToStructByName[entity.Permission])
+	if err != nil {
+		return []entity.Permission{}, fmt.Errorf("failed collecting rows: %w", err)
+	}
+
+	return permissions, nil
+}
+
+func (r *RoleRepository) SetRolePermissions(ctx context.Context, role string, permissions []string) error {
+	return pgx.BeginFunc(ctx, r.Pool, func(tx pgx.Tx) error {
+		isExists := false
+		err := tx.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM roles WHERE name = $1)", role).Scan(&isExists)
+		if err != nil {
+			return fmt.Errorf("failed to scan db row: %w", err)
+		}
+		if !isExists {
+			return repository.ErrNotFound
+		}
+
+		_, err = tx.Exec(ctx, "DELETE FROM role_permissions WHERE role = $1", role)
+		if err != nil {
+			return fmt.Errorf("failed to delete role permissions: %w", err)
+		}
+		_, err = tx.Exec(ctx,
+			"INSERT INTO role_permissions (role, permission) SELECT $1, unnest($2::VARCHAR[])",
+			role, permissions,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert role permissions: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func (r *RoleRepository) UserRoles(ctx context.Context, userID string) ([]string, error) {
+	rows, err := r.Pool.Query(ctx, "SELECT role FROM user_roles WHERE user_id = $1 ORDER BY role", userID)
+	if err != nil {
+		return []string{}, fmt.Errorf("failed query: %w", err)
+	}
+	roles, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return []string{}, fmt.Errorf("failed collecting rows: %w", err)
+	}
+
+	return roles, nil
+}
+
+func (r *RoleRepository) UserPermissions(ctx context.Context, userID string) ([]string, error) {
+	rows, err := r.Pool.Query(ctx, `
+		SELECT DISTINCT rp.permission
+		FROM user_roles ur
+		JOIN role_permissions rp ON rp.role = ur.role
+		WHERE ur.user_id = $1`,
+		userID,
+	)
+	if err != nil {
+		return []string{}, fmt.Errorf("failed query: %w", err)
+	}
+	permissions, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return []string{}, fmt.Errorf("failed collecting rows: %w", err)
+	}
+
+	return permissions, nil
+}
+
+// AssignRole is idempotent, returns repository.ErrNotFound if user or role does not exist
+func (r *RoleRepository) AssignRole(ctx context.Context, userID string, role string) error {
+	_, err := r.Pool.Exec(ctx,
+		"INSERT INTO user_roles (user_id, role) VALUES ($1, $2) ON CONFLICT DO NOTHING",
+		userID, role,
+	)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolationCode {
+		return repository.ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to assign role: %w", err)
+	}
+
+	return nil
+}
+
+func (r *RoleRepository) RevokeRole(ctx context.Context, userID string, role string) error {
+	res, err := r.Pool.Exec(ctx, "DELETE FROM user_roles WHERE user_id = $1 AND role = $2", userID, role)
+	if err != nil {
+		return fmt.Errorf("failed to revoke role: %w", err)
+	}
+	if res.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+// RoleUsersCount counts only enabled users
+func (r *RoleRepository) RoleUsersCount(ctx context.Context, role string) (int, error) {
+	var count int
+	err := r.Pool.QueryRow(ctx,
+		"SELECT COUNT(*) FROM user_roles ur JOIN users u ON u.id = ur.user_id WHERE ur.role = $1 AND u.disabled_at IS NULL",
+		role,
+	).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to scan db row: %w", err)
+	}
+
+	return count, nil
+}
+
+func (r *RoleRepository) FeatureGrants(ctx context.Context, userID string) ([]entity.FeatureGrant, error) {
+	rows, err := r.Pool.Query(ctx,
+		"SELECT feature_id, role, created_at FROM feature_grants WHERE user_id = $1 ORDER BY feature_id, role",
+		userID,
+	)
+	if err != nil {
+		return []entity.FeatureGrant{}, fmt.Errorf("failed query: %w", err)
+	}
+	grants, err := pgx.CollectRows(rows, pgx.RowToStructByName[entity.FeatureGrant])
+	if err != nil {
+		return []entity.FeatureGrant{}, fmt.Errorf("failed collecting rows: %w", err)
+	}
+
+	return grants, nil
+}
+
+func (r *RoleRepository) UserFeaturePermissions(ctx context.Context, userID string) (map[int][]string, error) {
+	rows, err := r.Pool.Query(ctx, `
+		SELECT DISTINCT fg.feature_id, rp.permission
+		FROM feature_grants fg
+		JOIN role_permissions rp ON rp.role = fg.role
+		WHERE fg.user_id = $1`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed query: %w", err)
+	}
+	defer rows.Close()
+
+	permissions := make(map[int][]string)
+	for rows.Next() {
+		var featureID int
+		var permission string
+		if err := rows.Scan(&featureID, &permission); err != nil {
+			return nil, fmt.Errorf("failed to scan db row: %w", err)
+		}
+		permissions[featureID] = append(permissions[featureID], permission)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed reading rows: %w", err)
+	}
+
+	return permissions, nil
+}
+
+// SaveFeatureGrant is idempotent, returns repository.ErrNotFound if user, feature or role does not exist
+func (r *RoleRepository) SaveFeatureGrant(ctx context.Context, userID string, featureID int, role string) error {
+	_, err := r.Pool.Exec(ctx,
+		"INSERT INTO feature_grants (user_id, feature_id, role) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING",
+		userID, featureID, role,
+	)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolationCode {
+		return repository.ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save feature grant: %w", err)
+	}
+
+	return nil
+}
+
+func (r *RoleRepository) DeleteFeatureGrant(ctx context.Context, userID string, featureID int, role string) error {
+	res, err := r.Pool.Exec(ctx,
+		"DELETE FROM feature_grants WHERE user_id = $1 AND feature_id = $2 AND role = $3",
+		userID, featureID, role,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete feature grant: %w", err)
+	}
+	if res.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,155 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/NikolaB131-org/banner-service/internal/entity"
+	"github.com/NikolaB131-org/banner-service/internal/repository"
+	"github.com/NikolaB131-org/banner-service/pkg/postgres"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type SessionRepository struct {
+	Pool *pgxpool.Pool
+}
+
+func NewSessionRepository(pg *postgres.Postgres) *SessionRepository {
+	return &SessionRepository{Pool: pg.Pool}
+}
+
+func (r *SessionRepository) SaveSession(ctx context.Context, userID string, expiresAt time.Time) (string, error) {
+	var id string
+
+	err := r.Pool.QueryRow(ctx,
+		"INSERT INTO sessions (user_id, expires_at) VALUES($1, $2) RETURNING id",
+		userID, expiresAt,
+	).Scan(&id)
+	if err != nil {
+		return "", fmt.Errorf("failed to scan db row: %w", err)
+	}
+
+	return id, nil
+}
+
+// SessionAccess returns user permissions of active session in one query, sessions of disabled users are not active
+func (r *SessionRepository) SessionAccess(ctx context.Context, sessionID string) (entity.SessionAccess, error) {
+	rows, err := r.Pool.Query(ctx, `
+		SELECT
+			s.user_id::text AS user_id,
+			ARRAY(
+				SELECT DISTINCT rp.permission
+				FROM user_roles ur
+				JOIN role_permissions rp ON rp.role = ur.role
+				WHERE ur.user_id = s.user_id
+			) AS permissions,
+			COALESCE((
+				SELECT jsonb_object_agg(g.feature_id, g.permissions)
+				FROM (
+					SELECT fg.feature_id, array_agg(DISTINCT rp.permission) AS permissions
+					FROM feature_grants fg
+					JOIN role_permissions rp ON rp.role = fg.role
+					WHERE fg.user_id = s.user_id
+					GROUP BY fg.feature_id
+				) g
+			), '{}') AS feature_permissions
+		FROM sessions s
+		JOIN users u ON u.id = s.user_id
+		WHERE s.id = $1 AND s.revoked_at IS NULL AND s.expires_at > now() AND u.disabled_at IS NULL`,
+		sessionID,
+	)
+	if err != nil {
+		return entity.SessionAccess{}, fmt.Errorf("failed query: %w", err)
+	}
+	sessionAccess, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[entity.SessionAccess])
+	if errors.Is(err, pgx.ErrNoRows) {
+		return entity.SessionAccess{}, repository.ErrNotFound
+	}
+	if err != nil {
+		return entity.SessionAccess{}, fmt.Errorf("failed collecting row: %w", err)
+	}
+
+	return sessionAccess, nil
+}
+
+func (r *SessionRepository) RevokeSession(ctx context.Context, sessionID string) error {
+	_, err := r.Pool.Exec(ctx, "UPDATE sessions SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL", sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SessionRepository) RevokeUserSessions(ctx context.Context, userID string) error {
+	_, err := r.Pool.Exec(ctx, "UPDATE sessions SET revoked_at = now() WHERE user_id = $1 AND revoked_at IS NULL", userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke user sessions: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SessionRepository) SaveRefreshToken(ctx context.Context, sessionID string, tokenHash []byte, expiresAt time.Time) error {
+	_, err := r.Pool.Exec(ctx,
+		"INSERT INTO refresh_tokens (token_hash, session_id, expires_at) VALUES($1, $2, $3)",
+		tokenHash, sessionID, expiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save refresh token: %w", err)
+	}
+
+	return nil
+}
+
+// UseRefreshToken marks refresh token as used and returns its state before that,
+// so caller can detect token reuse by non nil UsedAt
+func (r *SessionRepository) UseRefreshToken(ctx context.Context, tokenHash []byte) (entity.RefreshToken, error) {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return entity.RefreshToken{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `
+SELECT
+	refresh_tokens.session_id,
+	sessions.user_id,
+	users.username,
+	refresh_tokens.expires_at,
+	refresh_tokens.used_at,
+	sessions.expires_at AS session_expires_at,
+	sessions.revoked_at AS session_revoked_at
+FROM refresh_tokens
+INNER JOIN sessions ON sessions.id = refresh_tokens.session_id
+INNER JOIN users ON users.id = sessions.user_id
+WHERE refresh_tokens.token_hash = $1
+FOR UPDATE OF refresh_tokens`, tokenHash)
+	if err != nil {
+		return entity.RefreshToken{}, fmt.Errorf("failed query: %w", err)
+	}
+	token, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[entity.RefreshToken])
+	if errors.Is(err, pgx.ErrNoRows) {
+		return entity.RefreshToken{}, repository.ErrNotFound
+	}
+	if err != nil {
+		return entity.RefreshToken{}, fmt.Errorf("failed collecting row: %w", err)
+	}
+
+	if token.UsedAt == nil {
+		_, err = tx.Exec(ctx, "UPDATE refresh_tokens SET used_at = now() WHERE token_hash = $1", tokenHash)
+		if err != nil {
+			return entity.RefreshToken{}, fmt.Errorf("failed to mark refresh token used: %w", err)
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return entity.RefreshToken{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return token, nil
+}
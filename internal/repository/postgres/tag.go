@@ -2,9 +2,13 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
+	"github.com/NikolaB131-org/banner-service/internal/entity"
+	"github.com/NikolaB131-org/banner-service/internal/repository"
 	"github.com/NikolaB131-org/banner-service/pkg/postgres"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -25,3 +29,117 @@ func (r *TagRepository) IsExist(ctx context.Context, id int) (bool, error) {
 
 	return isExists, nil
 }
+
+func (r *TagRepository) Tags(ctx context.Context, limit *int, offset *int) ([]entity.Tag, error) {
+	rows, err := r.Pool.Query(ctx,
+		"SELECT id, name, description, created_at, updated_at FROM tags ORDER BY id OFFSET @offset LIMIT @limit",
+		pgx.NamedArgs{
+			"limit":  limit,
+			"offset": offset,
+		},
+	)
+	if err != nil {
+		return []entity.Tag{}, fmt.Errorf("failed query: %w", err)
+	}
+	tags, err := pgx.CollectRows(rows, pgx.RowToStructByName[entity.Tag])
+	if err != nil {
+		return []entity.Tag{}, fmt.Errorf("failed collecting rows: %w", err)
+	}
+
+	return tags, nil
+}
+
+func (r *TagRepository) TagByID(ctx context.Context, id int) (entity.Tag, error) {
+	rows, err := r.Pool.Query(ctx, "SELECT id, name, description, created_at, updated_at FROM tags WHERE id = $1", id)
+	if err != nil {
+		return entity.Tag{}, fmt.Errorf("failed query: %w", err)
+	}
+	tag, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[entity.Tag])
+	if errors.Is(err, pgx.ErrNoRows) {
+		return entity.Tag{}, repository.ErrNotFound
+	}
+	if err != nil {
+		return entity.Tag{}, fmt.Errorf("failed collecting row: %w", err)
+	}
+
+	return tag, nil
+}
+
+func (r *TagRepository) SaveTag(ctx context.Context, name string, description string) (int, error) {
+	var id int
+
+	err := r.Pool.QueryRow(ctx,
+		"INSERT INTO tags (name, description) VALUES($1, $2) RETURNING id",
+		name, description,
+	).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed query: %w", err)
+	}
+
+	return id, nil
+}
+
+func (r *TagRepository) UpdateTag(ctx context.Context, id int, name *string, description *string) error {
+	res, err := r.Pool.Exec(ctx,
+		"UPDATE tags SET name = COALESCE($2, name), description = COALESCE($3, description) WHERE id = $1",
+		id, name, description,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update tag: %w", err)
+	}
+	if res.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+// DeleteTag deletes tag and returns banners which had it, with cascade tag is removed from them
+// and banners left without tags are deleted, otherwise ErrInUse is returned if any banner has this tag
+func (r *TagRepository) DeleteTag(ctx context.Context, id int, cascade bool) ([]entity.Banner, error) {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return []entity.Banner{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `
+SELECT`+bannerColumns+`
+FROM banners
+WHERE EXISTS (SELECT 1 FROM banner_tags WHERE id = banner_id AND tag_id = $1)`, id)
+	if err != nil {
+		return []entity.Banner{}, fmt.Errorf("failed to get tag banners: %w", err)
+	}
+	banners, err := pgx.CollectRows(rows, pgx.RowToStructByName[entity.Banner])
+	if err != nil {
+		return []entity.Banner{}, fmt.Errorf("failed collecting rows: %w", err)
+	}
+	if len(banners) > 0 {
+		if !cascade {
+			return []entity.Banner{}, repository.ErrInUse
+		}
+		_, err = tx.Exec(ctx, `
+WITH untagged AS (DELETE FROM banner_tags WHERE tag_id = $1 RETURNING banner_id)
+DELETE FROM banners
+WHERE id IN (SELECT banner_id FROM untagged)
+	AND NOT EXISTS (SELECT 1 FROM banner_tags WHERE banner_id = id AND tag_id <> $1)`, id)
+		if err != nil {
+			return []entity.Banner{}, fmt.Errorf("failed to delete tag from banners: %w", err)
+		}
+	}
+
+	res, err := tx.Exec(ctx, "DELETE FROM tags WHERE id = $1", id)
+	if err != nil {
+		return []entity.Banner{}, fmt.Errorf("failed to delete tag: %w", err)
+	}
+	if res.RowsAffected() == 0 {
+		return []entity.Banner{}, repository.ErrNotFound
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return []entity.Banner{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return banners, nil
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/NikolaB131-org/banner-service/internal/repository"
 	"github.com/NikolaB131-org/banner-service/pkg/postgres"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -16,6 +17,18 @@ type UserRepository struct {
 	Pool *pgxpool.Pool
 }
 
+const usersSelect = `
+SELECT
+	u.id,
+	u.username,
+	u.password_hash,
+	COALESCE(array_agg(ur.role ORDER BY ur.role) FILTER (WHERE ur.role IS NOT NULL), '{}') AS roles,
+	u.disabled_at,
+	u.password_reset_required,
+	u.created_at
+FROM users u
+LEFT JOIN user_roles ur ON ur.user_id = u.id`
+
 func NewUserRepository(pg *postgres.Postgres) *UserRepository {
 	return &UserRepository{Pool: pg.Pool}
 }
@@ -36,22 +49,146 @@ func (r *UserRepository) SaveUser(ctx context.Context, user entity.User) (string
 }
 
 func (r *UserRepository) User(ctx context.Context, username string) (entity.User, error) {
-	var user entity.User
+	return r.oneUser(ctx, usersSelect+" WHERE u.username = $1 GROUP BY u.id", username)
+}
+
+func (r *UserRepository) UserByID(ctx context.Context, id string) (entity.User, error) {
+	return r.oneUser(ctx, usersSelect+" WHERE u.id = $1 GROUP BY u.id", id)
+}
+
+func (r *UserRepository) Users(ctx context.Context, limit *int, offset *int) ([]entity.User, error) {
+	rows, err := r.Pool.Query(ctx,
+		usersSelect+" GROUP BY u.id ORDER BY u.created_at, u.id OFFSET @offset LIMIT @limit",
+		pgx.NamedArgs{
+			"limit":  limit,
+			"offset": offset,
+		},
+	)
+	if err != nil {
+		return []entity.User{}, fmt.Errorf("failed query: %w", err)
+	}
+	users, err := pgx.CollectRows(rows, pgx.RowToStructByName[entity.User])
+	if err != nil {
+		return []entity.User{}, fmt.Errorf("failed collecting rows: %w", err)
+	}
+
+	return users, nil
+}
+
+func (r *UserRepository) SetUserDisabled(ctx context.Context, id string, isDisabled bool) error {
+	res, err := r.Pool.Exec(ctx,
+		"UPDATE users SET disabled_at = CASE WHEN $2 THEN COALESCE(disabled_at, now()) END WHERE id = $1",
+		id, isDisabled,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	if res.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *UserRepository) SetPasswordResetRequired(ctx context.Context, id string, isRequired bool) error {
+	res, err := r.Pool.Exec(ctx, "UPDATE users SET password_reset_required = $2 WHERE id = $1", id, isRequired)
+	if err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	if res.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+// SetPassword also clears password reset requirement
+func (r *UserRepository) SetPassword(ctx context.Context, id string, passwordHash []byte) error {
+	res, err := r.Pool.Exec(ctx,
+		"UPDATE users SET password_hash = $2, password_reset_required = false WHERE id = $1",
+		id, passwordHash,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	if res.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
 
-	row := r.Pool.QueryRow(ctx, "SELECT id, username, password_hash, role FROM users WHERE username = $1", username)
-	err := row.Scan(&user.ID, &user.Username, &user.PasswordHash, &user.Role)
+	return nil
+}
 
+// ReplacePasswordHash updates hash only if it is still equal to oldHash,
+// so rehashing on login can not revert password changed concurrently
+func (r *UserRepository) ReplacePasswordHash(ctx context.Context, id string, oldHash []byte, newHash []byte) error {
+	_, err := r.Pool.Exec(ctx,
+		"UPDATE users SET password_hash = $3 WHERE id = $1 AND password_hash = $2",
+		id, oldHash, newHash,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+
+	return nil
+}
+
+func (r *UserRepository) DeleteUser(ctx context.Context, id string) error {
+	res, err := r.Pool.Exec(ctx, "DELETE FROM users WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	if res.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *UserRepository) UserIDByIdentity(ctx context.Context, issuer string, subject string) (string, error) {
+	var userID string
+
+	err := r.Pool.QueryRow(ctx,
+		"SELECT user_id FROM user_identities WHERE issuer = $1 AND subject = $2",
+		issuer, subject,
+	).Scan(&userID)
 	if errors.Is(err, pgx.ErrNoRows) {
-		return entity.User{}, repository.ErrNotFound
+		return "", repository.ErrNotFound
 	}
 	if err != nil {
-		return entity.User{}, fmt.Errorf("failed to scan db row: %w", err)
+		return "", fmt.Errorf("failed to scan db row: %w", err)
 	}
 
-	return user, nil
+	return userID, nil
 }
 
-func (r *UserRepository) GrantAdminPermission(ctx context.Context, userID string) error {
-	_, err := r.Pool.Exec(ctx, "UPDATE users SET role = 'admin' WHERE id = $1", userID)
-	return err
+func (r *UserRepository) SaveIdentity(ctx context.Context, userID string, issuer string, subject string) error {
+	_, err := r.Pool.Exec(ctx,
+		"INSERT INTO user_identities (issuer, subject, user_id) VALUES ($1, $2, $3)",
+		issuer, subject, userID,
+	)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode {
+		return repository.ErrAlreadyExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save identity: %w", err)
+	}
+
+	return nil
+}
+
+func (r *UserRepository) oneUser(ctx context.Context, sql string, args ...any) (entity.User, error) {
+	rows, err := r.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return entity.User{}, fmt.Errorf("failed query: %w", err)
+	}
+	user, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[entity.User])
+	if errors.Is(err, pgx.ErrNoRows) {
+		return entity.User{}, repository.ErrNotFound
+	}
+	if err != nil {
+		return entity.User{}, fmt.Errorf("failed collecting row: %w", err)
+	}
+
+	return user, nil
 }
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
+	"math/rand/v2"
 	"time"
 
 	"github.com/NikolaB131-org/banner-service/internal/entity"
@@ -16,28 +18,58 @@ import (
 type BannerRepository struct {
 	Client    *redis.Client
 	BannerTTL time.Duration
+	// EarlyRefresh enables probabilistic early expiration (XFetch), the bigger it is
+	// the earlier before real expiration entries may be reported as missing, 0 disables it
+	EarlyRefresh time.Duration
 }
 
+// Banner key points to banner id, data of banner is a hash with banner prepared for every requested locale,
+// so all locales of banner are dropped at once when it changes. Data key prefix differs from the one of
+// plain string data used before locales, so instances of both versions do not collide during rollout
 const (
-	bannerKey     string = "banner:feature_id=%d,tag_id=%d"
-	bannerDataKey string = "banner-data:%v"
+	bannerKey           string = "banner:feature_id=%d,tag_id=%d"
+	bannerDataKeyPrefix string = "banner-locales:"
+	bannerDataKey       string = bannerDataKeyPrefix + "%v"
 )
 
-func NewBannerRepository(client *redisPkg.Redis, bannerTTL time.Duration) *BannerRepository {
-	return &BannerRepository{Client: client.Client, BannerTTL: bannerTTL}
+func NewBannerRepository(client *redisPkg.Redis, bannerTTL time.Duration, earlyRefresh time.Duration) *BannerRepository {
+	return &BannerRepository{Client: client.Client, BannerTTL: bannerTTL, EarlyRefresh: earlyRefresh}
 }
 
-func (r *BannerRepository) Banner(ctx context.Context, featureID int, tagID int) (entity.Banner, error) {
-	bannerDataID, err := r.Client.Get(ctx, fmt.Sprintf(bannerKey, featureID, tagID)).Result()
+// bannerScript resolves banner key to banner data of locale in one round trip. Pointer key and data key expire
+// independently, so pointer to already missing data is removed and reported as a miss, missing locale is just a miss.
+// Returns {data, pointer pttl} or nil. Data key is built inside the script, so it is not cluster safe
+var bannerScript = redis.NewScript(`
+local id = redis.call("GET", KEYS[1])
+if not id then
+	return nil
+end
+local data = redis.call("HGET", ARGV[1] .. id, ARGV[2])
+if not data then
+	if redis.call("EXISTS", ARGV[1] .. id) == 0 then
+		redis.call("DEL", KEYS[1])
+	end
+	return nil
+end
+return {data, redis.call("PTTL", KEYS[1])}
+`)
+
+func (r *BannerRepository) Banner(ctx context.Context, featureID int, tagID int, locale string) (entity.Banner, error) {
+	result, err := bannerScript.Run(ctx, r.Client, []string{fmt.Sprintf(bannerKey, featureID, tagID)}, bannerDataKeyPrefix, locale).Slice()
 	if errors.Is(err, redis.Nil) {
 		return entity.Banner{}, repository.ErrNotFound
 	}
 	if err != nil {
-		return entity.Banner{}, fmt.Errorf("redis get banner uuid failed: %w", err)
+		return entity.Banner{}, fmt.Errorf("redis get banner failed: %w", err)
 	}
-	bannerData, err := r.Client.Get(ctx, fmt.Sprintf(bannerDataKey, bannerDataID)).Result()
-	if err != nil {
-		return entity.Banner{}, fmt.Errorf("redis get banner data failed: %w", err)
+	if len(result) != 2 {
+		return entity.Banner{}, fmt.Errorf("redis get banner returned %d values instead of 2", len(result))
+	}
+	bannerData, _ := result[0].(string)
+	ttl, _ := result[1].(int64)
+
+	if r.shouldRefreshEarly(time.Duration(ttl) * time.Millisecond) {
+		return entity.Banner{}, repository.ErrNotFound
 	}
 
 	var banner entity.Banner
@@ -49,7 +81,14 @@ func (r *BannerRepository) Banner(ctx context.Context, featureID int, tagID int)
 	return banner, nil
 }
 
-func (r *BannerRepository) SaveBanner(ctx context.Context, banner entity.Banner) error {
+// SaveBanner prolongs data of other locales of banner as well, they are dropped together on banner change anyway
+func (r *BannerRepository) SaveBanner(ctx context.Context, banner entity.Banner, locale string) error {
+	ttl := r.bannerTTL(banner)
+	// Window boundary is already reached, entry would be stale at once (zero ttl also means no expiration for redis)
+	if ttl <= 0 {
+		return nil
+	}
+
 	_, err := r.Client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
 		bannerData, err := json.Marshal(banner)
 		if err != nil {
@@ -57,14 +96,20 @@ func (r *BannerRepository) SaveBanner(ctx context.Context, banner entity.Banner)
 		}
 
 		for _, tagID := range banner.TagIDs {
-			err := pipe.SetEx(ctx, fmt.Sprintf(bannerKey, banner.FeatureID, tagID), banner.ID, r.BannerTTL).Err()
+			// Set uses milliseconds for ttl which is not a whole number of seconds, SetEx would round it up to a second
+			// and serve banner after its activation window boundary
+			err := pipe.Set(ctx, fmt.Sprintf(bannerKey, banner.FeatureID, tagID), banner.ID, ttl).Err()
 			if err != nil {
-				return fmt.Errorf("redis setex failed: %w", err)
+				return fmt.Errorf("redis set failed: %w", err)
 			}
 		}
-		err = pipe.SetEx(ctx, fmt.Sprintf(bannerDataKey, banner.ID), bannerData, r.BannerTTL).Err()
+		err = pipe.HSet(ctx, fmt.Sprintf(bannerDataKey, banner.ID), locale, bannerData).Err()
+		if err != nil {
+			return fmt.Errorf("redis hset failed: %w", err)
+		}
+		err = pipe.PExpire(ctx, fmt.Sprintf(bannerDataKey, banner.ID), ttl).Err()
 		if err != nil {
-			return fmt.Errorf("redis setex failed: %w", err)
+			return fmt.Errorf("redis pexpire failed: %w", err)
 		}
 
 		return nil
@@ -76,3 +121,49 @@ func (r *BannerRepository) SaveBanner(ctx context.Context, banner entity.Banner)
 
 	return nil
 }
+
+// shouldRefreshEarly randomly reports entry as expired with probability growing as its ttl runs out,
+// so hot entry is usually recomputed by a single request before it actually expires for everyone
+func (r *BannerRepository) shouldRefreshEarly(ttl time.Duration) bool {
+	if r.EarlyRefresh <= 0 || ttl <= 0 {
+		return false
+	}
+	return float64(ttl) < -float64(r.EarlyRefresh)*math.Log(rand.Float64())
+}
+
+// bannerTTL limits cache ttl by the next banner activation window boundary,
+// so cached entry expires exactly when banner becomes active or inactive
+func (r *BannerRepository) bannerTTL(banner entity.Banner) time.Duration {
+	now := time.Now()
+	boundary := banner.NextWindowBoundary(now)
+	if boundary != nil && boundary.Sub(now) < r.BannerTTL {
+		return boundary.Sub(now)
+	}
+	return r.BannerTTL
+}
+
+func (r *BannerRepository) DeleteBanner(ctx context.Context, bannerID int) error {
+	err := r.Client.Del(ctx, fmt.Sprintf(bannerDataKey, bannerID)).Err()
+	if err != nil {
+		return fmt.Errorf("redis del failed: %w", err)
+	}
+
+	return nil
+}
+
+func (r *BannerRepository) DeleteBannerKeys(ctx context.Context, featureID int, tagIDs []int) error {
+	if len(tagIDs) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(tagIDs))
+	for _, tagID := range tagIDs {
+		keys = append(keys, fmt.Sprintf(bannerKey, featureID, tagID))
+	}
+	err := r.Client.Del(ctx, keys...).Err()
+	if err != nil {
+		return fmt.Errorf("redis del failed: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/NikolaB131-org/banner-service/internal/entity"
+	redisPkg "github.com/NikolaB131-org/banner-service/pkg/redis"
+	"github.com/redis/go-redis/v9"
+)
+
+type (
+	BannerInvalidationRepository struct {
+		Client *redis.Client
+	}
+
+	SessionInvalidationRepository struct {
+		Client *redis.Client
+	}
+)
+
+const (
+	bannerInvalidationChannel  string = "banner-invalidation"
+	sessionInvalidationChannel string = "session-invalidation"
+)
+
+func NewBannerInvalidationRepository(client *redisPkg.Redis) *BannerInvalidationRepository {
+	return &BannerInvalidationRepository{Client: client.Client}
+}
+
+func (r *BannerInvalidationRepository) PublishInvalidation(ctx context.Context, invalidation entity.BannerInvalidation) error {
+	return publish(ctx, r.Client, bannerInvalidationChannel, invalidation)
+}
+
+func (r *BannerInvalidationRepository) SubscribeInvalidations(ctx context.Context, handler func(entity.BannerInvalidation)) error {
+	return subscribe(ctx, r.Client, bannerInvalidationChannel, handler)
+}
+
+func NewSessionInvalidationRepository(client *redisPkg.Redis) *SessionInvalidationRepository {
+	return &SessionInvalidationRepository{Client: client.Client}
+}
+
+func (r *SessionInvalidationRepository) PublishInvalidation(ctx context.Context, invalidation entity.SessionInvalidation) error {
+	return publish(ctx, r.Client, sessionInvalidationChannel, invalidation)
+}
+
+func (r *SessionInvalidationRepository) SubscribeInvalidations(ctx context.Context, handler func(entity.SessionInvalidation)) error {
+	return subscribe(ctx, r.Client, sessionInvalidationChannel, handler)
+}
+
+func publish[T any](ctx context.Context, client *redis.Client, channel string, invalidation T) error {
+	message, err := json.Marshal(invalidation)
+	if err != nil {
+		return fmt.Errorf("failed parsing invalidation to json string: %w", err)
+	}
+
+	err = client.Publish(ctx, channel, message).Err()
+	if err != nil {
+		return fmt.Errorf("redis publish failed: %w", err)
+	}
+
+	return nil
+}
+
+func subscribe[T any](ctx context.Context, client *redis.Client, channel string, handler func(T)) error {
+	pubsub := client.Subscribe(ctx, channel)
+	defer pubsub.Close()
+
+	// Waiting for confirmation so that invalidations published after return of Receive are not lost
+	_, err := pubsub.Receive(ctx)
+	if err != nil {
+		return fmt.Errorf("redis subscribe failed: %w", err)
+	}
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case message, ok := <-messages:
+			if !ok {
+				return nil
+			}
+			var invalidation T
+			err := json.Unmarshal([]byte(message.Payload), &invalidation)
+			if err != nil {
+				slog.Warn(fmt.Sprintf("failed parsing %s message: %s", channel, err.Error()))
+				continue
+			}
+			handler(invalidation)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	redisPkg "github.com/NikolaB131-org/banner-service/pkg/redis"
+	"github.com/redis/go-redis/v9"
+)
+
+type LoginAttemptsRepository struct {
+	Client *redis.Client
+}
+
+const (
+	loginFailuresKeyPrefix string = "login:failures:"
+	loginLockKeyPrefix     string = "login:lock:"
+)
+
+func NewLoginAttemptsRepository(client *redisPkg.Redis) *LoginAttemptsRepository {
+	return &LoginAttemptsRepository{Client: client.Client}
+}
+
+// reserveScript returns the longest remaining lock of keys if any of them is locked, otherwise increments
+// failures counter of every key and returns 0. Key that reaches its limit is locked at once, lockout doubles
+// for every attempt after the limit. Failures counter is kept for window after lock expiration,
+// so the next attempt continues from the same count.
+// KEYS are {failures, lock} pairs, ARGV are window, lockout, max lockout in ms and limit of every pair
+var reserveScript = redis.NewScript(`
+local lockedFor = 0
+for i = 2, #KEYS, 2 do
+	lockedFor = math.max(lockedFor, redis.call("PTTL", KEYS[i]))
+end
+if lockedFor > 0 then
+	return lockedFor
+end
+local window, lockout, maxLockout = tonumber(ARGV[1]), tonumber(ARGV[2]), tonumber(ARGV[3])
+for i = 1, #KEYS, 2 do
+	local maxFailures = tonumber(ARGV[3 + (i + 1) / 2])
+	local failures = redis.call("INCR", KEYS[i])
+	redis.call("PEXPIRE", KEYS[i], window)
+	if maxFailures > 0 and failures >= maxFailures then
+		local duration = math.floor(math.min(lockout * 2 ^ (failures - maxFailures), maxLockout))
+		redis.call("SET", KEYS[i + 1], 1, "PX", duration)
+		redis.call("PEXPIRE", KEYS[i], duration + window)
+	end
+end
+return 0
+`)
+
+// releaseScript decrements failures counter of every key and removes lock that was set by the released attempt.
+// KEYS are {failures, lock} pairs, ARGV are limit of every pair
+var releaseScript = redis.NewScript(`
+for i = 1, #KEYS, 2 do
+	if redis.call("EXISTS", KEYS[i]) == 1 then
+		local maxFailures = tonumber(ARGV[(i + 1) / 2])
+		if redis.call("DECR", KEYS[i]) < maxFailures then
+			redis.call("DEL", KEYS[i + 1])
+		end
+	end
+end
+return 0
+`)
+
+// Reserve counts an attempt for every key of limits (key to max failures, 0 is unlimited) in one step,
+// returns the longest remaining lock and counts nothing if any of keys is locked
+func (r *LoginAttemptsRepository) Reserve(
+	ctx context.Context,
+	limits map[string]int,
+	window time.Duration,
+	lockout time.Duration,
+	maxLockout time.Duration,
+) (time.Duration, error) {
+	keys, limitArgs := attemptsScriptArgs(limits)
+	args := append([]any{window.Milliseconds(), lockout.Milliseconds(), maxLockout.Milliseconds()}, limitArgs...)
+
+	lockedFor, err := reserveScript.Run(ctx, r.Client, keys, args...).Int64()
+	if err != nil {
+		return 0, fmt.Errorf("redis reserve attempt failed: %w", err)
+	}
+
+	return time.Duration(lockedFor) * time.Millisecond, nil
+}
+
+// Release uncounts an attempt reserved for keys of limits, so it is not treated as failure
+func (r *LoginAttemptsRepository) Release(ctx context.Context, limits map[string]int) error {
+	keys, args := attemptsScriptArgs(limits)
+
+	err := releaseScript.Run(ctx, r.Client, keys, args...).Err()
+	if err != nil {
+		return fmt.Errorf("redis release attempt failed: %w", err)
+	}
+
+	return nil
+}
+
+func (r *LoginAttemptsRepository) Reset(ctx context.Context, key string) error {
+	err := r.Client.Del(ctx, loginFailuresKeyPrefix+key, loginLockKeyPrefix+key).Err()
+	if err != nil {
+		return fmt.Errorf("redis del failed: %w", err)
+	}
+
+	return nil
+}
+
+func attemptsScriptArgs(limits map[string]int) ([]string, []any) {
+	keys := make([]string, 0, 2*len(limits))
+	args := make([]any, 0, len(limits))
+	for key, maxFailures := range limits {
+		keys = append(keys, loginFailuresKeyPrefix+key, loginLockKeyPrefix+key)
+		args = append(args, maxFailures)
+	}
+	return keys, args
+}
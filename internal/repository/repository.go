@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/NikolaB131-org/banner-service/internal/entity"
 )
@@ -10,35 +11,198 @@ import (
 var (
 	ErrNotFound      = errors.New("not found")
 	ErrAlreadyExists = errors.New("already exists")
+	ErrInUse         = errors.New("in use")
+	// ErrReferenceNotFound is returned when entity refers to another one that does not exist
+	ErrReferenceNotFound = errors.New("referenced entity not found")
 )
 
 type (
 	User interface {
 		SaveUser(ctx context.Context, user entity.User) (string, error)
 		User(ctx context.Context, username string) (entity.User, error)
-		GrantAdminPermission(ctx context.Context, userID string) error
+		UserByID(ctx context.Context, id string) (entity.User, error)
+		Users(ctx context.Context, limit *int, offset *int) ([]entity.User, error)
+		SetUserDisabled(ctx context.Context, id string, isDisabled bool) error
+		SetPasswordResetRequired(ctx context.Context, id string, isRequired bool) error
+		// SetPassword also clears password reset requirement
+		SetPassword(ctx context.Context, id string, passwordHash []byte) error
+		// ReplacePasswordHash does nothing if hash was changed and is not equal to oldHash anymore
+		ReplacePasswordHash(ctx context.Context, id string, oldHash []byte, newHash []byte) error
+		DeleteUser(ctx context.Context, id string) error
+		// UserIDByIdentity finds user linked to identity of external provider
+		UserIDByIdentity(ctx context.Context, issuer string, subject string) (string, error)
+		SaveIdentity(ctx context.Context, userID string, issuer string, subject string) error
+	}
+
+	Role interface {
+		Roles(ctx context.Context) ([]entity.Role, error)
+		Permissions(ctx context.Context) ([]entity.Permission, error)
+		SetRolePermissions(ctx context.Context, role string, permissions []string) error
+		UserRoles(ctx context.Context, userID string) ([]string, error)
+		UserPermissions(ctx context.Context, userID string) ([]string, error)
+		AssignRole(ctx context.Context, userID string, role string) error
+		RevokeRole(ctx context.Context, userID string, role string) error
+		RoleUsersCount(ctx context.Context, role string) (int, error)
+		FeatureGrants(ctx context.Context, userID string) ([]entity.FeatureGrant, error)
+		// UserFeaturePermissions returns permissions granted to user only for specific features by feature id
+		UserFeaturePermissions(ctx context.Context, userID string) (map[int][]string, error)
+		SaveFeatureGrant(ctx context.Context, userID string, featureID int, role string) error
+		DeleteFeatureGrant(ctx context.Context, userID string, featureID int, role string) error
+	}
+
+	APIKey interface {
+		SaveAPIKey(ctx context.Context, key entity.APIKey) (string, error)
+		APIKeys(ctx context.Context) ([]entity.APIKey, error)
+		APIKeyByHash(ctx context.Context, keyHash []byte) (entity.APIKey, error)
+		TouchAPIKey(ctx context.Context, id string) error
+		// RevokeAPIKey returns ErrNotFound if key does not exist, is already revoked or id is not a uuid
+		RevokeAPIKey(ctx context.Context, id string) error
+	}
+
+	Session interface {
+		SaveSession(ctx context.Context, userID string, expiresAt time.Time) (string, error)
+		// SessionAccess returns ErrNotFound if session is revoked, expired or its user is disabled
+		SessionAccess(ctx context.Context, sessionID string) (entity.SessionAccess, error)
+		RevokeSession(ctx context.Context, sessionID string) error
+		RevokeUserSessions(ctx context.Context, userID string) error
+		SaveRefreshToken(ctx context.Context, sessionID string, tokenHash []byte, expiresAt time.Time) error
+		UseRefreshToken(ctx context.Context, tokenHash []byte) (entity.RefreshToken, error)
+	}
+
+	PasswordReset interface {
+		SavePasswordResetToken(ctx context.Context, userID string, tokenHash []byte, expiresAt time.Time) error
+		PasswordResetTokenUserID(ctx context.Context, tokenHash []byte) (string, error)
+		// UsePasswordResetToken returns user id of valid unused token and invalidates all tokens of the user
+		UsePasswordResetToken(ctx context.Context, tokenHash []byte) (string, error)
 	}
 
 	Banner interface {
 		IsExistsById(ctx context.Context, id int) (bool, error)
 		IsExists(ctx context.Context, featureID int, tagID int) (bool, error)
-		Banners(ctx context.Context, featureID *int, tagID *int, limit *int, offset *int) ([]entity.Banner, error)
+		// Banners filters by featureIDs only if it is not nil
+		Banners(
+			ctx context.Context,
+			featureID *int,
+			tagID *int,
+			featureIDs []int,
+			activeAt *time.Time,
+			limit *int,
+			offset *int,
+		) ([]entity.Banner, error)
 		BannerById(ctx context.Context, id int) (entity.Banner, error)
-		SaveBanner(ctx context.Context, tagIDs []int, featureID int, content map[string]any, isActive bool) (int, error)
-		UpdateBanner(ctx context.Context, bannerID int, tagIDs []int, featureID *int, content map[string]any, isActive *bool) error
+		SaveBanner(
+			ctx context.Context,
+			tagIDs []int,
+			featureID int,
+			content map[string]any,
+			isActive bool,
+			activeFrom *time.Time,
+			activeUntil *time.Time,
+			authorID string,
+		) (int, error)
+		// UpdateBanner locks banner and passes its current state to check, changes are applied only if check returns nil.
+		// Returns state of banner before update
+		UpdateBanner(
+			ctx context.Context,
+			bannerID int,
+			tagIDs []int,
+			featureID *int,
+			content map[string]any,
+			isActive *bool,
+			activeFrom entity.Nullable[time.Time],
+			activeUntil entity.Nullable[time.Time],
+			authorID string,
+			check func(oldBanner entity.Banner) error,
+		) (entity.Banner, error)
 		DeleteBannerByID(ctx context.Context, id int) error
+		DeleteBanners(ctx context.Context, featureID *int, tagID *int, limit int) ([]entity.Banner, error)
+		BannerRevisions(ctx context.Context, bannerID int, limit *int, offset *int) ([]entity.BannerRevision, error)
+		BannerRevision(ctx context.Context, bannerID int, version int) (entity.BannerRevision, error)
+		// RestoreBannerRevision returns ErrNotFound if banner does not exist, ErrAlreadyExists if it conflicts with another one,
+		// ErrReferenceNotFound if feature or tags of revision were deleted since
+		RestoreBannerRevision(
+			ctx context.Context,
+			revision entity.BannerRevision,
+			authorID string,
+			check func(oldBanner entity.Banner) error,
+		) (entity.Banner, error)
+		// Experiment and locale changes are stored as new revisions as well
+		SaveBannerExperiment(ctx context.Context, bannerID int, experiment entity.BannerExperiment, authorID string) error
+		DeleteBannerExperiment(ctx context.Context, bannerID int, authorID string) error
+		SaveBannerLocale(ctx context.Context, bannerID int, locale string, content map[string]any, authorID string) error
+		DeleteBannerLocale(ctx context.Context, bannerID int, locale string, authorID string) error
+	}
+
+	// BannerDeletionJob stores jobs of all replicas, unfinished job is owned by replica which renews its lease
+	BannerDeletionJob interface {
+		SaveBannerDeletionJob(ctx context.Context, job entity.BannerDeletionJob, lease time.Duration) error
+		// UpdateBannerDeletionJob stores status, deleted banners count, error and finish time of job
+		UpdateBannerDeletionJob(ctx context.Context, job entity.BannerDeletionJob) error
+		BannerDeletionJob(ctx context.Context, id string) (entity.BannerDeletionJob, error)
+		// DeleteBannerDeletionJobs deletes jobs created before createdBefore, including unfinished ones
+		DeleteBannerDeletionJobs(ctx context.Context, createdBefore time.Time) error
+		RenewBannerDeletionJobLeases(ctx context.Context, ids []string, lease time.Duration) error
+		// ClaimStaleBannerDeletionJobs takes over unfinished jobs with expired lease, for example of stopped replicas
+		ClaimStaleBannerDeletionJobs(ctx context.Context, lease time.Duration, limit int) ([]entity.BannerDeletionJob, error)
 	}
 
+	BannerStats interface {
+		// IncrementBannerStats adds counters to stored ones, counters of deleted banners are skipped
+		IncrementBannerStats(ctx context.Context, counters []entity.BannerCounters) error
+		// BannerStats returns counters of days from from to to inclusive ordered by day and variant
+		BannerStats(ctx context.Context, bannerID int, from time.Time, to time.Time) ([]entity.BannerCounters, error)
+	}
+
+	// BannerCache keeps banners prepared for a locale by feature, tag and locale
 	BannerCache interface {
-		Banner(ctx context.Context, featureID int, tagID int) (entity.Banner, error)
-		SaveBanner(ctx context.Context, banner entity.Banner) error
+		Banner(ctx context.Context, featureID int, tagID int, locale string) (entity.Banner, error)
+		SaveBanner(ctx context.Context, banner entity.Banner, locale string) error
+		// DeleteBanner removes banner of all locales
+		DeleteBanner(ctx context.Context, bannerID int) error
+		DeleteBannerKeys(ctx context.Context, featureID int, tagIDs []int) error
+	}
+
+	BannerInvalidation interface {
+		PublishInvalidation(ctx context.Context, invalidation entity.BannerInvalidation) error
+		// SubscribeInvalidations calls handler for every published invalidation until ctx is canceled
+		SubscribeInvalidations(ctx context.Context, handler func(entity.BannerInvalidation)) error
+	}
+
+	SessionInvalidation interface {
+		PublishInvalidation(ctx context.Context, invalidation entity.SessionInvalidation) error
+		// SubscribeInvalidations calls handler for every published invalidation until ctx is canceled
+		SubscribeInvalidations(ctx context.Context, handler func(entity.SessionInvalidation)) error
+	}
+
+	// LoginAttempts tracks failed logins by arbitrary keys (username, ip)
+	LoginAttempts interface {
+		// Reserve atomically counts an attempt for every key of limits unless one of them is locked,
+		// returns the longest remaining lock, 0 if attempt is reserved
+		Reserve(ctx context.Context, limits map[string]int, window time.Duration, lockout time.Duration, maxLockout time.Duration) (time.Duration, error)
+		Release(ctx context.Context, limits map[string]int) error
+		Reset(ctx context.Context, key string) error
+	}
+
+	Audit interface {
+		SaveRecord(ctx context.Context, record entity.AuditRecord) error
+		Records(ctx context.Context, filter entity.AuditFilter, limit int) ([]entity.AuditRecord, error)
 	}
 
 	Feature interface {
 		IsExist(ctx context.Context, id int) (bool, error)
+		Features(ctx context.Context, limit *int, offset *int) ([]entity.Feature, error)
+		FeatureByID(ctx context.Context, id int) (entity.Feature, error)
+		SaveFeature(ctx context.Context, name string, description string) (int, error)
+		UpdateFeature(ctx context.Context, id int, name *string, description *string) error
+		DeleteFeature(ctx context.Context, id int, cascade bool) ([]entity.Banner, error)
 	}
 
 	Tag interface {
 		IsExist(ctx context.Context, id int) (bool, error)
+		Tags(ctx context.Context, limit *int, offset *int) ([]entity.Tag, error)
+		TagByID(ctx context.Context, id int) (entity.Tag, error)
+		SaveTag(ctx context.Context, name string, description string) (int, error)
+		UpdateTag(ctx context.Context, id int, name *string, description *string) error
+		DeleteTag(ctx context.Context, id int, cascade bool) ([]entity.Banner, error)
 	}
 )
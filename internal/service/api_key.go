@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/NikolaB131-org/banner-service/internal/app/apikey"
+	"github.com/NikolaB131-org/banner-service/internal/entity"
+	"github.com/NikolaB131-org/banner-service/internal/repository"
+)
+
+type (
+	APIKeyService interface {
+		GetAPIKeys(ctx context.Context) ([]entity.APIKey, error)
+		// Create returns created key and its plain text value, which is not stored anywhere
+		Create(ctx context.Context, name string, scopes []string, expiresAt *time.Time, actorID string) (entity.APIKey, string, error)
+		Revoke(ctx context.Context, id string, actorID string) error
+	}
+
+	APIKey struct {
+		apiKeyRepository repository.APIKey
+		roleRepository   repository.Role
+		auditService     AuditService
+	}
+)
+
+var (
+	ErrAPIKeyNotFound        = errors.New("api key not found or already revoked")
+	ErrAPIKeyScopeNotAllowed = errors.New("scope can not be granted to api key")
+	ErrAPIKeyExpiresInPast   = errors.New("api key expiration time is in the past")
+)
+
+func NewAPIKeyService(apiKeyRepository repository.APIKey, roleRepository repository.Role, auditService AuditService) *APIKey {
+	return &APIKey{
+		apiKeyRepository: apiKeyRepository,
+		roleRepository:   roleRepository,
+		auditService:     auditService,
+	}
+}
+
+func (a *APIKey) GetAPIKeys(ctx context.Context) ([]entity.APIKey, error) {
+	keys, err := a.apiKeyRepository.APIKeys(ctx)
+	if err != nil {
+		return []entity.APIKey{}, fmt.Errorf("failed to get api keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+func (a *APIKey) Create(ctx context.Context, name string, scopes []string, expiresAt *time.Time, actorID string) (entity.APIKey, string, error) {
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return entity.APIKey{}, "", ErrAPIKeyExpiresInPast
+	}
+
+	knownPermissions, err := a.roleRepository.Permissions(ctx)
+	if err != nil {
+		return entity.APIKey{}, "", fmt.Errorf("failed to get permissions: %w", err)
+	}
+	for _, scope := range scopes {
+		// Keys are not tied to a person, so they must not be able to manage users and other keys
+		if scope == entity.PermissionUserManage {
+			return entity.APIKey{}, "", fmt.Errorf("%w: %s", ErrAPIKeyScopeNotAllowed, scope)
+		}
+		isKnown := slices.ContainsFunc(knownPermissions, func(p entity.Permission) bool { return p.Name == scope })
+		if !isKnown {
+			return entity.APIKey{}, "", fmt.Errorf("%w: %s", ErrUnknownPermission, scope)
+		}
+	}
+	scopes = slices.Clone(scopes)
+	slices.Sort(scopes)
+	scopes = slices.Compact(scopes)
+
+	plainKey, prefix, err := apikey.Generate()
+	if err != nil {
+		return entity.APIKey{}, "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+
+	key := entity.APIKey{
+		Name:      name,
+		Prefix:    prefix,
+		KeyHash:   apikey.Hash(plainKey),
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+		CreatedAt: time.Now(),
+	}
+	if actorID != "" {
+		key.CreatedBy = &actorID
+	}
+	key.ID, err = a.apiKeyRepository.SaveAPIKey(ctx, key)
+	if err != nil {
+		return entity.APIKey{}, "", fmt.Errorf("failed to save api key: %w", err)
+	}
+
+	a.auditService.Record(ctx, actorID, entity.AuditActionCreate, entity.AuditEntityAPIKey, key.ID, nil, key)
+
+	return key, plainKey, nil
+}
+
+func (a *APIKey) Revoke(ctx context.Context, id string, actorID string) error {
+	err := a.apiKeyRepository.RevokeAPIKey(ctx, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return ErrAPIKeyNotFound
+		default:
+			return fmt.Errorf("failed to revoke api key: %w", err)
+		}
+	}
+
+	a.auditService.Record(ctx, actorID, entity.AuditActionRevokeAPIKey, entity.AuditEntityAPIKey, id,
+		map[string]any{"revoked": false},
+		map[string]any{"revoked": true},
+	)
+
+	return nil
+}
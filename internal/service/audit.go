@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strconv"
+
+	"github.com/NikolaB131-org/banner-service/internal/app/requestid"
+	"github.com/NikolaB131-org/banner-service/internal/entity"
+	"github.com/NikolaB131-org/banner-service/internal/repository"
+)
+
+type (
+	AuditService interface {
+		Record(ctx context.Context, actorID string, action string, entityType string, entityID string, before any, after any)
+		GetRecords(ctx context.Context, filter entity.AuditFilter, cursor string, limit *int) ([]entity.AuditRecord, string, error)
+	}
+
+	Audit struct {
+		auditRepository repository.Audit
+	}
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+const (
+	defaultAuditLimit = 50
+	maxAuditLimit     = 500
+)
+
+func NewAuditService(auditRepository repository.Audit) *Audit {
+	return &Audit{auditRepository: auditRepository}
+}
+
+// Record saves diff between before and after states, nil before means creation and nil after means deletion.
+// Failures are only logged so audit never breaks already applied mutation
+func (a *Audit) Record(ctx context.Context, actorID string, action string, entityType string, entityID string, before any, after any) {
+	changes, err := diff(before, after)
+	if err != nil {
+		slog.Error(fmt.Sprintf("failed to build audit diff: %s", err.Error()))
+		return
+	}
+
+	record := entity.AuditRecord{
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Changes:    changes,
+		RequestID:  requestid.FromContext(ctx),
+	}
+	if actorID != "" {
+		record.ActorID = &actorID
+	}
+
+	err = a.auditRepository.SaveRecord(ctx, record)
+	if err != nil {
+		slog.Error(fmt.Sprintf("failed to save audit record: %s", err.Error()))
+	}
+}
+
+// GetRecords returns records page and cursor of the next page, which is empty on the last page
+func (a *Audit) GetRecords(ctx context.Context, filter entity.AuditFilter, cursor string, limit *int) ([]entity.AuditRecord, string, error) {
+	if cursor != "" {
+		beforeID, err := decodeCursor(cursor)
+		if err != nil {
+			return []entity.AuditRecord{}, "", ErrInvalidCursor
+		}
+		filter.BeforeID = &beforeID
+	}
+
+	pageLimit := defaultAuditLimit
+	if limit != nil && *limit > 0 {
+		pageLimit = min(*limit, maxAuditLimit)
+	}
+
+	records, err := a.auditRepository.Records(ctx, filter, pageLimit)
+	if err != nil {
+		return []entity.AuditRecord{}, "", fmt.Errorf("failed to get audit records: %w", err)
+	}
+
+	nextCursor := ""
+	if len(records) == pageLimit {
+		nextCursor = encodeCursor(records[len(records)-1].ID)
+	}
+
+	return records, nextCursor, nil
+}
+
+// diff compares top level fields of JSON representations and returns changed ones as {"field": {"before": .., "after": ..}}
+func diff(before any, after any) (map[string]any, error) {
+	beforeFields, err := toJSONFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := toJSONFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]any)
+	for key, beforeValue := range beforeFields {
+		afterValue, ok := afterFields[key]
+		if !ok || !reflect.DeepEqual(beforeValue, afterValue) {
+			changes[key] = map[string]any{"before": beforeValue, "after": afterValue}
+		}
+	}
+	for key, afterValue := range afterFields {
+		if _, ok := beforeFields[key]; !ok {
+			changes[key] = map[string]any{"before": nil, "after": afterValue}
+		}
+	}
+
+	return changes, nil
+}
+
+func toJSONFields(value any) (map[string]any, error) {
+	fields := make(map[string]any)
+	if value == nil {
+		return fields, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	return fields, nil
+}
+
+func encodeCursor(id int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(id, 10)))
+}
+
+func decodeCursor(cursor string) (int64, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(string(data), 10, 64)
+}
@@ -2,63 +2,176 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"log/slog"
 	"time"
 
 	"github.com/NikolaB131-org/banner-service/internal/app/jwt"
+	"github.com/NikolaB131-org/banner-service/internal/app/password"
 	"github.com/NikolaB131-org/banner-service/internal/entity"
 	"github.com/NikolaB131-org/banner-service/internal/repository"
-	"golang.org/x/crypto/bcrypt"
 )
 
 type (
 	AuthService interface {
-		Login(ctx context.Context, username string, password string) (string, error)
+		Login(ctx context.Context, username string, password string) (string, string, error)
+		Refresh(ctx context.Context, refreshToken string) (string, string, error)
+		Logout(ctx context.Context, sessionID string) error
+		// RegisterUser checks password against password policy
 		RegisterUser(ctx context.Context, username string, password string) (string, error)
-		MakeAdmin(ctx context.Context, userID string) error
+		// CreateUser does not check password policy, it is meant for users configured by operator
+		CreateUser(ctx context.Context, username string, password string) (string, error)
+		// StartSession returns access and refresh tokens of a new session of already authenticated user
+		StartSession(ctx context.Context, userID string, username string) (string, string, error)
+		// JWKS returns public keys which verify issued tokens
+		JWKS() jwt.JWKS
 	}
 
 	Auth struct {
-		userRepository repository.User
-		signSecret     string
-		tokenTTL       time.Duration
+		userRepository    repository.User
+		sessionRepository repository.Session
+		keySet            *jwt.KeySet
+		passwordHasher    *password.Hasher
+		passwordPolicy    *password.Policy
+		tokenTTL          time.Duration
+		refreshTokenTTL   time.Duration
 	}
 )
 
 var (
-	ErrUserAlreadyExists  = errors.New("user with this username already exists")
-	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrUserAlreadyExists     = errors.New("user with this username already exists")
+	ErrInvalidCredentials    = errors.New("invalid credentials")
+	ErrInvalidToken          = errors.New("invalid refresh token")
+	ErrTokenReused           = errors.New("refresh token reuse detected")
+	ErrUserDisabled          = errors.New("user is disabled")
+	ErrPasswordResetRequired = errors.New("password reset is required")
+	ErrWeakPassword          = errors.New("password does not satisfy password policy")
 )
 
-func NewAuthService(userRepository repository.User, signSecret string, tokenTTL time.Duration) *Auth {
+func NewAuthService(
+	userRepository repository.User,
+	sessionRepository repository.Session,
+	keySet *jwt.KeySet,
+	passwordHasher *password.Hasher,
+	passwordPolicy *password.Policy,
+	tokenTTL time.Duration,
+	refreshTokenTTL time.Duration,
+) *Auth {
 	return &Auth{
-		userRepository: userRepository,
-		signSecret:     signSecret,
-		tokenTTL:       tokenTTL,
+		userRepository:    userRepository,
+		sessionRepository: sessionRepository,
+		keySet:            keySet,
+		passwordHasher:    passwordHasher,
+		passwordPolicy:    passwordPolicy,
+		tokenTTL:          tokenTTL,
+		refreshTokenTTL:   refreshTokenTTL,
 	}
 }
 
-func (a *Auth) Login(ctx context.Context, username string, password string) (string, error) {
+// Login returns access and refresh tokens of a new session
+func (a *Auth) Login(ctx context.Context, username string, password string) (string, string, error) {
 	user, err := a.userRepository.User(ctx, username)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return "", "", fmt.Errorf("failed to check if user exists: %w", err)
+	}
+
+	// Unknown user is checked against empty hash, so response time does not disclose that user does not exist
+	if !a.passwordHasher.Verify(user.PasswordHash, password) {
+		return "", "", ErrInvalidCredentials
+	}
+	// Checked only after password, so account state is not disclosed to anyone guessing passwords
+	if user.DisabledAt != nil {
+		return "", "", ErrUserDisabled
+	}
+	if user.PasswordResetRequired {
+		return "", "", ErrPasswordResetRequired
+	}
+	if a.passwordHasher.NeedsRehash(user.PasswordHash) {
+		a.rehashPassword(ctx, user, password)
+	}
+
+	return a.StartSession(ctx, user.ID, user.Username)
+}
+
+func (a *Auth) StartSession(ctx context.Context, userID string, username string) (string, string, error) {
+	sessionID, err := a.sessionRepository.SaveSession(ctx, userID, time.Now().Add(a.refreshTokenTTL))
 	if err != nil {
-		return "", fmt.Errorf("failed to check if user exists: %w", err)
+		return "", "", fmt.Errorf("failed to create session: %w", err)
 	}
 
-	if err := bcrypt.CompareHashAndPassword(user.PasswordHash, []byte(password)); err != nil {
-		return "", ErrInvalidCredentials
+	return a.issueTokens(ctx, userID, username, sessionID)
+}
+
+// Refresh rotates refresh token, reusing already rotated token revokes the whole session
+func (a *Auth) Refresh(ctx context.Context, refreshToken string) (string, string, error) {
+	token, err := a.sessionRepository.UseRefreshToken(ctx, hashToken(refreshToken))
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return "", "", ErrInvalidToken
+		default:
+			return "", "", fmt.Errorf("failed to use refresh token: %w", err)
+		}
+	}
+
+	if token.UsedAt != nil {
+		err := a.sessionRepository.RevokeSession(ctx, token.SessionID)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to revoke session: %w", err)
+		}
+		slog.Warn(fmt.Sprintf("refresh token reuse detected, session %s revoked", token.SessionID))
+		return "", "", ErrTokenReused
 	}
 
-	signedToken, err := jwt.Generate(a.signSecret, a.tokenTTL, user.ID, user.Username)
+	now := time.Now()
+	if token.SessionRevokedAt != nil || now.After(token.ExpiresAt) || now.After(token.SessionExpiresAt) {
+		return "", "", ErrInvalidToken
+	}
+
+	return a.issueTokens(ctx, token.UserID, token.Username, token.SessionID)
+}
+
+func (a *Auth) Logout(ctx context.Context, sessionID string) error {
+	err := a.sessionRepository.RevokeSession(ctx, sessionID)
 	if err != nil {
-		return "", fmt.Errorf("failed to sign token: %w", err)
+		return fmt.Errorf("failed to revoke session: %w", err)
 	}
 
-	return signedToken, nil
+	return nil
+}
+
+func (a *Auth) issueTokens(ctx context.Context, userID string, username string, sessionID string) (string, string, error) {
+	refreshToken, err := generateRandomToken()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	err = a.sessionRepository.SaveRefreshToken(ctx, sessionID, hashToken(refreshToken), time.Now().Add(a.refreshTokenTTL))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to save refresh token: %w", err)
+	}
+
+	signedToken, err := a.keySet.Generate(a.tokenTTL, userID, username, sessionID)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to sign token: %w", err)
+	}
+
+	return signedToken, refreshToken, nil
 }
 
 func (a *Auth) RegisterUser(ctx context.Context, username string, password string) (string, error) {
+	err := a.passwordPolicy.Validate(username, password)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrWeakPassword, err)
+	}
+
+	return a.CreateUser(ctx, username, password)
+}
+
+func (a *Auth) CreateUser(ctx context.Context, username string, password string) (string, error) {
 	_, err := a.userRepository.User(ctx, username)
 	if err == nil {
 		return "", ErrUserAlreadyExists
@@ -66,7 +179,7 @@ func (a *Auth) RegisterUser(ctx context.Context, username string, password strin
 		return "", fmt.Errorf("failed to check if user exists: %w", err)
 	}
 
-	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	passwordHash, err := a.passwordHasher.Hash(password)
 	if err != nil {
 		return "", fmt.Errorf("failed to generate password hash: %w", err)
 	}
@@ -81,6 +194,33 @@ func (a *Auth) RegisterUser(ctx context.Context, username string, password strin
 	return userId, nil
 }
 
-func (a *Auth) MakeAdmin(ctx context.Context, userID string) error {
-	return a.userRepository.GrantAdminPermission(ctx, userID)
+func (a *Auth) JWKS() jwt.JWKS {
+	return a.keySet.JWKS()
+}
+
+// rehashPassword upgrades hash to configured algorithm and parameters, failure does not prevent login
+func (a *Auth) rehashPassword(ctx context.Context, user entity.User, password string) {
+	passwordHash, err := a.passwordHasher.Hash(password)
+	if err == nil {
+		err = a.userRepository.ReplacePasswordHash(ctx, user.ID, user.PasswordHash, passwordHash)
+	}
+	if err != nil {
+		slog.Warn(fmt.Sprintf("failed to rehash password of user %s: %s", user.ID, err.Error()))
+	}
+}
+
+// generateRandomToken returns url safe string of 32 random bytes
+func generateRandomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken is used to store only hashes of refresh and password reset tokens,
+// plain sha256 is enough for random tokens
+func hashToken(token string) []byte {
+	hash := sha256.Sum256([]byte(token))
+	return hash[:]
 }
@@ -5,18 +5,63 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"strconv"
+	"sync"
+	"time"
 
+	"github.com/NikolaB131-org/banner-service/internal/app/access"
+	"github.com/NikolaB131-org/banner-service/internal/app/locale"
+	"github.com/NikolaB131-org/banner-service/internal/app/metrics"
 	"github.com/NikolaB131-org/banner-service/internal/entity"
 	"github.com/NikolaB131-org/banner-service/internal/repository"
+	"golang.org/x/sync/singleflight"
 )
 
 type (
 	BannerService interface {
-		GetBanner(ctx context.Context, featureID int, tagID int, useLastRevision bool) (entity.Banner, error)
-		GetBanners(ctx context.Context, featureID *int, tagID *int, limit *int, offset *int) ([]entity.Banner, error)
-		Create(ctx context.Context, tagIDs []int, featureID int, content map[string]any, isActive bool) (int, error)
-		Update(ctx context.Context, id int, tagIDs []int, featureID *int, content map[string]any, isActive *bool) error
-		DeleteByID(ctx context.Context, id int) error
+		// GetBanner returns banner with content of experiment variant assigned to userID,
+		// or with content of the first supported locale of preferredLocales (in order of preference)
+		GetBanner(
+			ctx context.Context,
+			featureID int,
+			tagID int,
+			useLastRevision bool,
+			userID string,
+			preferredLocales []string,
+		) (entity.ServedBanner, error)
+		GetBanners(ctx context.Context, featureID *int, tagID *int, activeAt *time.Time, limit *int, offset *int) ([]entity.Banner, error)
+		Create(
+			ctx context.Context,
+			tagIDs []int,
+			featureID int,
+			content map[string]any,
+			isActive bool,
+			activeFrom *time.Time,
+			activeUntil *time.Time,
+			authorID string,
+		) (int, error)
+		Update(
+			ctx context.Context,
+			id int,
+			tagIDs []int,
+			featureID *int,
+			content map[string]any,
+			isActive *bool,
+			activeFrom entity.Nullable[time.Time],
+			activeUntil entity.Nullable[time.Time],
+			authorID string,
+		) error
+		DeleteByID(ctx context.Context, id int, authorID string) error
+		GetRevisions(ctx context.Context, id int, limit *int, offset *int) ([]entity.BannerRevision, error)
+		Rollback(ctx context.Context, id int, version int, authorID string) error
+		DeleteAsync(ctx context.Context, featureID *int, tagID *int, authorID string) (string, error)
+		DeletionJob(ctx context.Context, jobID string) (entity.BannerDeletionJob, error)
+		GetExperiment(ctx context.Context, bannerID int) (entity.BannerExperiment, error)
+		// SetExperiment replaces experiment of banner, users keep their variants unless name, variants or weights change
+		SetExperiment(ctx context.Context, bannerID int, experiment entity.BannerExperiment, authorID string) error
+		DeleteExperiment(ctx context.Context, bannerID int, authorID string) error
+		SetLocale(ctx context.Context, bannerID int, locale string, content map[string]any, authorID string) error
+		DeleteLocale(ctx context.Context, bannerID int, locale string, authorID string) error
 	}
 
 	Banner struct {
@@ -24,6 +69,19 @@ type (
 		bannerCacheRepository repository.BannerCache
 		tagRepository         repository.Tag
 		featureRepository     repository.Feature
+		auditService          AuditService
+		locales               *locale.Locales
+
+		bannerDeletionJobRepository repository.BannerDeletionJob
+		deletionPool                *workerPool
+		deletionJobTTL              time.Duration
+		deletionJobLease            time.Duration
+		deletionJobsMu              sync.Mutex
+		deletionJobs                map[string]struct{} // ids of unfinished jobs owned by this replica
+		stopDeletionLeases          chan struct{}
+		deletionLeasesStopped       chan struct{}
+
+		bannerLoads singleflight.Group
 	}
 )
 
@@ -32,6 +90,21 @@ var (
 	ErrBannerAlreadyExists    = errors.New("banner already exists")
 	ErrBannerTagNotExists     = errors.New("banner tag not exists")
 	ErrBannerFeatureNotExists = errors.New("banner feature not exists")
+	ErrBannerRevisionNotFound = errors.New("banner revision not found")
+	ErrBannerRevisionConflict = errors.New("banner revision refers to deleted feature or tag")
+	ErrBannerReferenceInvalid = errors.New("banner feature or tag not exists")
+	ErrBannerInvalidWindow    = errors.New("active_until must be after active_from")
+	ErrBannerAccessDenied     = errors.New("access to banner feature denied")
+	ErrExperimentNotFound     = errors.New("banner experiment not found")
+	ErrExperimentInvalid      = errors.New("invalid banner experiment")
+	ErrLocaleNotFound         = errors.New("banner locale not found")
+	ErrLocaleInvalid          = errors.New("invalid banner locale")
+)
+
+const (
+	defaultRevisionsLimit = 3
+	bannerLoadTimeout     = 5 * time.Second
+	maxExperimentNameLen  = 64
 )
 
 func NewBannerService(
@@ -39,58 +112,132 @@ func NewBannerService(
 	bannerCacheRepository repository.BannerCache,
 	tagRepository repository.Tag,
 	featureRepository repository.Feature,
+	auditService AuditService,
+	locales *locale.Locales,
+	bannerDeletionJobRepository repository.BannerDeletionJob,
+	deletionWorkers int,
+	deletionQueueSize int,
+	deletionJobTTL time.Duration,
+	deletionJobLease time.Duration,
 ) *Banner {
-	return &Banner{
-		bannerRepository:      bannerRepository,
-		bannerCacheRepository: bannerCacheRepository,
-		tagRepository:         tagRepository,
-		featureRepository:     featureRepository,
+	b := &Banner{
+		bannerRepository:            bannerRepository,
+		bannerCacheRepository:       bannerCacheRepository,
+		tagRepository:               tagRepository,
+		featureRepository:           featureRepository,
+		auditService:                auditService,
+		locales:                     locales,
+		bannerDeletionJobRepository: bannerDeletionJobRepository,
+		deletionPool:                newWorkerPool(deletionWorkers, deletionQueueSize),
+		deletionJobTTL:              deletionJobTTL,
+		deletionJobLease:            deletionJobLease,
+		deletionJobs:                make(map[string]struct{}),
+		stopDeletionLeases:          make(chan struct{}),
+		deletionLeasesStopped:       make(chan struct{}),
 	}
+	go b.runDeletionLeases()
+	return b
 }
 
-func (b *Banner) GetBanner(ctx context.Context, featureID int, tagID int, useLastRevision bool) (entity.Banner, error) {
-	getBannerFromDB := func() (entity.Banner, error) {
-		banners, err := b.bannerRepository.Banners(ctx, &featureID, &tagID, nil, nil)
-		if err != nil {
-			return entity.Banner{}, fmt.Errorf("failed to get banners: %w", err)
-		}
-		if len(banners) == 0 {
-			return entity.Banner{}, ErrBannerNotFound
-		}
-		return banners[0], nil
+// Close waits for queued background jobs to finish, leases of jobs are renewed until then
+func (b *Banner) Close() {
+	b.deletionPool.stop()
+	close(b.stopDeletionLeases)
+	<-b.deletionLeasesStopped
+}
+
+func (b *Banner) GetBanner(
+	ctx context.Context,
+	featureID int,
+	tagID int,
+	useLastRevision bool,
+	userID string,
+	preferredLocales []string,
+) (entity.ServedBanner, error) {
+	// Only supported locales get to cache keys, so clients can not flood cache with arbitrary ones
+	contentLocale := b.locales.Match(preferredLocales)
+	chain := b.locales.Chain(contentLocale)
+
+	banner, err := b.banner(ctx, featureID, tagID, contentLocale, chain, useLastRevision)
+	if err != nil {
+		return entity.ServedBanner{}, err
 	}
 
+	// Variant is chosen after cache, so cached banner is shared by all users
+	return banner.ServeTo(userID, chain), nil
+}
+
+func (b *Banner) banner(ctx context.Context, featureID int, tagID int, contentLocale string, chain []string, useLastRevision bool) (entity.Banner, error) {
 	if useLastRevision {
-		return getBannerFromDB()
+		return b.bannerFromDB(ctx, featureID, tagID)
 	}
 
-	cachedBanner, err := b.bannerCacheRepository.Banner(ctx, featureID, tagID)
+	cachedBanner, err := b.bannerCacheRepository.Banner(ctx, featureID, tagID, contentLocale)
 	if err != nil {
 		switch {
 		case errors.Is(err, repository.ErrNotFound):
-			banner, err := getBannerFromDB()
-			if err != nil {
-				return entity.Banner{}, err
-			}
-
-			go func() { // runs asynchronously to not block response
-				err = b.bannerCacheRepository.SaveBanner(ctx, banner)
-				if err != nil {
-					slog.Warn(fmt.Sprintf("failed to cache banner: %s", err.Error()))
-				}
-			}()
-
-			return banner, nil
+			metrics.BannerCacheRequests.WithLabelValues(metrics.CacheMiss).Inc()
+			return b.loadBanner(ctx, featureID, tagID, contentLocale, chain)
 		default:
+			metrics.BannerCacheRequests.WithLabelValues(metrics.CacheError).Inc()
 			return entity.Banner{}, fmt.Errorf("failed to get cached banner: %w", err)
 		}
 	}
 
+	metrics.BannerCacheRequests.WithLabelValues(metrics.CacheHit).Inc()
 	return cachedBanner, nil
 }
 
-func (b *Banner) GetBanners(ctx context.Context, featureID *int, tagID *int, limit *int, offset *int) ([]entity.Banner, error) {
-	banners, err := b.bannerRepository.Banners(ctx, featureID, tagID, limit, offset)
+// loadBanner coalesces concurrent cache misses for the same feature, tag and locale,
+// so only one of them queries database and fills cache while the rest wait for its result
+func (b *Banner) loadBanner(ctx context.Context, featureID int, tagID int, contentLocale string, chain []string) (entity.Banner, error) {
+	result, err, _ := b.bannerLoads.Do(fmt.Sprintf("%d:%d:%s", featureID, tagID, contentLocale), func() (any, error) {
+		// Result is shared between requests, so cancellation of the first one must not fail the others
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), bannerLoadTimeout)
+		defer cancel()
+
+		banner, err := b.bannerFromDB(ctx, featureID, tagID)
+		if err != nil {
+			return entity.Banner{}, err
+		}
+		banner = banner.ForLocale(chain)
+
+		// Cache is filled before the flight ends, otherwise requests coming right after it
+		// would miss again and query database once more
+		err = b.bannerCacheRepository.SaveBanner(ctx, banner, contentLocale)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("failed to cache banner: %s", err.Error()))
+		}
+
+		return banner, nil
+	})
+	if err != nil {
+		return entity.Banner{}, err
+	}
+
+	return result.(entity.Banner), nil
+}
+
+func (b *Banner) bannerFromDB(ctx context.Context, featureID int, tagID int) (entity.Banner, error) {
+	banners, err := b.bannerRepository.Banners(ctx, &featureID, &tagID, nil, nil, nil, nil)
+	if err != nil {
+		return entity.Banner{}, fmt.Errorf("failed to get banners: %w", err)
+	}
+	if len(banners) == 0 {
+		return entity.Banner{}, ErrBannerNotFound
+	}
+	return banners[0], nil
+}
+
+func (b *Banner) GetBanners(ctx context.Context, featureID *int, tagID *int, activeAt *time.Time, limit *int, offset *int) ([]entity.Banner, error) {
+	// Users with feature-scoped grants see only banners of their features
+	userAccess, ok := access.FromContext(ctx)
+	if !ok {
+		return []entity.Banner{}, fmt.Errorf("%w: no access in context", ErrBannerAccessDenied)
+	}
+	featureIDs := userAccess.Features(entity.PermissionBannerRead)
+
+	banners, err := b.bannerRepository.Banners(ctx, featureID, tagID, featureIDs, activeAt, limit, offset)
 	if err != nil {
 		return []entity.Banner{}, fmt.Errorf("failed to get banners: %w", err)
 	}
@@ -98,7 +245,31 @@ func (b *Banner) GetBanners(ctx context.Context, featureID *int, tagID *int, lim
 	return banners, nil
 }
 
-func (b *Banner) Create(ctx context.Context, tagIDs []int, featureID int, content map[string]any, isActive bool) (int, error) {
+func (b *Banner) Create(
+	ctx context.Context,
+	tagIDs []int,
+	featureID int,
+	content map[string]any,
+	isActive bool,
+	activeFrom *time.Time,
+	activeUntil *time.Time,
+	authorID string,
+) (int, error) {
+	if !isValidWindow(activeFrom, activeUntil) {
+		return 0, ErrBannerInvalidWindow
+	}
+
+	err := checkFeatureAccess(ctx, entity.PermissionBannerWrite, featureID)
+	if err != nil {
+		return 0, err
+	}
+	if isActive {
+		err = checkFeatureAccess(ctx, entity.PermissionBannerPublish, featureID)
+		if err != nil {
+			return 0, err
+		}
+	}
+
 	IsFeatureExists, err := b.featureRepository.IsExist(ctx, featureID)
 	if err != nil {
 		return 0, fmt.Errorf("failed to check is feature exists: %w", err)
@@ -124,38 +295,107 @@ func (b *Banner) Create(ctx context.Context, tagIDs []int, featureID int, conten
 			return 0, ErrBannerAlreadyExists
 		}
 	}
-	id, err := b.bannerRepository.SaveBanner(ctx, tagIDs, featureID, content, isActive)
+	id, err := b.bannerRepository.SaveBanner(ctx, tagIDs, featureID, content, isActive, activeFrom, activeUntil, authorID)
 	if err != nil {
 		return 0, fmt.Errorf("failed to create banner: %w", err)
 	}
 
+	b.auditBanner(ctx, authorID, entity.AuditActionCreate, id, nil)
+
 	return id, nil
 }
 
-func (b *Banner) Update(ctx context.Context, bannerID int, tagIDs []int, featureID *int, content map[string]any, isActive *bool) error {
-	exists, err := b.bannerRepository.IsExistsById(ctx, bannerID)
-	if err != nil {
-		return fmt.Errorf("failed to check is banner exists: %w", err)
-	}
-	if !exists {
-		return ErrBannerNotFound
+func (b *Banner) Update(
+	ctx context.Context,
+	bannerID int,
+	tagIDs []int,
+	featureID *int,
+	content map[string]any,
+	isActive *bool,
+	activeFrom entity.Nullable[time.Time],
+	activeUntil entity.Nullable[time.Time],
+	authorID string,
+) error {
+	// Access and window are checked against banner locked for update, so a concurrent update
+	// can not move it to another feature between the check and the update
+	check := func(oldBanner entity.Banner) error {
+		// Moving banner to another feature requires access to both of them
+		featureIDs := []int{oldBanner.FeatureID}
+		if featureID != nil && *featureID != oldBanner.FeatureID {
+			featureIDs = append(featureIDs, *featureID)
+		}
+		err := checkFeatureAccess(ctx, entity.PermissionBannerWrite, featureIDs...)
+		if err != nil {
+			return err
+		}
+		if isActive != nil || activeFrom.Set || activeUntil.Set {
+			err = checkFeatureAccess(ctx, entity.PermissionBannerPublish, featureIDs...)
+			if err != nil {
+				return err
+			}
+		}
+
+		newActiveFrom, newActiveUntil := oldBanner.ActiveFrom, oldBanner.ActiveUntil
+		if activeFrom.Set {
+			newActiveFrom = activeFrom.Value
+		}
+		if activeUntil.Set {
+			newActiveUntil = activeUntil.Value
+		}
+		if !isValidWindow(newActiveFrom, newActiveUntil) {
+			return ErrBannerInvalidWindow
+		}
+
+		return nil
 	}
 
-	err = b.bannerRepository.UpdateBanner(ctx, bannerID, tagIDs, featureID, content, isActive)
+	oldBanner, err := b.bannerRepository.UpdateBanner(ctx, bannerID, tagIDs, featureID, content, isActive, activeFrom, activeUntil, authorID, check)
 	if err != nil {
 		switch {
+		case errors.Is(err, ErrBannerAccessDenied) || errors.Is(err, ErrBannerInvalidWindow):
+			return err
+		case errors.Is(err, repository.ErrNotFound):
+			return ErrBannerNotFound
 		case errors.Is(err, repository.ErrAlreadyExists):
 			return ErrBannerAlreadyExists
+		case errors.Is(err, repository.ErrReferenceNotFound):
+			return fmt.Errorf("%w: %w", ErrBannerReferenceInvalid, err)
 		default:
 			return fmt.Errorf("failed to update banner: %w", err)
 		}
 	}
 
+	// Keys of new feature and tags are dropped as well, so they do not keep serving another banner
+	newBanner := entity.Banner{ID: bannerID, FeatureID: oldBanner.FeatureID, TagIDs: oldBanner.TagIDs}
+	if featureID != nil {
+		newBanner.FeatureID = *featureID
+	}
+	if tagIDs != nil {
+		newBanner.TagIDs = tagIDs
+	}
+	invalidateCachedBanners(ctx, b.bannerCacheRepository, oldBanner, newBanner)
+	b.auditBanner(ctx, authorID, entity.AuditActionUpdate, bannerID, oldBanner)
+
 	return nil
 }
 
-func (b *Banner) DeleteByID(ctx context.Context, id int) error {
-	err := b.bannerRepository.DeleteBannerByID(ctx, id)
+func (b *Banner) DeleteByID(ctx context.Context, id int, authorID string) error {
+	banner, err := b.bannerRepository.BannerById(ctx, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return ErrBannerNotFound
+		default:
+			return fmt.Errorf("failed to get banner: %w", err)
+		}
+	}
+
+	err = checkFeatureAccess(ctx, entity.PermissionBannerWrite, banner.FeatureID)
+	if err != nil {
+		return err
+	}
+
+	err = b.bannerRepository.DeleteBannerByID(ctx, id)
 	if err != nil {
 		switch {
 		case errors.Is(err, repository.ErrNotFound):
@@ -165,5 +405,337 @@ func (b *Banner) DeleteByID(ctx context.Context, id int) error {
 		}
 	}
 
+	invalidateCachedBanners(ctx, b.bannerCacheRepository, banner)
+	b.auditService.Record(ctx, authorID, entity.AuditActionDelete, entity.AuditEntityBanner, strconv.Itoa(id), banner, nil)
+
 	return nil
 }
+
+func (b *Banner) GetRevisions(ctx context.Context, id int, limit *int, offset *int) ([]entity.BannerRevision, error) {
+	banner, err := b.bannerRepository.BannerById(ctx, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return []entity.BannerRevision{}, ErrBannerNotFound
+		default:
+			return []entity.BannerRevision{}, fmt.Errorf("failed to get banner: %w", err)
+		}
+	}
+
+	err = checkFeatureAccess(ctx, entity.PermissionBannerRead, banner.FeatureID)
+	if err != nil {
+		return []entity.BannerRevision{}, err
+	}
+
+	if limit == nil {
+		defaultLimit := defaultRevisionsLimit
+		limit = &defaultLimit
+	}
+
+	revisions, err := b.bannerRepository.BannerRevisions(ctx, id, limit, offset)
+	if err != nil {
+		return []entity.BannerRevision{}, fmt.Errorf("failed to get banner revisions: %w", err)
+	}
+
+	return revisions, nil
+}
+
+func (b *Banner) Rollback(ctx context.Context, id int, version int, authorID string) error {
+	// Revisions are deleted with banner, so missing revision of missing banner is reported the same way
+	revision, err := b.bannerRepository.BannerRevision(ctx, id, version)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return ErrBannerRevisionNotFound
+		default:
+			return fmt.Errorf("failed to get banner revision: %w", err)
+		}
+	}
+
+	// Rollback may change both content and activity of banner, as well as move it back to another feature
+	check := func(oldBanner entity.Banner) error {
+		for _, permission := range []string{entity.PermissionBannerWrite, entity.PermissionBannerPublish} {
+			err := checkFeatureAccess(ctx, permission, oldBanner.FeatureID, revision.FeatureID)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// rollback is stored as a new revision, so history stays immutable
+	oldBanner, err := b.bannerRepository.RestoreBannerRevision(ctx, revision, authorID, check)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrBannerAccessDenied):
+			return err
+		case errors.Is(err, repository.ErrNotFound):
+			return ErrBannerNotFound
+		case errors.Is(err, repository.ErrAlreadyExists):
+			return ErrBannerAlreadyExists
+		case errors.Is(err, repository.ErrReferenceNotFound):
+			return fmt.Errorf("%w: %w", ErrBannerRevisionConflict, err)
+		default:
+			return fmt.Errorf("failed to rollback banner: %w", err)
+		}
+	}
+
+	invalidateCachedBanners(ctx, b.bannerCacheRepository, oldBanner, entity.Banner{ID: id, FeatureID: revision.FeatureID, TagIDs: revision.TagIDs})
+
+	banner, err := b.bannerRepository.BannerById(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get rolled back banner: %w", err)
+	}
+	b.auditService.Record(ctx, authorID, entity.AuditActionRollback, entity.AuditEntityBanner, strconv.Itoa(id), oldBanner, banner)
+
+	// Only the default locale is warmed up, others are loaded on demand
+	defaultLocale := b.locales.Default()
+	err = b.bannerCacheRepository.SaveBanner(ctx, banner.ForLocale(b.locales.Chain(defaultLocale)), defaultLocale)
+	if err != nil {
+		slog.Warn(fmt.Sprintf("failed to refresh cached banner: %s", err.Error()))
+	}
+
+	return nil
+}
+
+func (b *Banner) GetExperiment(ctx context.Context, bannerID int) (entity.BannerExperiment, error) {
+	banner, err := b.bannerRepository.BannerById(ctx, bannerID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return entity.BannerExperiment{}, ErrBannerNotFound
+		default:
+			return entity.BannerExperiment{}, fmt.Errorf("failed to get banner: %w", err)
+		}
+	}
+
+	err = checkFeatureAccess(ctx, entity.PermissionBannerRead, banner.FeatureID)
+	if err != nil {
+		return entity.BannerExperiment{}, err
+	}
+	if banner.Experiment == nil {
+		return entity.BannerExperiment{}, ErrExperimentNotFound
+	}
+
+	return *banner.Experiment, nil
+}
+
+func (b *Banner) SetExperiment(ctx context.Context, bannerID int, experiment entity.BannerExperiment, authorID string) error {
+	err := validateExperiment(experiment)
+	if err != nil {
+		return err
+	}
+
+	oldBanner, err := b.changedBanner(ctx, bannerID, entity.PermissionBannerWrite, entity.PermissionBannerPublish)
+	if err != nil {
+		return err
+	}
+
+	err = b.bannerRepository.SaveBannerExperiment(ctx, bannerID, experiment, authorID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return ErrBannerNotFound
+		default:
+			return fmt.Errorf("failed to save banner experiment: %w", err)
+		}
+	}
+
+	invalidateCachedBanners(ctx, b.bannerCacheRepository, oldBanner)
+	b.auditBanner(ctx, authorID, entity.AuditActionSetExperiment, bannerID, oldBanner)
+
+	return nil
+}
+
+func (b *Banner) DeleteExperiment(ctx context.Context, bannerID int, authorID string) error {
+	oldBanner, err := b.changedBanner(ctx, bannerID, entity.PermissionBannerWrite, entity.PermissionBannerPublish)
+	if err != nil {
+		return err
+	}
+
+	err = b.bannerRepository.DeleteBannerExperiment(ctx, bannerID, authorID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return ErrExperimentNotFound
+		default:
+			return fmt.Errorf("failed to delete banner experiment: %w", err)
+		}
+	}
+
+	invalidateCachedBanners(ctx, b.bannerCacheRepository, oldBanner)
+	b.auditBanner(ctx, authorID, entity.AuditActionDeleteExperiment, bannerID, oldBanner)
+
+	return nil
+}
+
+func (b *Banner) SetLocale(ctx context.Context, bannerID int, locale string, content map[string]any, authorID string) error {
+	locale, err := b.supportedLocale(locale)
+	if err != nil {
+		return err
+	}
+	if len(content) == 0 {
+		return fmt.Errorf("%w: content is required", ErrLocaleInvalid)
+	}
+
+	oldBanner, err := b.changedBanner(ctx, bannerID, entity.PermissionBannerWrite)
+	if err != nil {
+		return err
+	}
+
+	err = b.bannerRepository.SaveBannerLocale(ctx, bannerID, locale, content, authorID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return ErrBannerNotFound
+		default:
+			return fmt.Errorf("failed to save banner locale: %w", err)
+		}
+	}
+
+	invalidateCachedBanners(ctx, b.bannerCacheRepository, oldBanner)
+	b.auditBanner(ctx, authorID, entity.AuditActionSetLocale, bannerID, oldBanner)
+
+	return nil
+}
+
+func (b *Banner) DeleteLocale(ctx context.Context, bannerID int, locale string, authorID string) error {
+	locale, err := b.supportedLocale(locale)
+	if err != nil {
+		return err
+	}
+
+	oldBanner, err := b.changedBanner(ctx, bannerID, entity.PermissionBannerWrite)
+	if err != nil {
+		return err
+	}
+
+	err = b.bannerRepository.DeleteBannerLocale(ctx, bannerID, locale, authorID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return ErrLocaleNotFound
+		default:
+			return fmt.Errorf("failed to delete banner locale: %w", err)
+		}
+	}
+
+	invalidateCachedBanners(ctx, b.bannerCacheRepository, oldBanner)
+	b.auditBanner(ctx, authorID, entity.AuditActionDeleteLocale, bannerID, oldBanner)
+
+	return nil
+}
+
+// supportedLocale normalizes locale, content of unsupported locales would never be served
+func (b *Banner) supportedLocale(tag string) (string, error) {
+	normalized, err := locale.Normalize(tag)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrLocaleInvalid, err)
+	}
+	if !b.locales.IsSupported(normalized) {
+		return "", fmt.Errorf("%w: locale %s is not supported", ErrLocaleInvalid, normalized)
+	}
+	return normalized, nil
+}
+
+// changedBanner returns banner if user has all permissions to change it,
+// experiment changes content served to users, so it requires publish permission as well as write
+func (b *Banner) changedBanner(ctx context.Context, bannerID int, permissions ...string) (entity.Banner, error) {
+	banner, err := b.bannerRepository.BannerById(ctx, bannerID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return entity.Banner{}, ErrBannerNotFound
+		default:
+			return entity.Banner{}, fmt.Errorf("failed to get banner: %w", err)
+		}
+	}
+
+	for _, permission := range permissions {
+		err = checkFeatureAccess(ctx, permission, banner.FeatureID)
+		if err != nil {
+			return entity.Banner{}, err
+		}
+	}
+
+	return banner, nil
+}
+
+// auditBanner records banner mutation with its current state loaded from database as after state
+func (b *Banner) auditBanner(ctx context.Context, authorID string, action string, bannerID int, before any) {
+	after, err := b.bannerRepository.BannerById(ctx, bannerID)
+	if err != nil {
+		slog.Error(fmt.Sprintf("failed to get banner for audit: %s", err.Error()))
+		return
+	}
+	b.auditService.Record(ctx, authorID, action, entity.AuditEntityBanner, strconv.Itoa(bannerID), before, after)
+}
+
+// checkFeatureAccess returns ErrBannerAccessDenied if caller has no permission for any of featureIDs
+// or ctx carries no access at all, internal callers must use access.System
+func checkFeatureAccess(ctx context.Context, permission string, featureIDs ...int) error {
+	userAccess, ok := access.FromContext(ctx)
+	if !ok {
+		return fmt.Errorf("%w: no access in context", ErrBannerAccessDenied)
+	}
+
+	for _, featureID := range featureIDs {
+		if !userAccess.HasForFeature(permission, featureID) {
+			return fmt.Errorf("%w: %s is required for feature %d", ErrBannerAccessDenied, permission, featureID)
+		}
+	}
+
+	return nil
+}
+
+// invalidateCachedBanners removes banners data and all their feature/tag keys from cache,
+// failures are only logged because database is already changed at this point
+func invalidateCachedBanners(ctx context.Context, bannerCacheRepository repository.BannerCache, banners ...entity.Banner) {
+	for _, banner := range banners {
+		err := bannerCacheRepository.DeleteBannerKeys(ctx, banner.FeatureID, banner.TagIDs)
+		if err != nil {
+			slog.Error(fmt.Sprintf("failed to invalidate cached banner keys: %s", err.Error()))
+		}
+		err = bannerCacheRepository.DeleteBanner(ctx, banner.ID)
+		if err != nil {
+			slog.Error(fmt.Sprintf("failed to invalidate cached banner: %s", err.Error()))
+		}
+	}
+}
+
+func validateExperiment(experiment entity.BannerExperiment) error {
+	if experiment.Name == "" || len(experiment.Name) > maxExperimentNameLen {
+		return fmt.Errorf("%w: name must be from 1 to %d characters long", ErrExperimentInvalid, maxExperimentNameLen)
+	}
+	if len(experiment.Variants) == 0 {
+		return fmt.Errorf("%w: at least one variant is required", ErrExperimentInvalid)
+	}
+
+	names := make(map[string]bool, len(experiment.Variants))
+	totalWeight := 0
+	for _, variant := range experiment.Variants {
+		if variant.Name == "" || len(variant.Name) > maxExperimentNameLen {
+			return fmt.Errorf("%w: variant name must be from 1 to %d characters long", ErrExperimentInvalid, maxExperimentNameLen)
+		}
+		if names[variant.Name] {
+			return fmt.Errorf("%w: variant %s is duplicated", ErrExperimentInvalid, variant.Name)
+		}
+		names[variant.Name] = true
+		if len(variant.Content) == 0 {
+			return fmt.Errorf("%w: content of variant %s is required", ErrExperimentInvalid, variant.Name)
+		}
+		if variant.Weight < 0 {
+			return fmt.Errorf("%w: weight of variant %s must not be negative", ErrExperimentInvalid, variant.Name)
+		}
+		totalWeight += variant.Weight
+	}
+	if totalWeight == 0 {
+		return fmt.Errorf("%w: sum of weights must be positive", ErrExperimentInvalid)
+	}
+
+	return nil
+}
+
+func isValidWindow(activeFrom *time.Time, activeUntil *time.Time) bool {
+	return activeFrom == nil || activeUntil == nil || activeUntil.After(*activeFrom)
+}
@@ -0,0 +1,236 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/NikolaB131-org/banner-service/internal/app/access"
+	"github.com/NikolaB131-org/banner-service/internal/app/requestid"
+	"github.com/NikolaB131-org/banner-service/internal/entity"
+	"github.com/NikolaB131-org/banner-service/internal/repository"
+)
+
+var (
+	ErrDeletionQueueFull       = errors.New("deletion queue is full")
+	ErrDeletionJobNotFound     = errors.New("deletion job not found")
+	ErrDeletionFilterNotExists = errors.New("feature_id or tag_id must be specified")
+)
+
+const deletionBatchSize = 100
+
+func (b *Banner) DeleteAsync(ctx context.Context, featureID *int, tagID *int, authorID string) (string, error) {
+	if featureID == nil && tagID == nil {
+		return "", ErrDeletionFilterNotExists
+	}
+
+	err := checkDeletionAccess(ctx, featureID)
+	if err != nil {
+		return "", err
+	}
+
+	// Old jobs are evicted on enqueue, so their number is bounded by jobs enqueued within ttl
+	err = b.bannerDeletionJobRepository.DeleteBannerDeletionJobs(ctx, time.Now().Add(-b.deletionJobTTL))
+	if err != nil {
+		slog.Error(fmt.Sprintf("failed to evict old banner deletion jobs: %s", err.Error()))
+	}
+
+	jobID, err := generateJobID()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate job id: %w", err)
+	}
+
+	job := entity.BannerDeletionJob{
+		ID:        jobID,
+		Status:    entity.BannerDeletionJobQueued,
+		FeatureID: featureID,
+		TagID:     tagID,
+		CreatedBy: authorID,
+		CreatedAt: time.Now(),
+	}
+
+	// Job is stored before it is queued, so it can not be updated by worker before it exists
+	err = b.bannerDeletionJobRepository.SaveBannerDeletionJob(ctx, job, b.deletionJobLease)
+	if err != nil {
+		return "", fmt.Errorf("failed to save deletion job: %w", err)
+	}
+
+	// request context is done when job runs, so only request id is carried over
+	jobCtx := requestid.NewContext(context.Background(), requestid.FromContext(ctx))
+
+	if !b.submitDeletionJob(jobCtx, job) {
+		job.Status = entity.BannerDeletionJobFailed
+		job.Error = ErrDeletionQueueFull.Error()
+		b.updateDeletionJob(ctx, &job)
+		return "", ErrDeletionQueueFull
+	}
+
+	return jobID, nil
+}
+
+// DeletionJob returns job of any replica, it is visible to users allowed to enqueue the same deletion
+func (b *Banner) DeletionJob(ctx context.Context, jobID string) (entity.BannerDeletionJob, error) {
+	job, err := b.bannerDeletionJobRepository.BannerDeletionJob(ctx, jobID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return entity.BannerDeletionJob{}, ErrDeletionJobNotFound
+		default:
+			return entity.BannerDeletionJob{}, fmt.Errorf("failed to get deletion job: %w", err)
+		}
+	}
+
+	err = checkDeletionAccess(ctx, job.FeatureID)
+	if err != nil {
+		return entity.BannerDeletionJob{}, err
+	}
+
+	return job, nil
+}
+
+// submitDeletionJob queues job owned by this replica, its lease is renewed until the job finishes
+func (b *Banner) submitDeletionJob(ctx context.Context, job entity.BannerDeletionJob) bool {
+	b.deletionJobsMu.Lock()
+	b.deletionJobs[job.ID] = struct{}{}
+	b.deletionJobsMu.Unlock()
+
+	if !b.deletionPool.submit(func() { b.runDeletionJob(ctx, job) }) {
+		b.releaseDeletionJob(job.ID)
+		return false
+	}
+	return true
+}
+
+func (b *Banner) releaseDeletionJob(jobID string) {
+	b.deletionJobsMu.Lock()
+	delete(b.deletionJobs, jobID)
+	b.deletionJobsMu.Unlock()
+}
+
+// runDeletionLeases renews leases of jobs owned by this replica and takes over jobs with expired lease,
+// including unfinished jobs of the previous run of this replica, until Close is called
+func (b *Banner) runDeletionLeases() {
+	defer close(b.deletionLeasesStopped)
+
+	ticker := time.NewTicker(b.deletionJobLease / 3)
+	defer ticker.Stop()
+
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), b.deletionJobLease/3)
+		b.renewDeletionLeases(ctx)
+		b.claimStaleDeletionJobs(ctx)
+		cancel()
+
+		select {
+		case <-ticker.C:
+		case <-b.stopDeletionLeases:
+			return
+		}
+	}
+}
+
+func (b *Banner) renewDeletionLeases(ctx context.Context) {
+	b.deletionJobsMu.Lock()
+	jobIDs := make([]string, 0, len(b.deletionJobs))
+	for jobID := range b.deletionJobs {
+		jobIDs = append(jobIDs, jobID)
+	}
+	b.deletionJobsMu.Unlock()
+
+	if len(jobIDs) == 0 {
+		return
+	}
+	err := b.bannerDeletionJobRepository.RenewBannerDeletionJobLeases(ctx, jobIDs, b.deletionJobLease)
+	if err != nil {
+		slog.Error(fmt.Sprintf("failed to renew banner deletion job leases: %s", err.Error()))
+	}
+}
+
+// claimStaleDeletionJobs takes over only as many jobs as queue can accept, the rest stay for other replicas
+func (b *Banner) claimStaleDeletionJobs(ctx context.Context) {
+	limit := b.deletionPool.free()
+	if limit == 0 {
+		return
+	}
+
+	jobs, err := b.bannerDeletionJobRepository.ClaimStaleBannerDeletionJobs(ctx, b.deletionJobLease, limit)
+	if err != nil {
+		slog.Error(fmt.Sprintf("failed to claim stale banner deletion jobs: %s", err.Error()))
+		return
+	}
+	for _, job := range jobs {
+		slog.Info(fmt.Sprintf("resuming banner deletion job %s", job.ID))
+		// Job which does not fit into queue is left with its lease, so it is claimed again after the lease expires
+		if !b.submitDeletionJob(context.Background(), job) {
+			slog.Warn(fmt.Sprintf("banner deletion job %s is not resumed: %s", job.ID, ErrDeletionQueueFull.Error()))
+		}
+	}
+}
+
+func (b *Banner) runDeletionJob(ctx context.Context, job entity.BannerDeletionJob) {
+	defer b.releaseDeletionJob(job.ID)
+
+	job.Status = entity.BannerDeletionJobRunning
+	b.updateDeletionJob(ctx, &job)
+
+	for {
+		deleted, err := b.bannerRepository.DeleteBanners(ctx, job.FeatureID, job.TagID, deletionBatchSize)
+		if err != nil {
+			slog.Error(fmt.Sprintf("banner deletion job %s failed: %s", job.ID, err.Error()))
+			job.Status = entity.BannerDeletionJobFailed
+			job.Error = "failed to delete banners"
+			b.updateDeletionJob(ctx, &job)
+			return
+		}
+		if len(deleted) == 0 {
+			break
+		}
+		invalidateCachedBanners(ctx, b.bannerCacheRepository, deleted...)
+		for _, banner := range deleted {
+			b.auditService.Record(ctx, job.CreatedBy, entity.AuditActionDelete, entity.AuditEntityBanner, strconv.Itoa(banner.ID), banner, nil)
+		}
+		job.Deleted += len(deleted)
+		b.updateDeletionJob(ctx, &job)
+	}
+
+	job.Status = entity.BannerDeletionJobDone
+	b.updateDeletionJob(ctx, &job)
+}
+
+// updateDeletionJob stores job progress, failure to store it does not stop the job
+func (b *Banner) updateDeletionJob(ctx context.Context, job *entity.BannerDeletionJob) {
+	if job.Status == entity.BannerDeletionJobDone || job.Status == entity.BannerDeletionJobFailed {
+		finishedAt := time.Now()
+		job.FinishedAt = &finishedAt
+	}
+
+	err := b.bannerDeletionJobRepository.UpdateBannerDeletionJob(ctx, *job)
+	if err != nil {
+		slog.Error(fmt.Sprintf("failed to update banner deletion job %s: %s", job.ID, err.Error()))
+	}
+}
+
+// checkDeletionAccess checks permission to delete banners of feature, deleting by tag only affects
+// every feature, so it needs global permission
+func checkDeletionAccess(ctx context.Context, featureID *int) error {
+	if featureID != nil {
+		return checkFeatureAccess(ctx, entity.PermissionBannerWrite, *featureID)
+	}
+	if userAccess, ok := access.FromContext(ctx); !ok || !userAccess.Has(entity.PermissionBannerWrite) {
+		return fmt.Errorf("%w: %s is required for all features", ErrBannerAccessDenied, entity.PermissionBannerWrite)
+	}
+	return nil
+}
+
+func generateJobID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
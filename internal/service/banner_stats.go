@@ -0,0 +1,229 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/NikolaB131-org/banner-service/internal/entity"
+	"github.com/NikolaB131-org/banner-service/internal/repository"
+)
+
+type (
+	BannerStatsService interface {
+		// TrackImpression and TrackClick only count event in memory, counters are flushed to repository in background
+		TrackImpression(banner entity.ServedBanner)
+		TrackClick(banner entity.ServedBanner)
+		// GetStats returns stats of every day between from and to inclusive (the last 30 days by default),
+		// events of the last flush interval are not included yet
+		GetStats(ctx context.Context, bannerID int, from *time.Time, to *time.Time) ([]entity.BannerDayStats, error)
+	}
+
+	BannerStats struct {
+		bannerStatsRepository repository.BannerStats
+		bannerRepository      repository.Banner
+		flushSize             int
+
+		mu      sync.Mutex
+		pending map[bannerCountersKey]*entity.BannerCounters
+
+		flushes chan struct{} // requests early flush when pending reaches flush size
+		stop    chan struct{}
+		stopped chan struct{}
+	}
+
+	bannerCountersKey struct {
+		bannerID int
+		day      time.Time
+		variant  string
+	}
+)
+
+var ErrBannerStatsInvalidRange = errors.New("invalid stats date range")
+
+const (
+	defaultStatsDays        = 30
+	maxStatsDays            = 366
+	bannerStatsFlushTimeout = 10 * time.Second
+)
+
+// NewBannerStatsService starts flushing counters every flushInterval or as soon as flushSize
+// distinct banner/day/variant counters are pending, Close must be called to flush the rest
+func NewBannerStatsService(
+	bannerStatsRepository repository.BannerStats,
+	bannerRepository repository.Banner,
+	flushInterval time.Duration,
+	flushSize int,
+) *BannerStats {
+	s := &BannerStats{
+		bannerStatsRepository: bannerStatsRepository,
+		bannerRepository:      bannerRepository,
+		flushSize:             flushSize,
+		pending:               make(map[bannerCountersKey]*entity.BannerCounters),
+		flushes:               make(chan struct{}, 1),
+		stop:                  make(chan struct{}),
+		stopped:               make(chan struct{}),
+	}
+	go s.run(flushInterval)
+	return s
+}
+
+// Close stops background flushing and flushes pending counters
+func (s *BannerStats) Close() {
+	close(s.stop)
+	<-s.stopped
+}
+
+func (s *BannerStats) TrackImpression(banner entity.ServedBanner) {
+	s.track(banner, 1, 0)
+}
+
+func (s *BannerStats) TrackClick(banner entity.ServedBanner) {
+	s.track(banner, 0, 1)
+}
+
+func (s *BannerStats) GetStats(ctx context.Context, bannerID int, from *time.Time, to *time.Time) ([]entity.BannerDayStats, error) {
+	toDay := statsDay(time.Now())
+	if to != nil {
+		toDay = statsDay(*to)
+	}
+	fromDay := toDay.AddDate(0, 0, -(defaultStatsDays - 1))
+	if from != nil {
+		fromDay = statsDay(*from)
+	}
+	if fromDay.After(toDay) {
+		return []entity.BannerDayStats{}, fmt.Errorf("%w: from must not be after to", ErrBannerStatsInvalidRange)
+	}
+	days := int(toDay.Sub(fromDay).Hours()/24) + 1
+	if days > maxStatsDays {
+		return []entity.BannerDayStats{}, fmt.Errorf("%w: at most %d days can be requested", ErrBannerStatsInvalidRange, maxStatsDays)
+	}
+
+	banner, err := s.bannerRepository.BannerById(ctx, bannerID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return []entity.BannerDayStats{}, ErrBannerNotFound
+		default:
+			return []entity.BannerDayStats{}, fmt.Errorf("failed to get banner: %w", err)
+		}
+	}
+	err = checkFeatureAccess(ctx, entity.PermissionBannerRead, banner.FeatureID)
+	if err != nil {
+		return []entity.BannerDayStats{}, err
+	}
+
+	counters, err := s.bannerStatsRepository.BannerStats(ctx, bannerID, fromDay, toDay)
+	if err != nil {
+		return []entity.BannerDayStats{}, fmt.Errorf("failed to get banner stats: %w", err)
+	}
+
+	// Days without events are present with zero counters, so clients can plot stats as is
+	stats := make([]entity.BannerDayStats, days)
+	for i := range stats {
+		stats[i].Date = fromDay.AddDate(0, 0, i).Format(time.DateOnly)
+	}
+	for _, c := range counters {
+		dayStats := &stats[int(statsDay(c.Day).Sub(fromDay).Hours()/24)]
+		dayStats.Impressions += c.Impressions
+		dayStats.Clicks += c.Clicks
+		if c.Variant != "" {
+			dayStats.Variants = append(dayStats.Variants, entity.BannerVariantStats{
+				Variant:     c.Variant,
+				Impressions: c.Impressions,
+				Clicks:      c.Clicks,
+				CTR:         entity.CTR(c.Impressions, c.Clicks),
+			})
+		}
+	}
+	for i := range stats {
+		stats[i].CTR = entity.CTR(stats[i].Impressions, stats[i].Clicks)
+	}
+
+	return stats, nil
+}
+
+func (s *BannerStats) track(banner entity.ServedBanner, impressions int64, clicks int64) {
+	key := bannerCountersKey{bannerID: banner.ID, day: statsDay(time.Now()), variant: banner.Variant}
+
+	s.mu.Lock()
+	counters, ok := s.pending[key]
+	if !ok {
+		counters = &entity.BannerCounters{BannerID: key.bannerID, Day: key.day, Variant: key.variant}
+		s.pending[key] = counters
+	}
+	counters.Impressions += impressions
+	counters.Clicks += clicks
+	isFull := len(s.pending) >= s.flushSize
+	s.mu.Unlock()
+
+	if isFull {
+		select {
+		case s.flushes <- struct{}{}:
+		default: // flush is already requested
+		}
+	}
+}
+
+func (s *BannerStats) run(flushInterval time.Duration) {
+	defer close(s.stopped)
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-s.flushes:
+		case <-s.stop:
+			s.flush()
+			return
+		}
+		s.flush()
+	}
+}
+
+func (s *BannerStats) flush() {
+	s.mu.Lock()
+	pending := s.pending
+	s.pending = make(map[bannerCountersKey]*entity.BannerCounters, len(pending))
+	s.mu.Unlock()
+
+	if len(pending) == 0 {
+		return
+	}
+	counters := make([]entity.BannerCounters, 0, len(pending))
+	for _, c := range pending {
+		counters = append(counters, *c)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), bannerStatsFlushTimeout)
+	defer cancel()
+
+	err := s.bannerStatsRepository.IncrementBannerStats(ctx, counters)
+	if err != nil {
+		slog.Error(fmt.Sprintf("failed to flush banner stats: %s", err.Error()))
+
+		// Counters are retried on the next flush, there is only one per banner, day and variant
+		// served during outage, so memory stays bounded
+		s.mu.Lock()
+		for key, c := range pending {
+			if current, ok := s.pending[key]; ok {
+				current.Impressions += c.Impressions
+				current.Clicks += c.Clicks
+			} else {
+				s.pending[key] = c
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+// statsDay truncates t to the start of its day in UTC, stats of all replicas are split into days the same way
+func statsDay(t time.Time) time.Time {
+	year, month, day := t.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
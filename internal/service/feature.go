@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/NikolaB131-org/banner-service/internal/entity"
+	"github.com/NikolaB131-org/banner-service/internal/repository"
+)
+
+type (
+	FeatureService interface {
+		GetFeatures(ctx context.Context, limit *int, offset *int) ([]entity.Feature, error)
+		GetFeature(ctx context.Context, id int) (entity.Feature, error)
+		Create(ctx context.Context, name string, description string, actorID string) (int, error)
+		Update(ctx context.Context, id int, name *string, description *string, actorID string) error
+		// DeleteByID with cascade deletes banners of feature too, every deleted banner is audited
+		DeleteByID(ctx context.Context, id int, cascade bool, actorID string) error
+	}
+
+	Feature struct {
+		featureRepository     repository.Feature
+		bannerCacheRepository repository.BannerCache
+		auditService          AuditService
+	}
+)
+
+var (
+	ErrFeatureNotFound = errors.New("feature not found")
+	ErrFeatureInUse    = errors.New("feature is used by banners")
+)
+
+func NewFeatureService(featureRepository repository.Feature, bannerCacheRepository repository.BannerCache, auditService AuditService) *Feature {
+	return &Feature{
+		featureRepository:     featureRepository,
+		bannerCacheRepository: bannerCacheRepository,
+		auditService:          auditService,
+	}
+}
+
+func (f *Feature) GetFeatures(ctx context.Context, limit *int, offset *int) ([]entity.Feature, error) {
+	features, err := f.featureRepository.Features(ctx, limit, offset)
+	if err != nil {
+		return []entity.Feature{}, fmt.Errorf("failed to get features: %w", err)
+	}
+
+	return features, nil
+}
+
+func (f *Feature) GetFeature(ctx context.Context, id int) (entity.Feature, error) {
+	feature, err := f.featureRepository.FeatureByID(ctx, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return entity.Feature{}, ErrFeatureNotFound
+		default:
+			return entity.Feature{}, fmt.Errorf("failed to get feature: %w", err)
+		}
+	}
+
+	return feature, nil
+}
+
+func (f *Feature) Create(ctx context.Context, name string, description string, actorID string) (int, error) {
+	id, err := f.featureRepository.SaveFeature(ctx, name, description)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create feature: %w", err)
+	}
+
+	f.auditFeature(ctx, actorID, entity.AuditActionCreate, id, nil)
+
+	return id, nil
+}
+
+func (f *Feature) Update(ctx context.Context, id int, name *string, description *string, actorID string) error {
+	oldFeature, err := f.GetFeature(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	err = f.featureRepository.UpdateFeature(ctx, id, name, description)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return ErrFeatureNotFound
+		default:
+			return fmt.Errorf("failed to update feature: %w", err)
+		}
+	}
+
+	f.auditFeature(ctx, actorID, entity.AuditActionUpdate, id, oldFeature)
+
+	return nil
+}
+
+func (f *Feature) DeleteByID(ctx context.Context, id int, cascade bool, actorID string) error {
+	feature, err := f.GetFeature(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	banners, err := f.featureRepository.DeleteFeature(ctx, id, cascade)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return ErrFeatureNotFound
+		case errors.Is(err, repository.ErrInUse):
+			return ErrFeatureInUse
+		default:
+			return fmt.Errorf("failed to delete feature: %w", err)
+		}
+	}
+
+	invalidateCachedBanners(ctx, f.bannerCacheRepository, banners...)
+	f.auditService.Record(ctx, actorID, entity.AuditActionDelete, entity.AuditEntityFeature, strconv.Itoa(id), feature, nil)
+	for _, banner := range banners {
+		f.auditService.Record(ctx, actorID, entity.AuditActionDelete, entity.AuditEntityBanner, strconv.Itoa(banner.ID), banner, nil)
+	}
+
+	return nil
+}
+
+func (f *Feature) auditFeature(ctx context.Context, actorID string, action string, featureID int, before any) {
+	after, err := f.featureRepository.FeatureByID(ctx, featureID)
+	if err != nil {
+		slog.Error(fmt.Sprintf("failed to get feature for audit: %s", err.Error()))
+		return
+	}
+	f.auditService.Record(ctx, actorID, action, entity.AuditEntityFeature, strconv.Itoa(featureID), before, after)
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+type (
+	HealthService interface {
+		Ready(ctx context.Context) map[string]error
+		SetShuttingDown()
+	}
+
+	// Pinger is a dependency which availability is required to serve requests
+	Pinger interface {
+		Ping(ctx context.Context) error
+	}
+
+	Health struct {
+		dependencies map[string]Pinger
+		shuttingDown atomic.Bool
+	}
+)
+
+var ErrShuttingDown = errors.New("server is shutting down")
+
+func NewHealthService(dependencies map[string]Pinger) *Health {
+	return &Health{dependencies: dependencies}
+}
+
+// Ready pings every dependency and returns their errors by name, nil error means dependency is available
+func (h *Health) Ready(ctx context.Context) map[string]error {
+	checks := make(map[string]error, len(h.dependencies)+1)
+	if h.shuttingDown.Load() {
+		checks["server"] = ErrShuttingDown
+	}
+	for name, dependency := range h.dependencies {
+		checks[name] = dependency.Ping(ctx)
+	}
+	return checks
+}
+
+// SetShuttingDown makes readiness check fail so that load balancer stops sending new requests
+func (h *Health) SetShuttingDown() {
+	h.shuttingDown.Store(true)
+}
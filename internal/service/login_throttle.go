@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/NikolaB131-org/banner-service/internal/repository"
+)
+
+type (
+	LoginThrottleService interface {
+		// Reserve counts login attempt before credentials are verified, so concurrent guesses can not exceed the limit,
+		// returns LoginLockedError if username or ip is locked out. Reserved attempt stays counted as failure
+		Reserve(ctx context.Context, username string, ip string) error
+		// Release uncounts reserved attempt that did not fail on credentials
+		Release(ctx context.Context, username string, ip string) error
+		// RegisterSuccess resets username failures and releases ip attempt, earlier ip failures are kept so that
+		// attacker owning one account can not reset its ip counter while guessing others
+		RegisterSuccess(ctx context.Context, username string, ip string) error
+		// ReservePasswordChange, ReleasePasswordChange and RegisterPasswordChangeSuccess throttle
+		// guessing of current password by user id, ip failures are shared with logins
+		ReservePasswordChange(ctx context.Context, userID string, ip string) error
+		ReleasePasswordChange(ctx context.Context, userID string, ip string) error
+		RegisterPasswordChangeSuccess(ctx context.Context, userID string, ip string) error
+		// RegisterPasswordResetRequest counts every reset request of username and ip,
+		// returns LoginLockedError instead if either of them is locked out
+		RegisterPasswordResetRequest(ctx context.Context, username string, ip string) error
+	}
+
+	LoginThrottle struct {
+		loginAttemptsRepository repository.LoginAttempts
+		maxUsernameFailures     int
+		maxIPFailures           int
+		maxResetRequests        int
+		failureWindow           time.Duration
+		lockout                 time.Duration
+		maxLockout              time.Duration
+	}
+
+	LoginLockedError struct {
+		RetryAfter time.Duration
+	}
+)
+
+var ErrLoginLocked = errors.New("too many failed login attempts")
+
+func (e *LoginLockedError) Error() string {
+	return fmt.Sprintf("%s, retry after %s", ErrLoginLocked.Error(), e.RetryAfter.Round(time.Second))
+}
+
+func (e *LoginLockedError) Unwrap() error {
+	return ErrLoginLocked
+}
+
+func NewLoginThrottleService(
+	loginAttemptsRepository repository.LoginAttempts,
+	maxUsernameFailures int,
+	maxIPFailures int,
+	maxResetRequests int,
+	failureWindow time.Duration,
+	lockout time.Duration,
+	maxLockout time.Duration,
+) *LoginThrottle {
+	return &LoginThrottle{
+		loginAttemptsRepository: loginAttemptsRepository,
+		maxUsernameFailures:     maxUsernameFailures,
+		maxIPFailures:           maxIPFailures,
+		maxResetRequests:        maxResetRequests,
+		failureWindow:           failureWindow,
+		lockout:                 lockout,
+		maxLockout:              maxLockout,
+	}
+}
+
+func (l *LoginThrottle) Reserve(ctx context.Context, username string, ip string) error {
+	return l.reserve(ctx, l.loginLimits(usernameAttemptsKey(username), ip))
+}
+
+func (l *LoginThrottle) Release(ctx context.Context, username string, ip string) error {
+	return l.release(ctx, l.loginLimits(usernameAttemptsKey(username), ip))
+}
+
+func (l *LoginThrottle) RegisterSuccess(ctx context.Context, username string, ip string) error {
+	return l.registerSuccess(ctx, usernameAttemptsKey(username), ip)
+}
+
+func (l *LoginThrottle) ReservePasswordChange(ctx context.Context, userID string, ip string) error {
+	return l.reserve(ctx, l.loginLimits(passwordChangeAttemptsKey(userID), ip))
+}
+
+func (l *LoginThrottle) ReleasePasswordChange(ctx context.Context, userID string, ip string) error {
+	return l.release(ctx, l.loginLimits(passwordChangeAttemptsKey(userID), ip))
+}
+
+func (l *LoginThrottle) RegisterPasswordChangeSuccess(ctx context.Context, userID string, ip string) error {
+	return l.registerSuccess(ctx, passwordChangeAttemptsKey(userID), ip)
+}
+
+func (l *LoginThrottle) RegisterPasswordResetRequest(ctx context.Context, username string, ip string) error {
+	return l.reserve(ctx, map[string]int{
+		passwordResetAttemptsKey(usernameAttemptsKey(username)): l.maxResetRequests,
+		passwordResetAttemptsKey(ipAttemptsKey(ip)):             l.maxIPFailures,
+	})
+}
+
+// loginLimits are limits of credentials guess, key is username or user id
+func (l *LoginThrottle) loginLimits(key string, ip string) map[string]int {
+	return map[string]int{key: l.maxUsernameFailures, ipAttemptsKey(ip): l.maxIPFailures}
+}
+
+func (l *LoginThrottle) reserve(ctx context.Context, limits map[string]int) error {
+	lockedFor, err := l.loginAttemptsRepository.Reserve(ctx, limits, l.failureWindow, l.lockout, l.maxLockout)
+	if err != nil {
+		return fmt.Errorf("failed to reserve login attempt: %w", err)
+	}
+	if lockedFor > 0 {
+		return &LoginLockedError{RetryAfter: lockedFor}
+	}
+
+	return nil
+}
+
+func (l *LoginThrottle) release(ctx context.Context, limits map[string]int) error {
+	err := l.loginAttemptsRepository.Release(ctx, limits)
+	if err != nil {
+		return fmt.Errorf("failed to release login attempt: %w", err)
+	}
+
+	return nil
+}
+
+func (l *LoginThrottle) registerSuccess(ctx context.Context, key string, ip string) error {
+	err := l.loginAttemptsRepository.Reset(ctx, key)
+	if err != nil {
+		return fmt.Errorf("failed to reset login failures: %w", err)
+	}
+
+	return l.release(ctx, map[string]int{ipAttemptsKey(ip): l.maxIPFailures})
+}
+
+func usernameAttemptsKey(username string) string {
+	return "user:" + username
+}
+
+func ipAttemptsKey(ip string) string {
+	return "ip:" + ip
+}
+
+func passwordChangeAttemptsKey(userID string) string {
+	return "password_change:" + userID
+}
+
+// passwordResetAttemptsKey keeps reset requests apart from failed logins of the same username or ip
+func passwordResetAttemptsKey(key string) string {
+	return "password_reset:" + key
+}
@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/NikolaB131-org/banner-service/internal/entity"
+	"github.com/NikolaB131-org/banner-service/internal/repository"
+	"github.com/NikolaB131-org/banner-service/pkg/oidc"
+)
+
+type (
+	OIDCService interface {
+		// AuthURL returns provider login page url and request values which must be passed to Login after redirect back
+		AuthURL() (string, entity.OIDCAuthRequest, error)
+		// Login provisions or links user by provider identity and returns access and refresh tokens
+		Login(ctx context.Context, code string, request entity.OIDCAuthRequest) (string, string, error)
+	}
+
+	OIDCProvider interface {
+		AuthCodeURL(state string, nonce string, verifier string) string
+		Exchange(ctx context.Context, code string, verifier string, nonce string) (oidc.IDToken, error)
+	}
+
+	OIDC struct {
+		provider          OIDCProvider
+		userRepository    repository.User
+		authService       AuthService
+		roleService       RoleService
+		usernameClaim     string
+		rolesClaim        string
+		roleMapping       map[string]string
+		linkExistingUsers bool
+	}
+)
+
+var (
+	ErrOIDCExchange        = errors.New("identity provider rejected login")
+	ErrOIDCInvalidUsername = errors.New("identity provider returned empty or too long username")
+	ErrOIDCUsernameTaken   = errors.New("username is already used by a local user")
+)
+
+// maxUsernameLength is a limit of users.username column
+const maxUsernameLength = 32
+
+func NewOIDCService(
+	provider OIDCProvider,
+	userRepository repository.User,
+	authService AuthService,
+	roleService RoleService,
+	usernameClaim string,
+	rolesClaim string,
+	roleMapping map[string]string,
+	linkExistingUsers bool,
+) *OIDC {
+	return &OIDC{
+		provider:          provider,
+		userRepository:    userRepository,
+		authService:       authService,
+		roleService:       roleService,
+		usernameClaim:     usernameClaim,
+		rolesClaim:        rolesClaim,
+		roleMapping:       roleMapping,
+		linkExistingUsers: linkExistingUsers,
+	}
+}
+
+func (o *OIDC) AuthURL() (string, entity.OIDCAuthRequest, error) {
+	var request entity.OIDCAuthRequest
+	for _, value := range []*string{&request.State, &request.Nonce, &request.Verifier} {
+		var err error
+		*value, err = generateRandomToken()
+		if err != nil {
+			return "", entity.OIDCAuthRequest{}, fmt.Errorf("failed to generate oidc request: %w", err)
+		}
+	}
+
+	return o.provider.AuthCodeURL(request.State, request.Nonce, request.Verifier), request, nil
+}
+
+func (o *OIDC) Login(ctx context.Context, code string, request entity.OIDCAuthRequest) (string, string, error) {
+	idToken, err := o.provider.Exchange(ctx, code, request.Verifier, request.Nonce)
+	if err != nil {
+		return "", "", fmt.Errorf("%w: %s", ErrOIDCExchange, err.Error())
+	}
+
+	user, err := o.linkedUser(ctx, idToken)
+	if err != nil {
+		return "", "", err
+	}
+	if user.DisabledAt != nil {
+		return "", "", ErrUserDisabled
+	}
+
+	err = o.syncRoles(ctx, user, idToken.Claims[o.rolesClaim])
+	if err != nil {
+		return "", "", err
+	}
+
+	return o.authService.StartSession(ctx, user.ID, user.Username)
+}
+
+// linkedUser returns user linked to identity, on first login it creates a new user or links existing one
+func (o *OIDC) linkedUser(ctx context.Context, idToken oidc.IDToken) (entity.User, error) {
+	userID, err := o.userRepository.UserIDByIdentity(ctx, idToken.Issuer, idToken.Subject)
+	if err == nil {
+		return o.userByID(ctx, userID)
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return entity.User{}, fmt.Errorf("failed to get user by identity: %w", err)
+	}
+
+	username, _ := idToken.Claims[o.usernameClaim].(string)
+	if username == "" || len(username) > maxUsernameLength {
+		return entity.User{}, ErrOIDCInvalidUsername
+	}
+
+	existingUser, err := o.userRepository.User(ctx, username)
+	switch {
+	case err == nil:
+		if !o.linkExistingUsers {
+			return entity.User{}, ErrOIDCUsernameTaken
+		}
+		userID = existingUser.ID
+		slog.Info(fmt.Sprintf("linking user %s to identity provider %s", username, idToken.Issuer))
+	case errors.Is(err, repository.ErrNotFound):
+		// User has no local password, so it can log in only through identity provider
+		userID, err = o.userRepository.SaveUser(ctx, entity.User{Username: username})
+		if err != nil {
+			return entity.User{}, fmt.Errorf("failed to save user: %w", err)
+		}
+		slog.Info(fmt.Sprintf("provisioning user %s from identity provider %s", username, idToken.Issuer))
+	default:
+		return entity.User{}, fmt.Errorf("failed to check if user exists: %w", err)
+	}
+
+	err = o.userRepository.SaveIdentity(ctx, userID, idToken.Issuer, idToken.Subject)
+	if err != nil {
+		return entity.User{}, fmt.Errorf("failed to save identity: %w", err)
+	}
+
+	return o.userByID(ctx, userID)
+}
+
+// syncRoles grants roles mapped from claim and revokes mapped roles missing in it, other roles are left as is
+func (o *OIDC) syncRoles(ctx context.Context, user entity.User, claim any) error {
+	desiredRoles := make(map[string]struct{})
+	for _, value := range claimValues(claim) {
+		if role, ok := o.roleMapping[value]; ok {
+			desiredRoles[role] = struct{}{}
+		}
+	}
+
+	managedRoles := make([]string, 0, len(o.roleMapping))
+	for _, role := range o.roleMapping {
+		managedRoles = append(managedRoles, role)
+	}
+	slices.Sort(managedRoles)
+
+	for _, role := range slices.Compact(managedRoles) {
+		_, isDesired := desiredRoles[role]
+		isAssigned := slices.Contains(user.Roles, role)
+		switch {
+		case isDesired && !isAssigned:
+			err := o.roleService.AssignRole(ctx, user.ID, role, "")
+			if err != nil {
+				return fmt.Errorf("failed to assign role %s from identity provider: %w", role, err)
+			}
+		case !isDesired && isAssigned:
+			err := o.roleService.RevokeRole(ctx, user.ID, role, "")
+			if errors.Is(err, ErrLastAdminRoleRevoked) {
+				slog.Warn(fmt.Sprintf("identity provider revokes admin role from the last admin %s, role is kept", user.Username))
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("failed to revoke role %s by identity provider: %w", role, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (o *OIDC) userByID(ctx context.Context, userID string) (entity.User, error) {
+	user, err := o.userRepository.UserByID(ctx, userID)
+	if err != nil {
+		return entity.User{}, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return user, nil
+}
+
+// claimValues accepts both a single string and an array of strings, providers differ here
+func claimValues(claim any) []string {
+	switch claim := claim.(type) {
+	case string:
+		return []string{claim}
+	case []any:
+		values := make([]string, 0, len(claim))
+		for _, value := range claim {
+			if value, ok := value.(string); ok {
+				values = append(values, value)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
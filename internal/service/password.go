@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/NikolaB131-org/banner-service/internal/app/password"
+	"github.com/NikolaB131-org/banner-service/internal/app/requestid"
+	"github.com/NikolaB131-org/banner-service/internal/repository"
+	"github.com/NikolaB131-org/banner-service/pkg/notifier"
+)
+
+type (
+	PasswordService interface {
+		// ChangePassword revokes all sessions of user and returns access and refresh tokens of a new session
+		ChangePassword(ctx context.Context, userID string, currentPassword string, newPassword string) (string, string, error)
+		// RequestReset queues sending of single-use reset token by notifier, unknown and disabled users are
+		// silently ignored in background, so neither response nor its time disclose which accounts exist
+		RequestReset(ctx context.Context, username string) error
+		// ResetPassword sets new password by reset token and revokes all sessions of user
+		ResetPassword(ctx context.Context, token string, newPassword string) error
+	}
+
+	Password struct {
+		userRepository          repository.User
+		sessionRepository       repository.Session
+		passwordResetRepository repository.PasswordReset
+		authService             AuthService
+		passwordHasher          *password.Hasher
+		passwordPolicy          *password.Policy
+		notifier                notifier.Notifier
+		resetTokenTTL           time.Duration
+		resetPool               *workerPool
+	}
+)
+
+var (
+	ErrInvalidResetToken        = errors.New("invalid or expired password reset token")
+	ErrPasswordResetUnavailable = errors.New("too many pending password reset requests")
+)
+
+const (
+	passwordResetWorkers   = 2
+	passwordResetQueueSize = 100
+	passwordResetTimeout   = 30 * time.Second
+)
+
+func NewPasswordService(
+	userRepository repository.User,
+	sessionRepository repository.Session,
+	passwordResetRepository repository.PasswordReset,
+	authService AuthService,
+	passwordHasher *password.Hasher,
+	passwordPolicy *password.Policy,
+	notifier notifier.Notifier,
+	resetTokenTTL time.Duration,
+) *Password {
+	return &Password{
+		userRepository:          userRepository,
+		sessionRepository:       sessionRepository,
+		passwordResetRepository: passwordResetRepository,
+		authService:             authService,
+		passwordHasher:          passwordHasher,
+		passwordPolicy:          passwordPolicy,
+		notifier:                notifier,
+		resetTokenTTL:           resetTokenTTL,
+		resetPool:               newWorkerPool(passwordResetWorkers, passwordResetQueueSize),
+	}
+}
+
+func (p *Password) ChangePassword(ctx context.Context, userID string, currentPassword string, newPassword string) (string, string, error) {
+	user, err := p.userRepository.UserByID(ctx, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return "", "", ErrUserNotFound
+		default:
+			return "", "", fmt.Errorf("failed to get user: %w", err)
+		}
+	}
+
+	if !p.passwordHasher.Verify(user.PasswordHash, currentPassword) {
+		return "", "", ErrInvalidCredentials
+	}
+	err = p.passwordPolicy.Validate(user.Username, newPassword)
+	if err != nil {
+		return "", "", fmt.Errorf("%w: %w", ErrWeakPassword, err)
+	}
+
+	err = p.setPassword(ctx, user.ID, newPassword)
+	if err != nil {
+		return "", "", err
+	}
+
+	return p.authService.StartSession(ctx, user.ID, user.Username)
+}
+
+func (p *Password) RequestReset(ctx context.Context, username string) error {
+	// request context is done when reset is processed, so only request id is carried over
+	resetCtx := requestid.NewContext(context.Background(), requestid.FromContext(ctx))
+
+	// User is looked up in background as well, so response time does not depend on whether account exists
+	if !p.resetPool.submit(func() { p.sendResetToken(resetCtx, username) }) {
+		return ErrPasswordResetUnavailable
+	}
+
+	return nil
+}
+
+// Close waits for queued password reset requests to be sent
+func (p *Password) Close() {
+	p.resetPool.stop()
+}
+
+func (p *Password) sendResetToken(ctx context.Context, username string) {
+	ctx, cancel := context.WithTimeout(ctx, passwordResetTimeout)
+	defer cancel()
+
+	err := p.requestReset(ctx, username)
+	if err != nil {
+		slog.Error(fmt.Sprintf("failed to send password reset token: %s", err.Error()))
+	}
+}
+
+func (p *Password) requestReset(ctx context.Context, username string) error {
+	user, err := p.userRepository.User(ctx, username)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return nil
+		default:
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+	}
+	if user.DisabledAt != nil {
+		return nil
+	}
+
+	token, err := generateRandomToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate password reset token: %w", err)
+	}
+	expiresAt := time.Now().Add(p.resetTokenTTL)
+	err = p.passwordResetRepository.SavePasswordResetToken(ctx, user.ID, hashToken(token), expiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to save password reset token: %w", err)
+	}
+
+	err = p.notifier.Notify(ctx, notifier.Message{
+		Recipient: user.Username,
+		Subject:   "Password reset",
+		Body: fmt.Sprintf(
+			"Password reset token: %s\nSend it with a new password to POST /v1/auth/password_reset/confirm before %s.",
+			token, expiresAt.UTC().Format(time.RFC3339),
+		),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to notify user: %w", err)
+	}
+
+	slog.Info(fmt.Sprintf("password reset requested for user %s", user.ID))
+
+	return nil
+}
+
+func (p *Password) ResetPassword(ctx context.Context, token string, newPassword string) error {
+	tokenHash := hashToken(token)
+
+	// Password is validated before using token, so user can retry with another password
+	userID, err := p.passwordResetRepository.PasswordResetTokenUserID(ctx, tokenHash)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return ErrInvalidResetToken
+		default:
+			return fmt.Errorf("failed to get password reset token: %w", err)
+		}
+	}
+	user, err := p.userRepository.UserByID(ctx, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return ErrInvalidResetToken
+		default:
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+	}
+	err = p.passwordPolicy.Validate(user.Username, newPassword)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrWeakPassword, err)
+	}
+
+	userID, err = p.passwordResetRepository.UsePasswordResetToken(ctx, tokenHash)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return ErrInvalidResetToken
+		default:
+			return fmt.Errorf("failed to use password reset token: %w", err)
+		}
+	}
+
+	return p.setPassword(ctx, userID, newPassword)
+}
+
+// setPassword revokes all sessions, so whoever knew the old password is logged out
+func (p *Password) setPassword(ctx context.Context, userID string, password string) error {
+	passwordHash, err := p.passwordHasher.Hash(password)
+	if err != nil {
+		return fmt.Errorf("failed to generate password hash: %w", err)
+	}
+
+	err = p.userRepository.SetPassword(ctx, userID, passwordHash)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return ErrUserNotFound
+		default:
+			return fmt.Errorf("failed to set password: %w", err)
+		}
+	}
+
+	err = p.sessionRepository.RevokeUserSessions(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke user sessions: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,274 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/NikolaB131-org/banner-service/internal/entity"
+	"github.com/NikolaB131-org/banner-service/internal/repository"
+)
+
+type (
+	RoleService interface {
+		GetRoles(ctx context.Context) ([]entity.Role, error)
+		GetPermissions(ctx context.Context) ([]entity.Permission, error)
+		SetRolePermissions(ctx context.Context, role string, permissions []string, actorID string) error
+		GetUserRoles(ctx context.Context, userID string) ([]string, error)
+		AssignRole(ctx context.Context, userID string, role string, actorID string) error
+		RevokeRole(ctx context.Context, userID string, role string, actorID string) error
+		GetFeatureGrants(ctx context.Context, userID string) ([]entity.FeatureGrant, error)
+		GrantFeatureRole(ctx context.Context, userID string, featureID int, role string, actorID string) error
+		RevokeFeatureRole(ctx context.Context, userID string, featureID int, role string, actorID string) error
+	}
+
+	Role struct {
+		roleRepository repository.Role
+		auditService   AuditService
+	}
+)
+
+var (
+	ErrRoleNotFound         = errors.New("role or user not found")
+	ErrRoleNotAssigned      = errors.New("role is not assigned to user")
+	ErrUnknownPermission    = errors.New("unknown permission")
+	ErrAdminRoleImmutable   = errors.New("admin role permissions can not be changed")
+	ErrLastAdminRoleRevoked = errors.New("can not revoke admin role from the last admin")
+	ErrRoleNotFeatureScoped = errors.New("role has permissions which can not be granted for a single feature")
+	ErrFeatureGrantNotFound = errors.New("feature grant not found")
+)
+
+func NewRoleService(roleRepository repository.Role, auditService AuditService) *Role {
+	return &Role{
+		roleRepository: roleRepository,
+		auditService:   auditService,
+	}
+}
+
+func (r *Role) GetRoles(ctx context.Context) ([]entity.Role, error) {
+	roles, err := r.roleRepository.Roles(ctx)
+	if err != nil {
+		return []entity.Role{}, fmt.Errorf("failed to get roles: %w", err)
+	}
+
+	return roles, nil
+}
+
+func (r *Role) GetPermissions(ctx context.Context) ([]entity.Permission, error) {
+	permissions, err := r.roleRepository.Permissions(ctx)
+	if err != nil {
+		return []entity.Permission{}, fmt.Errorf("failed to get permissions: %w", err)
+	}
+
+	return permissions, nil
+}
+
+func (r *Role) SetRolePermissions(ctx context.Context, role string, permissions []string, actorID string) error {
+	// Admin must always be able to manage roles, otherwise nobody could fix a mistake
+	if role == entity.RoleAdmin {
+		return ErrAdminRoleImmutable
+	}
+
+	knownPermissions, err := r.roleRepository.Permissions(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get permissions: %w", err)
+	}
+	for _, permission := range permissions {
+		isKnown := slices.ContainsFunc(knownPermissions, func(p entity.Permission) bool { return p.Name == permission })
+		if !isKnown {
+			return fmt.Errorf("%w: %s", ErrUnknownPermission, permission)
+		}
+	}
+
+	before, err := r.rolePermissions(ctx, role)
+	if err != nil {
+		return err
+	}
+
+	slices.Sort(permissions)
+	permissions = slices.Compact(permissions)
+	err = r.roleRepository.SetRolePermissions(ctx, role, permissions)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return ErrRoleNotFound
+		default:
+			return fmt.Errorf("failed to set role permissions: %w", err)
+		}
+	}
+
+	r.auditService.Record(ctx, actorID, entity.AuditActionUpdate, entity.AuditEntityRole, role,
+		map[string]any{"permissions": before},
+		map[string]any{"permissions": permissions},
+	)
+
+	return nil
+}
+
+func (r *Role) GetUserRoles(ctx context.Context, userID string) ([]string, error) {
+	roles, err := r.roleRepository.UserRoles(ctx, userID)
+	if err != nil {
+		return []string{}, fmt.Errorf("failed to get user roles: %w", err)
+	}
+
+	return roles, nil
+}
+
+func (r *Role) AssignRole(ctx context.Context, userID string, role string, actorID string) error {
+	before, err := r.GetUserRoles(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	err = r.roleRepository.AssignRole(ctx, userID, role)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return ErrRoleNotFound
+		default:
+			return fmt.Errorf("failed to assign role: %w", err)
+		}
+	}
+
+	r.auditUserRoles(ctx, actorID, entity.AuditActionAssignRole, userID, before)
+
+	return nil
+}
+
+func (r *Role) RevokeRole(ctx context.Context, userID string, role string, actorID string) error {
+	before, err := r.GetUserRoles(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(before, role) {
+		return ErrRoleNotAssigned
+	}
+
+	if role == entity.RoleAdmin {
+		adminsCount, err := r.roleRepository.RoleUsersCount(ctx, entity.RoleAdmin)
+		if err != nil {
+			return fmt.Errorf("failed to count admins: %w", err)
+		}
+		if adminsCount <= 1 {
+			return ErrLastAdminRoleRevoked
+		}
+	}
+
+	err = r.roleRepository.RevokeRole(ctx, userID, role)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return ErrRoleNotAssigned
+		default:
+			return fmt.Errorf("failed to revoke role: %w", err)
+		}
+	}
+
+	r.auditUserRoles(ctx, actorID, entity.AuditActionRevokeRole, userID, before)
+
+	return nil
+}
+
+func (r *Role) GetFeatureGrants(ctx context.Context, userID string) ([]entity.FeatureGrant, error) {
+	grants, err := r.roleRepository.FeatureGrants(ctx, userID)
+	if err != nil {
+		return []entity.FeatureGrant{}, fmt.Errorf("failed to get feature grants: %w", err)
+	}
+
+	return grants, nil
+}
+
+func (r *Role) GrantFeatureRole(ctx context.Context, userID string, featureID int, role string, actorID string) error {
+	permissions, err := r.rolePermissions(ctx, role)
+	if err != nil {
+		return err
+	}
+	for _, permission := range permissions {
+		if !entity.IsFeatureScoped(permission) {
+			return fmt.Errorf("%w: %s", ErrRoleNotFeatureScoped, permission)
+		}
+	}
+
+	before, err := r.GetFeatureGrants(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	err = r.roleRepository.SaveFeatureGrant(ctx, userID, featureID, role)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return ErrRoleNotFound
+		default:
+			return fmt.Errorf("failed to grant feature role: %w", err)
+		}
+	}
+
+	r.auditFeatureGrants(ctx, actorID, entity.AuditActionGrantFeatureRole, userID, before)
+
+	return nil
+}
+
+func (r *Role) RevokeFeatureRole(ctx context.Context, userID string, featureID int, role string, actorID string) error {
+	before, err := r.GetFeatureGrants(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	err = r.roleRepository.DeleteFeatureGrant(ctx, userID, featureID, role)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return ErrFeatureGrantNotFound
+		default:
+			return fmt.Errorf("failed to revoke feature role: %w", err)
+		}
+	}
+
+	r.auditFeatureGrants(ctx, actorID, entity.AuditActionRevokeFeatureRole, userID, before)
+
+	return nil
+}
+
+func (r *Role) rolePermissions(ctx context.Context, role string) ([]string, error) {
+	roles, err := r.roleRepository.Roles(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get roles: %w", err)
+	}
+	i := slices.IndexFunc(roles, func(r entity.Role) bool { return r.Name == role })
+	if i == -1 {
+		return nil, ErrRoleNotFound
+	}
+
+	return roles[i].Permissions, nil
+}
+
+// auditUserRoles records user roles change with roles loaded from database as after state
+func (r *Role) auditUserRoles(ctx context.Context, actorID string, action string, userID string, before []string) {
+	after, err := r.roleRepository.UserRoles(ctx, userID)
+	if err != nil {
+		slog.Error(fmt.Sprintf("failed to get user roles for audit: %s", err.Error()))
+		return
+	}
+
+	r.auditService.Record(ctx, actorID, action, entity.AuditEntityUser, userID,
+		map[string]any{"roles": before},
+		map[string]any{"roles": after},
+	)
+}
+
+// auditFeatureGrants records user feature grants change with grants loaded from database as after state
+func (r *Role) auditFeatureGrants(ctx context.Context, actorID string, action string, userID string, before []entity.FeatureGrant) {
+	after, err := r.roleRepository.FeatureGrants(ctx, userID)
+	if err != nil {
+		slog.Error(fmt.Sprintf("failed to get feature grants for audit: %s", err.Error()))
+		return
+	}
+
+	r.auditService.Record(ctx, actorID, action, entity.AuditEntityUser, userID,
+		map[string]any{"feature_grants": before},
+		map[string]any{"feature_grants": after},
+	)
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/NikolaB131-org/banner-service/internal/entity"
+	"github.com/NikolaB131-org/banner-service/internal/repository"
+)
+
+type (
+	TagService interface {
+		GetTags(ctx context.Context, limit *int, offset *int) ([]entity.Tag, error)
+		GetTag(ctx context.Context, id int) (entity.Tag, error)
+		Create(ctx context.Context, name string, description string, actorID string) (int, error)
+		Update(ctx context.Context, id int, name *string, description *string, actorID string) error
+		// DeleteByID with cascade deletes banners of tag too, every deleted banner is audited
+		DeleteByID(ctx context.Context, id int, cascade bool, actorID string) error
+	}
+
+	Tag struct {
+		tagRepository         repository.Tag
+		bannerCacheRepository repository.BannerCache
+		auditService          AuditService
+	}
+)
+
+var (
+	ErrTagNotFound = errors.New("tag not found")
+	ErrTagInUse    = errors.New("tag is used by banners")
+)
+
+func NewTagService(tagRepository repository.Tag, bannerCacheRepository repository.BannerCache, auditService AuditService) *Tag {
+	return &Tag{
+		tagRepository:         tagRepository,
+		bannerCacheRepository: bannerCacheRepository,
+		auditService:          auditService,
+	}
+}
+
+func (t *Tag) GetTags(ctx context.Context, limit *int, offset *int) ([]entity.Tag, error) {
+	tags, err := t.tagRepository.Tags(ctx, limit, offset)
+	if err != nil {
+		return []entity.Tag{}, fmt.Errorf("failed to get tags: %w", err)
+	}
+
+	return tags, nil
+}
+
+func (t *Tag) GetTag(ctx context.Context, id int) (entity.Tag, error) {
+	tag, err := t.tagRepository.TagByID(ctx, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return entity.Tag{}, ErrTagNotFound
+		default:
+			return entity.Tag{}, fmt.Errorf("failed to get tag: %w", err)
+		}
+	}
+
+	return tag, nil
+}
+
+func (t *Tag) Create(ctx context.Context, name string, description string, actorID string) (int, error) {
+	id, err := t.tagRepository.SaveTag(ctx, name, description)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create tag: %w", err)
+	}
+
+	t.auditTag(ctx, actorID, entity.AuditActionCreate, id, nil)
+
+	return id, nil
+}
+
+func (t *Tag) Update(ctx context.Context, id int, name *string, description *string, actorID string) error {
+	oldTag, err := t.GetTag(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	err = t.tagRepository.UpdateTag(ctx, id, name, description)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return ErrTagNotFound
+		default:
+			return fmt.Errorf("failed to update tag: %w", err)
+		}
+	}
+
+	t.auditTag(ctx, actorID, entity.AuditActionUpdate, id, oldTag)
+
+	return nil
+}
+
+func (t *Tag) DeleteByID(ctx context.Context, id int, cascade bool, actorID string) error {
+	tag, err := t.GetTag(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	banners, err := t.tagRepository.DeleteTag(ctx, id, cascade)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return ErrTagNotFound
+		case errors.Is(err, repository.ErrInUse):
+			return ErrTagInUse
+		default:
+			return fmt.Errorf("failed to delete tag: %w", err)
+		}
+	}
+
+	invalidateCachedBanners(ctx, t.bannerCacheRepository, banners...)
+	t.auditService.Record(ctx, actorID, entity.AuditActionDelete, entity.AuditEntityTag, strconv.Itoa(id), tag, nil)
+	for _, banner := range banners {
+		t.auditService.Record(ctx, actorID, entity.AuditActionDelete, entity.AuditEntityBanner, strconv.Itoa(banner.ID), banner, nil)
+	}
+
+	return nil
+}
+
+func (t *Tag) auditTag(ctx context.Context, actorID string, action string, tagID int, before any) {
+	after, err := t.tagRepository.TagByID(ctx, tagID)
+	if err != nil {
+		slog.Error(fmt.Sprintf("failed to get tag for audit: %s", err.Error()))
+		return
+	}
+	t.auditService.Record(ctx, actorID, action, entity.AuditEntityTag, strconv.Itoa(tagID), before, after)
+}
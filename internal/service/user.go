@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/NikolaB131-org/banner-service/internal/entity"
+	"github.com/NikolaB131-org/banner-service/internal/repository"
+)
+
+type (
+	UserService interface {
+		GetUsers(ctx context.Context, limit *int, offset *int) ([]entity.User, error)
+		GetUser(ctx context.Context, id string) (entity.User, error)
+		SetDisabled(ctx context.Context, id string, isDisabled bool, actorID string) error
+		ForcePasswordReset(ctx context.Context, id string, actorID string) error
+		Delete(ctx context.Context, id string, actorID string) error
+	}
+
+	User struct {
+		userRepository    repository.User
+		sessionRepository repository.Session
+		roleRepository    repository.Role
+		auditService      AuditService
+	}
+)
+
+var (
+	ErrUserNotFound      = errors.New("user not found")
+	ErrSelfModification  = errors.New("can not disable or delete yourself")
+	ErrLastAdminDisabled = errors.New("can not disable or delete the last admin")
+)
+
+func NewUserService(
+	userRepository repository.User,
+	sessionRepository repository.Session,
+	roleRepository repository.Role,
+	auditService AuditService,
+) *User {
+	return &User{
+		userRepository:    userRepository,
+		sessionRepository: sessionRepository,
+		roleRepository:    roleRepository,
+		auditService:      auditService,
+	}
+}
+
+func (u *User) GetUsers(ctx context.Context, limit *int, offset *int) ([]entity.User, error) {
+	users, err := u.userRepository.Users(ctx, limit, offset)
+	if err != nil {
+		return []entity.User{}, fmt.Errorf("failed to get users: %w", err)
+	}
+
+	return users, nil
+}
+
+func (u *User) GetUser(ctx context.Context, id string) (entity.User, error) {
+	user, err := u.userRepository.UserByID(ctx, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return entity.User{}, ErrUserNotFound
+		default:
+			return entity.User{}, fmt.Errorf("failed to get user: %w", err)
+		}
+	}
+
+	return user, nil
+}
+
+// SetDisabled disables or enables user, disabling also revokes all user sessions
+func (u *User) SetDisabled(ctx context.Context, id string, isDisabled bool, actorID string) error {
+	before, err := u.GetUser(ctx, id)
+	if err != nil {
+		return err
+	}
+	if isDisabled {
+		if err := u.checkCanBeRemoved(ctx, before, actorID); err != nil {
+			return err
+		}
+	}
+
+	err = u.userRepository.SetUserDisabled(ctx, id, isDisabled)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return ErrUserNotFound
+		default:
+			return fmt.Errorf("failed to set user disabled: %w", err)
+		}
+	}
+	if isDisabled {
+		err = u.sessionRepository.RevokeUserSessions(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to revoke user sessions: %w", err)
+		}
+	}
+
+	action := entity.AuditActionEnableUser
+	if isDisabled {
+		action = entity.AuditActionDisableUser
+	}
+	u.audit(ctx, actorID, action, before)
+
+	return nil
+}
+
+// ForcePasswordReset forbids login until password is reset and logs user out everywhere
+func (u *User) ForcePasswordReset(ctx context.Context, id string, actorID string) error {
+	before, err := u.GetUser(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	err = u.userRepository.SetPasswordResetRequired(ctx, id, true)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return ErrUserNotFound
+		default:
+			return fmt.Errorf("failed to require password reset: %w", err)
+		}
+	}
+	err = u.sessionRepository.RevokeUserSessions(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to revoke user sessions: %w", err)
+	}
+
+	u.audit(ctx, actorID, entity.AuditActionForcePasswordReset, before)
+
+	return nil
+}
+
+func (u *User) Delete(ctx context.Context, id string, actorID string) error {
+	user, err := u.GetUser(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := u.checkCanBeRemoved(ctx, user, actorID); err != nil {
+		return err
+	}
+
+	err = u.userRepository.DeleteUser(ctx, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return ErrUserNotFound
+		default:
+			return fmt.Errorf("failed to delete user: %w", err)
+		}
+	}
+	// Sessions are deleted with user, revoking them drops their cached access as well
+	err = u.sessionRepository.RevokeUserSessions(ctx, id)
+	if err != nil {
+		slog.Warn(fmt.Sprintf("failed to revoke sessions of deleted user: %s", err.Error()))
+	}
+
+	u.auditService.Record(ctx, actorID, entity.AuditActionDelete, entity.AuditEntityUser, id, user, nil)
+
+	return nil
+}
+
+// checkCanBeRemoved prevents admins from locking everyone, including themselves, out of user management
+func (u *User) checkCanBeRemoved(ctx context.Context, user entity.User, actorID string) error {
+	if user.ID == actorID {
+		return ErrSelfModification
+	}
+	if user.DisabledAt == nil && slices.Contains(user.Roles, entity.RoleAdmin) {
+		adminsCount, err := u.roleRepository.RoleUsersCount(ctx, entity.RoleAdmin)
+		if err != nil {
+			return fmt.Errorf("failed to count admins: %w", err)
+		}
+		if adminsCount <= 1 {
+			return ErrLastAdminDisabled
+		}
+	}
+
+	return nil
+}
+
+func (u *User) audit(ctx context.Context, actorID string, action string, before entity.User) {
+	after, err := u.userRepository.UserByID(ctx, before.ID)
+	if err != nil {
+		// User state was changed anyway, audit record is just less detailed
+		after = before
+	}
+
+	u.auditService.Record(ctx, actorID, action, entity.AuditEntityUser, before.ID, before, after)
+}
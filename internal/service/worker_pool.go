@@ -0,0 +1,67 @@
+package service
+
+import "sync"
+
+// workerPool runs submitted tasks on a fixed number of goroutines with a bounded queue
+type workerPool struct {
+	tasks  chan func()
+	wg     sync.WaitGroup
+	mu     sync.RWMutex
+	closed bool
+}
+
+func newWorkerPool(workers int, queueSize int) *workerPool {
+	p := &workerPool{tasks: make(chan func(), queueSize)}
+
+	p.wg.Add(workers)
+	for range workers {
+		go func() {
+			defer p.wg.Done()
+			for task := range p.tasks {
+				task()
+			}
+		}()
+	}
+
+	return p
+}
+
+// submit enqueues task without blocking, returns false if queue is full or pool is stopped
+func (p *workerPool) submit(task func()) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		return false
+	}
+
+	select {
+	case p.tasks <- task:
+		return true
+	default:
+		return false
+	}
+}
+
+// free returns number of tasks which can be submitted without blocking right now
+func (p *workerPool) free() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		return 0
+	}
+	return cap(p.tasks) - len(p.tasks)
+}
+
+// stop rejects new tasks and waits until already queued ones are processed
+func (p *workerPool) stop() {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.tasks)
+	}
+	p.mu.Unlock()
+
+	p.wg.Wait()
+}
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS banner_tags;
+DROP TABLE IF EXISTS banners;
+DROP TABLE IF EXISTS tags;
+DROP TABLE IF EXISTS features;
+DROP TABLE IF EXISTS users;
+DROP FUNCTION IF EXISTS trigger_set_updated_at();
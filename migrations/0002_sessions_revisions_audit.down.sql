@@ -0,0 +1,13 @@
+DROP TABLE audit_log;
+DROP TABLE banner_revisions;
+
+ALTER TABLE banners DROP COLUMN active_from, DROP COLUMN active_until;
+
+DROP TRIGGER tags_update_timestamp ON tags;
+ALTER TABLE tags DROP COLUMN name, DROP COLUMN description, DROP COLUMN created_at, DROP COLUMN updated_at;
+
+DROP TRIGGER features_update_timestamp ON features;
+ALTER TABLE features DROP COLUMN name, DROP COLUMN description, DROP COLUMN created_at, DROP COLUMN updated_at;
+
+DROP TABLE refresh_tokens;
+DROP TABLE sessions;
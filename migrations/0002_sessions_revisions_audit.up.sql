@@ -0,0 +1,71 @@
+CREATE TABLE sessions (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  expires_at TIMESTAMP NOT NULL,
+  revoked_at TIMESTAMP,
+  created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE TABLE refresh_tokens (
+  token_hash BYTEA PRIMARY KEY,
+  session_id UUID NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+  expires_at TIMESTAMP NOT NULL,
+  used_at TIMESTAMP,
+  created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+ALTER TABLE features
+  ADD COLUMN name VARCHAR(64) NOT NULL DEFAULT '',
+  ADD COLUMN description TEXT NOT NULL DEFAULT '',
+  ADD COLUMN created_at TIMESTAMP NOT NULL DEFAULT now(),
+  ADD COLUMN updated_at TIMESTAMP NOT NULL DEFAULT now();
+
+CREATE TRIGGER features_update_timestamp
+BEFORE UPDATE ON features
+FOR EACH ROW EXECUTE PROCEDURE trigger_set_updated_at();
+
+ALTER TABLE tags
+  ADD COLUMN name VARCHAR(64) NOT NULL DEFAULT '',
+  ADD COLUMN description TEXT NOT NULL DEFAULT '',
+  ADD COLUMN created_at TIMESTAMP NOT NULL DEFAULT now(),
+  ADD COLUMN updated_at TIMESTAMP NOT NULL DEFAULT now();
+
+CREATE TRIGGER tags_update_timestamp
+BEFORE UPDATE ON tags
+FOR EACH ROW EXECUTE PROCEDURE trigger_set_updated_at();
+
+ALTER TABLE banners
+  ADD COLUMN active_from TIMESTAMPTZ,
+  ADD COLUMN active_until TIMESTAMPTZ CHECK (active_until > active_from);
+
+CREATE TABLE banner_revisions (
+  banner_id INT NOT NULL REFERENCES banners(id) ON DELETE CASCADE,
+  version INT NOT NULL,
+  tag_ids INT[] NOT NULL,
+  feature_id INT NOT NULL,
+  content JSONB NOT NULL,
+  is_active BOOLEAN NOT NULL,
+  active_from TIMESTAMPTZ,
+  active_until TIMESTAMPTZ,
+  author_id UUID REFERENCES users(id) ON DELETE SET NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT now(),
+  PRIMARY KEY (banner_id, version)
+);
+
+CREATE TABLE audit_log (
+  id BIGSERIAL PRIMARY KEY,
+  actor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+  action VARCHAR(32) NOT NULL,
+  entity_type VARCHAR(32) NOT NULL,
+  entity_id VARCHAR(64) NOT NULL,
+  changes JSONB NOT NULL,
+  request_id VARCHAR(64) NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX audit_log_entity_idx ON audit_log (entity_type, entity_id);
+CREATE INDEX audit_log_actor_idx ON audit_log (actor_id);
+
+-- Move sequences past explicitly inserted ids
+SELECT setval('features_id_seq', (SELECT MAX(id) FROM features));
+SELECT setval('tags_id_seq', (SELECT MAX(id) FROM tags));
@@ -0,0 +1,55 @@
+CREATE TABLE roles (
+  name VARCHAR(32) PRIMARY KEY,
+  description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE permissions (
+  name VARCHAR(64) PRIMARY KEY,
+  description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE role_permissions (
+  role VARCHAR(32) NOT NULL REFERENCES roles(name) ON DELETE CASCADE,
+  permission VARCHAR(64) NOT NULL REFERENCES permissions(name) ON DELETE CASCADE,
+  PRIMARY KEY (role, permission)
+);
+
+CREATE TABLE user_roles (
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  role VARCHAR(32) NOT NULL REFERENCES roles(name) ON DELETE CASCADE,
+  created_at TIMESTAMP NOT NULL DEFAULT now(),
+  PRIMARY KEY (user_id, role)
+);
+
+CREATE INDEX user_roles_role_idx ON user_roles (role);
+
+INSERT INTO permissions (name, description) VALUES
+  ('banner:read', 'View banners including inactive ones, features and tags'),
+  ('banner:write', 'Create, update and delete banners, features and tags'),
+  ('banner:publish', 'Activate and deactivate banners, change activation windows, rollback'),
+  ('audit:read', 'View audit log'),
+  ('user:manage', 'Manage users, their roles and role permissions');
+
+INSERT INTO roles (name, description) VALUES
+  ('viewer', 'Read-only access to banners'),
+  ('editor', 'Edits banner content'),
+  ('publisher', 'Edits and publishes banners'),
+  ('admin', 'Full access');
+
+INSERT INTO role_permissions (role, permission) VALUES
+  ('viewer', 'banner:read'),
+  ('editor', 'banner:read'),
+  ('editor', 'banner:write'),
+  ('publisher', 'banner:read'),
+  ('publisher', 'banner:write'),
+  ('publisher', 'banner:publish'),
+  ('admin', 'banner:read'),
+  ('admin', 'banner:write'),
+  ('admin', 'banner:publish'),
+  ('admin', 'audit:read'),
+  ('admin', 'user:manage');
+
+-- Move existing admins to the new model, plain users get no roles
+INSERT INTO user_roles (user_id, role) SELECT id, 'admin' FROM users WHERE role = 'admin';
+
+ALTER TABLE users DROP COLUMN role;
@@ -0,0 +1,8 @@
+-- Roles granted to user only within a single feature, in addition to global user_roles
+CREATE TABLE feature_grants (
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  feature_id INT NOT NULL REFERENCES features(id) ON DELETE CASCADE,
+  role VARCHAR(32) NOT NULL REFERENCES roles(name) ON DELETE CASCADE,
+  created_at TIMESTAMP NOT NULL DEFAULT now(),
+  PRIMARY KEY (user_id, feature_id, role)
+);
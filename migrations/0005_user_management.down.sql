@@ -0,0 +1,3 @@
+ALTER TABLE users
+  DROP COLUMN password_reset_required,
+  DROP COLUMN disabled_at;
@@ -0,0 +1,12 @@
+CREATE TABLE api_keys (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  name VARCHAR(64) NOT NULL CHECK (name <> ''),
+  prefix VARCHAR(16) NOT NULL,
+  key_hash BYTEA NOT NULL UNIQUE,
+  scopes VARCHAR(64)[] NOT NULL DEFAULT '{}',
+  created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+  expires_at TIMESTAMPTZ,
+  last_used_at TIMESTAMPTZ,
+  revoked_at TIMESTAMPTZ,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
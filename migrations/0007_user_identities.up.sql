@@ -0,0 +1,12 @@
+-- Users provisioned by external identity provider have no local password
+ALTER TABLE users ALTER COLUMN password_hash DROP NOT NULL;
+
+CREATE TABLE user_identities (
+  issuer VARCHAR(255) NOT NULL,
+  subject VARCHAR(255) NOT NULL,
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  created_at TIMESTAMP NOT NULL DEFAULT now(),
+  PRIMARY KEY (issuer, subject)
+);
+
+CREATE INDEX user_identities_user_idx ON user_identities (user_id);
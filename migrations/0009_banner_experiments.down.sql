@@ -0,0 +1,2 @@
+DROP TABLE banner_variants;
+DROP TABLE banner_experiments;
@@ -0,0 +1,14 @@
+-- A/B experiment of a banner, users are split between its variants by weights
+CREATE TABLE banner_experiments (
+  banner_id INT PRIMARY KEY REFERENCES banners(id) ON DELETE CASCADE,
+  name VARCHAR(64) NOT NULL CHECK (name <> ''),
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE banner_variants (
+  banner_id INT NOT NULL REFERENCES banner_experiments(banner_id) ON DELETE CASCADE,
+  name VARCHAR(64) NOT NULL CHECK (name <> ''),
+  content JSONB NOT NULL,
+  weight INT NOT NULL CHECK (weight >= 0),
+  PRIMARY KEY (banner_id, name)
+);
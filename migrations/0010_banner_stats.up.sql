@@ -0,0 +1,9 @@
+-- Daily counters of banner events, variant is empty for content served outside of experiment
+CREATE TABLE banner_stats (
+  banner_id INT NOT NULL REFERENCES banners(id) ON DELETE CASCADE,
+  day DATE NOT NULL,
+  variant VARCHAR(64) NOT NULL DEFAULT '',
+  impressions BIGINT NOT NULL DEFAULT 0,
+  clicks BIGINT NOT NULL DEFAULT 0,
+  PRIMARY KEY (banner_id, day, variant)
+);
@@ -0,0 +1,7 @@
+-- Localized content of a banner, banners.content is served when there is no content for requested locale
+CREATE TABLE banner_locales (
+  banner_id INT NOT NULL REFERENCES banners(id) ON DELETE CASCADE,
+  locale VARCHAR(35) NOT NULL CHECK (locale <> ''),
+  content JSONB NOT NULL,
+  PRIMARY KEY (banner_id, locale)
+);
@@ -0,0 +1 @@
+DROP TABLE banner_deletion_jobs;
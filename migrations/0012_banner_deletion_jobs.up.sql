@@ -0,0 +1,14 @@
+-- State of asynchronous banner deletions, stored in database so any replica can report it
+CREATE TABLE banner_deletion_jobs (
+  id VARCHAR(32) PRIMARY KEY,
+  status VARCHAR(16) NOT NULL,
+  feature_id INT,
+  tag_id INT,
+  deleted INT NOT NULL DEFAULT 0,
+  error TEXT NOT NULL DEFAULT '',
+  created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX banner_deletion_jobs_created_at_idx ON banner_deletion_jobs (created_at);
@@ -0,0 +1,3 @@
+-- Experiment and localized content are restored by rollback as well,
+-- NULL locales mark revisions saved before they were stored, rollback to them keeps current ones
+ALTER TABLE banner_revisions ADD COLUMN experiment JSONB, ADD COLUMN locales JSONB;
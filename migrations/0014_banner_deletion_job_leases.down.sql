@@ -0,0 +1,2 @@
+DROP INDEX banner_deletion_jobs_lease_until_idx;
+ALTER TABLE banner_deletion_jobs DROP COLUMN lease_until;
@@ -0,0 +1,5 @@
+-- Unfinished job is owned by replica while its lease is renewed, jobs of stopped replicas are taken over after it expires.
+-- Jobs enqueued before leases existed are taken over at once
+ALTER TABLE banner_deletion_jobs ADD COLUMN lease_until TIMESTAMPTZ NOT NULL DEFAULT now();
+
+CREATE INDEX banner_deletion_jobs_lease_until_idx ON banner_deletion_jobs (lease_until) WHERE status IN ('queued', 'running');
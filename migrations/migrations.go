@@ -0,0 +1,9 @@
+// Package migrations contains versioned database schema changes.
+// Files are named <version>_<name>.up.sql and <version>_<name>.down.sql,
+// new changes must be added as a new version instead of editing applied ones
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,256 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"slices"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type (
+	Migration struct {
+		Version int
+		Name    string
+		Up      string
+		Down    string
+	}
+
+	Migrator struct {
+		pool       *pgxpool.Pool
+		migrations []Migration // sorted by version
+	}
+)
+
+var (
+	ErrNoDownMigration = errors.New("migration has no down file")
+	ErrUnknownVersion  = errors.New("unknown migration version")
+)
+
+// lockID is a key of postgres advisory lock which prevents concurrently starting instances from migrating at the same time
+const lockID int64 = 0x62616e6e6572 // "banner"
+
+var fileNameRegexp = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// New reads migrations from fsys root, file names must match <version>_<name>.(up|down).sql
+func New(pool *pgxpool.Pool, fsys fs.FS) (*Migrator, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations dir: %w", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := fileNameRegexp.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+
+		version, err := strconv.Atoi(match[1])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse migration version %s: %w", entry.Name(), err)
+		}
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration version %d is used by both %s and %s", version, migration.Name, match[2])
+		}
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int { return a.Version - b.Version })
+
+	return &Migrator{pool: pool, migrations: migrations}, nil
+}
+
+// Up applies all pending migrations, each one in its own transaction
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+
+	err := m.withLock(ctx, func(conn *pgx.Conn) error {
+		current, err := currentVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if migration.Version <= current {
+				continue
+			}
+			err := apply(ctx, conn, migration.Up, func(tx pgx.Tx) error {
+				_, err := tx.Exec(ctx, "INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", migration.Version, migration.Name)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("failed to apply migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down reverts last steps applied migrations
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var reverted []Migration
+
+	err := m.withLock(ctx, func(conn *pgx.Conn) error {
+		for range steps {
+			current, err := currentVersion(ctx, conn)
+			if err != nil {
+				return err
+			}
+			if current == 0 {
+				return nil
+			}
+
+			migration, ok := m.migration(current)
+			if !ok {
+				return fmt.Errorf("%w: %d", ErrUnknownVersion, current)
+			}
+			if migration.Down == "" {
+				return fmt.Errorf("%w: %d_%s", ErrNoDownMigration, migration.Version, migration.Name)
+			}
+
+			err = apply(ctx, conn, migration.Down, func(tx pgx.Tx) error {
+				_, err := tx.Exec(ctx, "DELETE FROM schema_migrations WHERE version = $1", migration.Version)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("failed to revert migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			reverted = append(reverted, migration)
+		}
+		return nil
+	})
+
+	return reverted, err
+}
+
+// Force marks all migrations up to version as applied without running them,
+// used to adopt databases created before migrations were introduced
+func (m *Migrator) Force(ctx context.Context, version int) error {
+	if _, ok := m.migration(version); !ok {
+		return fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+	}
+
+	return m.withLock(ctx, func(conn *pgx.Conn) error {
+		return pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+			_, err := tx.Exec(ctx, "DELETE FROM schema_migrations")
+			if err != nil {
+				return fmt.Errorf("failed to clear schema_migrations: %w", err)
+			}
+			for _, migration := range m.migrations {
+				if migration.Version > version {
+					break
+				}
+				_, err := tx.Exec(ctx, "INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", migration.Version, migration.Name)
+				if err != nil {
+					return fmt.Errorf("failed to mark migration %d as applied: %w", migration.Version, err)
+				}
+			}
+			return nil
+		})
+	})
+}
+
+// Version returns version of last applied migration, 0 if there are none
+func (m *Migrator) Version(ctx context.Context) (int, error) {
+	var version int
+
+	err := m.withLock(ctx, func(conn *pgx.Conn) error {
+		var err error
+		version, err = currentVersion(ctx, conn)
+		return err
+	})
+
+	return version, err
+}
+
+func (m *Migrator) migration(version int) (Migration, bool) {
+	i, found := slices.BinarySearchFunc(m.migrations, version, func(m Migration, v int) int { return m.Version - v })
+	if !found {
+		return Migration{}, false
+	}
+	return m.migrations[i], true
+}
+
+// withLock runs f on a single connection holding session advisory lock and ensures schema_migrations table exists
+func (m *Migrator) withLock(ctx context.Context, f func(conn *pgx.Conn) error) error {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	_, err = conn.Exec(ctx, "SELECT pg_advisory_lock($1)", lockID)
+	if err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer func() {
+		// Not using ctx since it may be already canceled, lock must be released anyway
+		_, err := conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", lockID)
+		if err != nil {
+			conn.Conn().Close(context.Background()) // closing connection releases session lock
+		}
+	}()
+
+	_, err = conn.Exec(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	return f(conn.Conn())
+}
+
+func currentVersion(ctx context.Context, conn *pgx.Conn) (int, error) {
+	var version int
+	err := conn.QueryRow(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get current migration version: %w", err)
+	}
+	return version, nil
+}
+
+// apply executes migration sql and bookkeeping query atomically
+func apply(ctx context.Context, conn *pgx.Conn, sql string, record func(tx pgx.Tx) error) error {
+	return pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, sql)
+		if err != nil {
+			return err
+		}
+		return record(tx)
+	})
+}
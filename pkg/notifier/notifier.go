@@ -0,0 +1,101 @@
+// Package notifier delivers messages to users. Real delivery channels (email, chat)
+// can be plugged in by implementing Notifier, log and file implementations are meant for local use
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+type (
+	Notifier interface {
+		Notify(ctx context.Context, message Message) error
+	}
+
+	Message struct {
+		Recipient string `json:"recipient"` // username
+		Subject   string `json:"subject"`
+		Body      string `json:"body"`
+	}
+
+	// Log writes messages to service log, bodies are redacted unless logBody is set
+	// because they carry secrets (password reset tokens) which must not get to logs in production
+	Log struct {
+		logBody bool
+	}
+
+	// File appends messages to file as json lines
+	File struct {
+		path string
+		mu   sync.Mutex
+	}
+
+	fileRecord struct {
+		Message
+		SentAt time.Time `json:"sent_at"`
+	}
+)
+
+func New(notifierType string, filePath string, logBody bool) (Notifier, error) {
+	switch notifierType {
+	case "log":
+		return NewLog(logBody), nil
+	case "file":
+		if filePath == "" {
+			return nil, fmt.Errorf("file path is required for file notifier")
+		}
+		return NewFile(filePath), nil
+	default:
+		return nil, fmt.Errorf("unknown notifier type %q", notifierType)
+	}
+}
+
+func NewLog(logBody bool) *Log {
+	return &Log{logBody: logBody}
+}
+
+func (l *Log) Notify(ctx context.Context, message Message) error {
+	body := "[redacted]"
+	if l.logBody {
+		body = message.Body
+	}
+	slog.Info(
+		"notification",
+		slog.String("recipient", message.Recipient),
+		slog.String("subject", message.Subject),
+		slog.String("body", body),
+	)
+	return nil
+}
+
+func NewFile(path string) *File {
+	return &File{path: path}
+}
+
+func (f *File) Notify(ctx context.Context, message Message) error {
+	record, err := json.Marshal(fileRecord{Message: message, SentAt: time.Now()})
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open notifications file: %w", err)
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(record, '\n'))
+	if err != nil {
+		return fmt.Errorf("failed to write notification: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+type (
+	Config struct {
+		Issuer       string
+		ClientID     string
+		ClientSecret string
+		RedirectURL  string
+		Scopes       []string // openid is always requested
+	}
+
+	// Client performs authorization code flow with PKCE against OpenID provider
+	Client struct {
+		oauth2   oauth2.Config
+		verifier *oidc.IDTokenVerifier
+	}
+
+	IDToken struct {
+		Issuer  string
+		Subject string
+		Claims  map[string]any
+	}
+)
+
+var (
+	ErrNoIDToken     = errors.New("token response has no id_token")
+	ErrNonceMismatch = errors.New("id_token nonce does not match")
+)
+
+// New fetches provider metadata from issuer discovery document
+func New(ctx context.Context, config Config) (*Client, error) {
+	provider, err := oidc.NewProvider(ctx, config.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover oidc provider: %w", err)
+	}
+
+	scopes := []string{oidc.ScopeOpenID}
+	for _, scope := range config.Scopes {
+		if scope != oidc.ScopeOpenID {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	return &Client{
+		oauth2: oauth2.Config{
+			ClientID:     config.ClientID,
+			ClientSecret: config.ClientSecret,
+			RedirectURL:  config.RedirectURL,
+			Endpoint:     provider.Endpoint(),
+			Scopes:       scopes,
+		},
+		verifier: provider.Verifier(&oidc.Config{ClientID: config.ClientID}),
+	}, nil
+}
+
+// AuthCodeURL returns provider login page url, verifier is a PKCE code verifier
+func (c *Client) AuthCodeURL(state string, nonce string, verifier string) string {
+	return c.oauth2.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier))
+}
+
+// Exchange redeems authorization code and returns verified id_token claims
+func (c *Client) Exchange(ctx context.Context, code string, verifier string, nonce string) (IDToken, error) {
+	token, err := c.oauth2.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return IDToken{}, fmt.Errorf("failed to exchange code: %w", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return IDToken{}, ErrNoIDToken
+	}
+	idToken, err := c.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return IDToken{}, fmt.Errorf("failed to verify id_token: %w", err)
+	}
+	if idToken.Nonce != nonce {
+		return IDToken{}, ErrNonceMismatch
+	}
+
+	claims := make(map[string]any)
+	if err := idToken.Claims(&claims); err != nil {
+		return IDToken{}, fmt.Errorf("failed to parse id_token claims: %w", err)
+	}
+
+	return IDToken{Issuer: idToken.Issuer, Subject: idToken.Subject, Claims: claims}, nil
+}
@@ -32,3 +32,7 @@ func (p *Postgres) Close() {
 		p.Pool.Close()
 	}
 }
+
+func (p *Postgres) Ping(ctx context.Context) error {
+	return p.Pool.Ping(ctx)
+}
@@ -38,3 +38,7 @@ func (r *Redis) Close() error {
 	}
 	return nil
 }
+
+func (r *Redis) Ping(ctx context.Context) error {
+	return r.Client.Ping(ctx).Err()
+}
@@ -0,0 +1,108 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/NikolaB131-org/banner-service/internal/service"
+	"github.com/stretchr/testify/suite"
+)
+
+type APIKeySuite struct {
+	suite.Suite
+	*fixture
+}
+
+func TestAPIKeySuite(t *testing.T) {
+	suite.Run(t, new(APIKeySuite))
+}
+
+func (suite *APIKeySuite) SetupSuite() {
+	suite.fixture = sharedFixture()
+}
+
+func (s *APIKeySuite) createKey(body string) (string, string) {
+	status, resBody := s.do(s.AdminToken, http.MethodPost, "/v1/api_key/", body)
+	s.Require().Equal(http.StatusCreated, status)
+	var created struct {
+		ID  string `json:"api_key_id"`
+		Key string `json:"key"`
+	}
+	s.Require().NoError(json.Unmarshal(resBody, &created))
+	return created.ID, created.Key
+}
+
+func (s *APIKeySuite) doWithKey(key string, method string, path string) (int, []byte) {
+	return s.request(method, path, "", http.Header{"X-API-Key": {key}})
+}
+
+func (s *APIKeySuite) TestAPIKey_Scopes() {
+	_, frontendKey := s.createKey(`{"name": "frontend"}`)
+	readerID, readerKey := s.createKey(`{"name": "reader", "scopes": ["banner:read"]}`)
+
+	status, _ := s.doWithKey(frontendKey, http.MethodGet, "/v1/user_banner?tag_id=29&feature_id=19")
+	s.NotEqual(http.StatusUnauthorized, status)
+	status, _ = s.doWithKey(frontendKey, http.MethodGet, "/v1/banner/")
+	s.Equal(http.StatusForbidden, status)
+	status, _ = s.doWithKey(readerKey, http.MethodGet, "/v1/banner/")
+	s.Equal(http.StatusOK, status)
+	status, _ = s.doWithKey("bnr_notakey", http.MethodGet, "/v1/user_banner?tag_id=29&feature_id=19")
+	s.Equal(http.StatusUnauthorized, status)
+
+	status, body := s.do(s.AdminToken, http.MethodGet, "/v1/api_key/", "")
+	s.Equal(http.StatusOK, status)
+	s.Contains(string(body), readerID)
+	s.NotContains(string(body), readerKey)
+
+	status, _ = s.do(s.AdminToken, http.MethodDelete, "/v1/api_key/"+readerID, "")
+	s.Equal(http.StatusNoContent, status)
+	status, _ = s.doWithKey(readerKey, http.MethodGet, "/v1/banner/")
+	s.Equal(http.StatusUnauthorized, status)
+	status, _ = s.do(s.AdminToken, http.MethodDelete, "/v1/api_key/"+readerID, "")
+	s.Equal(http.StatusNotFound, status)
+}
+
+func (s *APIKeySuite) TestAPIKey_InvalidCreate() {
+	status, _ := s.do(s.AdminToken, http.MethodPost, "/v1/api_key/", `{"name": "admin", "scopes": ["user:manage"]}`)
+	s.Equal(http.StatusBadRequest, status)
+	status, _ = s.do(s.AdminToken, http.MethodPost, "/v1/api_key/", `{"name": "unknown", "scopes": ["banner:fly"]}`)
+	s.Equal(http.StatusBadRequest, status)
+	status, _ = s.do(s.AdminToken, http.MethodPost, "/v1/api_key/", `{"name": "expired", "expires_at": "2020-01-01T00:00:00Z"}`)
+	s.Equal(http.StatusBadRequest, status)
+}
+
+func (s *APIKeySuite) TestAPIKey_RevokeInvalidID() {
+	status, _ := s.do(s.AdminToken, http.MethodDelete, "/v1/api_key/not-a-uuid", "")
+	s.Equal(http.StatusBadRequest, status)
+
+	err := s.APIKeyService.Revoke(context.Background(), "not-a-uuid", s.AdminID)
+	s.ErrorIs(err, service.ErrAPIKeyNotFound)
+}
+
+func (s *APIKeySuite) TestAPIKey_ActorIsOwner() {
+	_, writerKey := s.createKey(`{"name": "writer", "scopes": ["banner:write"]}`)
+
+	status, body := s.request(http.MethodPost, "/v1/banner/", `{"tag_ids": [29], "feature_id": 12, "content": {"key": 1}, "is_active": false}`,
+		http.Header{"X-API-Key": {writerKey}})
+	s.Require().Equal(http.StatusCreated, status)
+	var created struct {
+		BannerID int `json:"banner_id"`
+	}
+	s.Require().NoError(json.Unmarshal(body, &created))
+
+	// actions made with key are attributed to the user who created it
+	status, body = s.do(s.AdminToken, http.MethodGet, fmt.Sprintf("/v1/audit/?banner_id=%d&limit=1", created.BannerID), "")
+	s.Require().Equal(http.StatusOK, status)
+	var audit struct {
+		Records []struct {
+			ActorID *string `json:"actor_id"`
+		} `json:"records"`
+	}
+	s.Require().NoError(json.Unmarshal(body, &audit))
+	s.Require().Len(audit.Records, 1)
+	s.Require().NotNil(audit.Records[0].ActorID)
+	s.Equal(s.AdminID, *audit.Records[0].ActorID)
+}
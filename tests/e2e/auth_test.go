@@ -6,6 +6,7 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/NikolaB131-org/banner-service/config"
@@ -45,7 +46,7 @@ func (s *AuthSuite) TestAuthRoutes_LoginAdmin() {
 }
 
 func (s *AuthSuite) TestAuthRoutes_RegisterLoginUser() {
-	body := `{"username": "testuser2", "password": "qwerty"}`
+	body := `{"username": "testuser2", "password": "correct-horse-7"}`
 
 	res, _ := http.Post(s.BaseUrl+"/register", "application/json", strings.NewReader(body))
 	parsedBody, _ := io.ReadAll(res.Body)
@@ -65,3 +66,105 @@ func (s *AuthSuite) TestAuthRoutes_RegisterLoginUser() {
 	s.Equal(http.StatusOK, res.StatusCode)
 	s.NotEmpty(resBodyLogin.Token)
 }
+
+func (s *AuthSuite) TestAuthRoutes_RefreshLogout() {
+	res, _ := http.Post(s.BaseUrl+"/login", "application/json", strings.NewReader(`{"username": "admin", "password": "admin"}`))
+	parsedBody, _ := io.ReadAll(res.Body)
+	var loginBody struct {
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	json.Unmarshal(parsedBody, &loginBody)
+	s.Equal(http.StatusOK, res.StatusCode)
+	s.NotEmpty(loginBody.RefreshToken)
+
+	refreshBody := fmt.Sprintf(`{"refresh_token": "%s"}`, loginBody.RefreshToken)
+	res, _ = http.Post(s.BaseUrl+"/refresh", "application/json", strings.NewReader(refreshBody))
+	parsedBody, _ = io.ReadAll(res.Body)
+	var refreshedBody struct {
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	json.Unmarshal(parsedBody, &refreshedBody)
+	s.Equal(http.StatusOK, res.StatusCode)
+	s.NotEqual(loginBody.RefreshToken, refreshedBody.RefreshToken)
+
+	// reusing rotated token revokes the whole session
+	res, _ = http.Post(s.BaseUrl+"/refresh", "application/json", strings.NewReader(refreshBody))
+	s.Equal(http.StatusUnauthorized, res.StatusCode)
+	res, _ = http.Post(s.BaseUrl+"/refresh", "application/json", strings.NewReader(fmt.Sprintf(`{"refresh_token": "%s"}`, refreshedBody.RefreshToken)))
+	s.Equal(http.StatusUnauthorized, res.StatusCode)
+
+	res, _ = http.Post(s.BaseUrl+"/login", "application/json", strings.NewReader(`{"username": "admin", "password": "admin"}`))
+	parsedBody, _ = io.ReadAll(res.Body)
+	json.Unmarshal(parsedBody, &loginBody)
+
+	req, _ := http.NewRequest(http.MethodPost, s.BaseUrl+"/logout", nil)
+	req.Header.Add("Authorization", "Bearer "+loginBody.Token)
+	res, _ = http.DefaultClient.Do(req)
+	s.Equal(http.StatusNoContent, res.StatusCode)
+
+	req, _ = http.NewRequest(http.MethodPost, s.BaseUrl+"/logout", nil)
+	req.Header.Add("Authorization", "Bearer "+loginBody.Token)
+	res, _ = http.DefaultClient.Do(req)
+	s.Equal(http.StatusUnauthorized, res.StatusCode)
+}
+
+func (s *AuthSuite) TestAuthRoutes_LoginInvalidCredentials() {
+	res, _ := http.Post(s.BaseUrl+"/login", "application/json", strings.NewReader(`{"username": "unknownuser", "password": "qwerty"}`))
+	s.Equal(http.StatusUnauthorized, res.StatusCode)
+}
+
+func (s *AuthSuite) TestAuthRoutes_LoginLockout() {
+	body := `{"username": "throttleduser", "password": "correct-horse-7"}`
+	res, _ := http.Post(s.BaseUrl+"/register", "application/json", strings.NewReader(body))
+	s.Equal(http.StatusOK, res.StatusCode)
+
+	wrongBody := `{"username": "throttleduser", "password": "wrong"}`
+	for range 5 {
+		res, _ = http.Post(s.BaseUrl+"/login", "application/json", strings.NewReader(wrongBody))
+		s.Equal(http.StatusUnauthorized, res.StatusCode)
+	}
+
+	// locked out even with correct password
+	res, _ = http.Post(s.BaseUrl+"/login", "application/json", strings.NewReader(body))
+	s.Equal(http.StatusTooManyRequests, res.StatusCode)
+	s.NotEmpty(res.Header.Get("Retry-After"))
+}
+
+func (s *AuthSuite) TestAuthRoutes_LoginLockoutConcurrent() {
+	res, _ := http.Post(s.BaseUrl+"/register", "application/json", strings.NewReader(`{"username": "concurrentuser", "password": "correct-horse-7"}`))
+	s.Equal(http.StatusOK, res.StatusCode)
+
+	// attempts are reserved before password is verified, so parallel guesses can not pass the limit
+	var wg sync.WaitGroup
+	statuses := make(chan int, 10)
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := http.Post(s.BaseUrl+"/login", "application/json", strings.NewReader(`{"username": "concurrentuser", "password": "wrong"}`))
+			if err == nil {
+				statuses <- res.StatusCode
+			}
+		}()
+	}
+	wg.Wait()
+	close(statuses)
+
+	var unauthorized int
+	for status := range statuses {
+		if status == http.StatusUnauthorized {
+			unauthorized++
+		}
+	}
+	s.Equal(5, unauthorized)
+}
+
+func (s *AuthSuite) TestAuthRoutes_RegisterWeakPassword() {
+	for _, password := range []string{"short", "password123", "weakuser-1"} {
+		body := fmt.Sprintf(`{"username": "weakuser", "password": "%s"}`, password)
+		res, _ := http.Post(s.BaseUrl+"/register", "application/json", strings.NewReader(body))
+		s.Equal(http.StatusBadRequest, res.StatusCode, password)
+	}
+}
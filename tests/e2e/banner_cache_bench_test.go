@@ -0,0 +1,73 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/NikolaB131-org/banner-service/config"
+	"github.com/NikolaB131-org/banner-service/internal/entity"
+	redisRepo "github.com/NikolaB131-org/banner-service/internal/repository/redis"
+	"github.com/NikolaB131-org/banner-service/pkg/redis"
+)
+
+// Run with: go test ./tests/e2e/ -run '^$' -bench BannerCache
+
+func newBenchmarkBannerCache(b *testing.B) (*redisRepo.BannerRepository, entity.Banner) {
+	configPath := "/app/config.yml"
+	config, err := config.NewConfig(&configPath)
+	if err != nil {
+		b.Fatal(err)
+	}
+	redisClient, err := redis.New(config.Redis.Url)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { redisClient.Close() })
+
+	repo := redisRepo.NewBannerRepository(redisClient, time.Hour, 0)
+	banner := entity.Banner{ID: 1000, TagIDs: []int{1000}, FeatureID: 1000, Content: map[string]any{"title": "benchmark"}, IsActive: true}
+	err = repo.SaveBanner(context.Background(), banner, "en")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	return repo, banner
+}
+
+// Previous layout lookup: pointer and data are read with two sequential GETs
+func BenchmarkBannerCache_TwoRoundTrips(b *testing.B) {
+	repo, banner := newBenchmarkBannerCache(b)
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for range b.N {
+		bannerDataID, err := repo.Client.Get(ctx, fmt.Sprintf("banner:feature_id=%d,tag_id=%d", banner.FeatureID, banner.TagIDs[0])).Result()
+		if err != nil {
+			b.Fatal(err)
+		}
+		bannerData, err := repo.Client.HGet(ctx, fmt.Sprintf("banner-locales:%v", bannerDataID), "en").Result()
+		if err != nil {
+			b.Fatal(err)
+		}
+		var cached entity.Banner
+		if err := json.Unmarshal([]byte(bannerData), &cached); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkBannerCache_SingleRoundTrip(b *testing.B) {
+	repo, banner := newBenchmarkBannerCache(b)
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for range b.N {
+		_, err := repo.Banner(ctx, banner.FeatureID, banner.TagIDs[0], "en")
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,286 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/NikolaB131-org/banner-service/internal/entity"
+	"github.com/NikolaB131-org/banner-service/internal/service"
+	"github.com/stretchr/testify/suite"
+)
+
+type BannerSuite struct {
+	suite.Suite
+	*fixture
+}
+
+func TestBannerSuite(t *testing.T) {
+	suite.Run(t, new(BannerSuite))
+}
+
+func (suite *BannerSuite) SetupSuite() {
+	suite.fixture = sharedFixture()
+}
+
+func (s *BannerSuite) TestBannerRoutes_RevisionsRollback() {
+	bannerID, err := s.BannerService.Create(systemContext(), []int{22}, 16, map[string]any{"v": 1}, true, nil, nil, "")
+	if err != nil {
+		panic(err)
+	}
+
+	for i := 2; i <= 4; i++ {
+		status, _ := s.do(s.AdminToken, http.MethodPatch, fmt.Sprintf("/v1/banner/%d", bannerID), fmt.Sprintf(`{"content": {"v": %d}}`, i))
+		s.Equal(http.StatusOK, status)
+	}
+
+	status, body := s.do(s.AdminToken, http.MethodGet, fmt.Sprintf("/v1/banner/%d/revisions", bannerID), "")
+	var revisions []struct {
+		Version int            `json:"version"`
+		Content map[string]any `json:"content"`
+	}
+	json.Unmarshal(body, &revisions)
+	s.Equal(http.StatusOK, status)
+	s.Len(revisions, 3)
+	s.Equal(4, revisions[0].Version)
+	s.Equal(2, revisions[2].Version)
+
+	status, _ = s.do(s.AdminToken, http.MethodPost, fmt.Sprintf("/v1/banner/%d/rollback", bannerID), `{"version": 1}`)
+	s.Equal(http.StatusOK, status)
+
+	status, body = s.do(s.AdminToken, http.MethodGet, "/v1/banner/?feature_id=16&tag_id=22", "")
+	s.Equal(http.StatusOK, status)
+	s.Contains(string(body), `"content":{"v":1}`)
+
+	status, _ = s.do(s.AdminToken, http.MethodPost, fmt.Sprintf("/v1/banner/%d/rollback", bannerID), `{"version": 100}`)
+	s.Equal(http.StatusNotFound, status)
+}
+
+func (s *BannerSuite) createTag(name string) int {
+	status, body := s.do(s.AdminToken, http.MethodPost, "/v1/tag/", fmt.Sprintf(`{"name": "%s"}`, name))
+	s.Require().Equal(http.StatusCreated, status)
+	var created struct {
+		ID int `json:"tag_id"`
+	}
+	s.Require().NoError(json.Unmarshal(body, &created))
+	return created.ID
+}
+
+func (s *BannerSuite) TestBannerRoutes_RollbackDeletedTag() {
+	oldTagID, newTagID := s.createTag("rollback_old"), s.createTag("rollback_new")
+
+	bannerID, err := s.BannerService.Create(systemContext(), []int{oldTagID}, 11, map[string]any{"v": 1}, true, nil, nil, "")
+	s.Require().NoError(err)
+	bannerPath := fmt.Sprintf("/v1/banner/%d", bannerID)
+
+	s.Equal(http.StatusOK, s.status(s.AdminToken, http.MethodPatch, bannerPath, fmt.Sprintf(`{"tag_ids": [%d]}`, newTagID)))
+	s.Equal(http.StatusNoContent, s.status(s.AdminToken, http.MethodDelete, fmt.Sprintf("/v1/tag/%d", oldTagID), ""))
+
+	// revision refers to deleted tag, so it can not be restored
+	s.Equal(http.StatusConflict, s.status(s.AdminToken, http.MethodPost, bannerPath+"/rollback", `{"version": 1}`))
+	s.Equal(http.StatusBadRequest, s.status(s.AdminToken, http.MethodPatch, bannerPath, fmt.Sprintf(`{"tag_ids": [%d]}`, oldTagID)))
+}
+
+func (s *BannerSuite) TestBanner_NoAccessInContext() {
+	_, err := s.BannerService.Create(context.Background(), []int{22}, 19, map[string]any{"v": 1}, true, nil, nil, "")
+	s.ErrorIs(err, service.ErrBannerAccessDenied)
+	_, err = s.BannerService.GetBanners(context.Background(), nil, nil, nil, nil, nil)
+	s.ErrorIs(err, service.ErrBannerAccessDenied)
+}
+
+func (s *BannerSuite) TestBannerRoutes_RollbackExperimentLocales() {
+	bannerID, err := s.BannerService.Create(systemContext(), []int{29}, 15, map[string]any{"title": "base"}, true, nil, nil, "")
+	if err != nil {
+		panic(err)
+	}
+	bannerPath := fmt.Sprintf("/v1/banner/%d", bannerID)
+
+	status, _ := s.do(s.AdminToken, http.MethodPut, bannerPath+"/experiment", `{"name": "rollback_test", "variants": [
+		{"name": "a", "content": {"title": "A"}, "weight": 1}
+	]}`)
+	s.Equal(http.StatusOK, status)
+	status, _ = s.do(s.AdminToken, http.MethodPut, bannerPath+"/locales/ru", `{"title": "ru"}`)
+	s.Equal(http.StatusOK, status)
+
+	// experiment and locale changes are stored as revisions as well
+	status, body := s.do(s.AdminToken, http.MethodGet, bannerPath+"/revisions", "")
+	s.Equal(http.StatusOK, status)
+	var revisions []struct {
+		Version    int                       `json:"version"`
+		Experiment *struct{ Name string }    `json:"experiment"`
+		Locales    map[string]map[string]any `json:"locales"`
+	}
+	s.Require().NoError(json.Unmarshal(body, &revisions))
+	s.Require().Len(revisions, 3)
+	s.Equal(3, revisions[0].Version)
+	s.Require().NotNil(revisions[0].Experiment)
+	s.Equal("rollback_test", revisions[0].Experiment.Name)
+	s.Equal(map[string]map[string]any{"ru": {"title": "ru"}}, revisions[0].Locales)
+	s.Nil(revisions[2].Experiment)
+	s.Empty(revisions[2].Locales)
+
+	status, _ = s.do(s.AdminToken, http.MethodDelete, bannerPath+"/experiment", "")
+	s.Equal(http.StatusNoContent, status)
+	status, _ = s.do(s.AdminToken, http.MethodDelete, bannerPath+"/locales/ru", "")
+	s.Equal(http.StatusNoContent, status)
+
+	status, _ = s.do(s.AdminToken, http.MethodPost, bannerPath+"/rollback", `{"version": 3}`)
+	s.Equal(http.StatusOK, status)
+
+	status, body = s.do(s.AdminToken, http.MethodGet, bannerPath+"/experiment", "")
+	s.Equal(http.StatusOK, status)
+	s.Contains(string(body), `"name":"rollback_test"`)
+	status, body = s.do(s.AdminToken, http.MethodGet, "/v1/banner/?feature_id=15&tag_id=29", "")
+	s.Equal(http.StatusOK, status)
+	s.Contains(string(body), `"locales":{"ru":{"title":"ru"}}`)
+}
+
+func (s *BannerSuite) TestBannerRoutes_ConcurrentUpdates() {
+	bannerID, err := s.BannerService.Create(systemContext(), []int{24}, 16, map[string]any{"v": 1}, true, nil, nil, "")
+	if err != nil {
+		panic(err)
+	}
+
+	// tag-only updates do not change banner row itself, but still must get distinct revisions
+	var wg sync.WaitGroup
+	statuses := make([]int, 2)
+	for i, tagID := range []int{25, 26} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			statuses[i] = s.status(s.AdminToken, http.MethodPatch, fmt.Sprintf("/v1/banner/%d", bannerID), fmt.Sprintf(`{"tag_ids": [24, %d]}`, tagID))
+		}()
+	}
+	wg.Wait()
+	s.Equal([]int{http.StatusOK, http.StatusOK}, statuses)
+
+	status, body := s.do(s.AdminToken, http.MethodGet, fmt.Sprintf("/v1/banner/%d/revisions?limit=10", bannerID), "")
+	var revisions []struct {
+		Version int `json:"version"`
+	}
+	json.Unmarshal(body, &revisions)
+	s.Equal(http.StatusOK, status)
+	s.Len(revisions, 3)
+	s.Equal(3, revisions[0].Version)
+	s.Equal(2, revisions[1].Version)
+}
+
+func (s *BannerSuite) TestBannerRoutes_UpdateFeatureConflict() {
+	_, err := s.BannerService.Create(systemContext(), []int{27}, 16, map[string]any{"v": 1}, true, nil, nil, "")
+	if err != nil {
+		panic(err)
+	}
+	bannerID, err := s.BannerService.Create(systemContext(), []int{27}, 17, map[string]any{"v": 2}, true, nil, nil, "")
+	if err != nil {
+		panic(err)
+	}
+
+	// banner with the same tag already exists in feature 16
+	status, _ := s.do(s.AdminToken, http.MethodPatch, fmt.Sprintf("/v1/banner/%d", bannerID), `{"feature_id": 16}`)
+	s.Equal(http.StatusConflict, status)
+
+	status, _ = s.do(s.AdminToken, http.MethodPatch, fmt.Sprintf("/v1/banner/%d", bannerID), `{"feature_id": 16, "tag_ids": [28]}`)
+	s.Equal(http.StatusOK, status)
+
+	// version 1 is taken by another banner now
+	_, err = s.BannerService.Create(systemContext(), []int{27}, 17, map[string]any{"v": 3}, true, nil, nil, "")
+	if err != nil {
+		panic(err)
+	}
+	status, _ = s.do(s.AdminToken, http.MethodPost, fmt.Sprintf("/v1/banner/%d/rollback", bannerID), `{"version": 1}`)
+	s.Equal(http.StatusConflict, status)
+}
+
+func (s *BannerSuite) TestBannerRoutes_DeleteAsync() {
+	for _, tagID := range []int{20, 21, 22} {
+		_, err := s.BannerService.Create(systemContext(), []int{tagID}, 19, map[string]any{"tag": tagID}, true, nil, nil, "")
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	status, _ := s.do(s.AdminToken, http.MethodDelete, "/v1/banner/", "")
+	s.Equal(http.StatusBadRequest, status)
+
+	status, body := s.do(s.AdminToken, http.MethodDelete, "/v1/banner/?feature_id=19", "")
+	var resBody struct {
+		JobID string `json:"job_id"`
+	}
+	json.Unmarshal(body, &resBody)
+	s.Equal(http.StatusAccepted, status)
+	s.NotEmpty(resBody.JobID)
+
+	var job struct {
+		Status  string `json:"status"`
+		Deleted int    `json:"deleted"`
+	}
+	s.Eventually(func() bool {
+		status, body := s.do(s.AdminToken, http.MethodGet, "/v1/banner/jobs/"+resBody.JobID, "")
+		json.Unmarshal(body, &job)
+		return status == http.StatusOK && job.Status == "done"
+	}, 5*time.Second, 100*time.Millisecond)
+	s.Equal(3, job.Deleted)
+
+	status, body = s.do(s.AdminToken, http.MethodGet, "/v1/banner/?feature_id=19", "")
+	s.Equal(http.StatusOK, status)
+	s.JSONEq(`[]`, string(body))
+}
+
+func (s *BannerSuite) TestBanner_StaleDeletionJobResumed() {
+	tagID := s.createTag("stale_deletion")
+	_, err := s.BannerService.Create(systemContext(), []int{tagID}, 11, map[string]any{"stale": 1}, true, nil, nil, "")
+	s.Require().NoError(err)
+
+	// job of a replica stopped in the middle of deletion, its lease is already expired
+	job := entity.BannerDeletionJob{
+		ID:        "stale" + strconv.Itoa(tagID),
+		Status:    entity.BannerDeletionJobRunning,
+		TagID:     &tagID,
+		CreatedBy: s.AdminID,
+		CreatedAt: time.Now(),
+	}
+	s.Require().NoError(s.DeletionJobRepository.SaveBannerDeletionJob(context.Background(), job, -time.Second))
+
+	s.Eventually(func() bool {
+		job, err = s.DeletionJobRepository.BannerDeletionJob(context.Background(), job.ID)
+		return err == nil && job.Status == entity.BannerDeletionJobDone
+	}, s.Config.Banner.DeletionJobLease, 100*time.Millisecond)
+	s.Equal(1, job.Deleted)
+}
+
+func (s *BannerSuite) TestBannerRoutes_Audit() {
+	status, body := s.do(s.AdminToken, http.MethodPost, "/v1/banner/", `{"tag_ids": [23], "feature_id": 16, "content": {"audit": 1}, "is_active": true}`)
+	var created struct {
+		BannerID int `json:"banner_id"`
+	}
+	json.Unmarshal(body, &created)
+	s.Equal(http.StatusCreated, status)
+
+	status, _ = s.do(s.AdminToken, http.MethodPatch, fmt.Sprintf("/v1/banner/%d", created.BannerID), `{"is_active": false}`)
+	s.Equal(http.StatusOK, status)
+
+	status, body = s.do(s.AdminToken, http.MethodGet, fmt.Sprintf("/v1/audit/?banner_id=%d&limit=1", created.BannerID), "")
+	var audit struct {
+		Records []struct {
+			ActorID   *string                   `json:"actor_id"`
+			Action    string                    `json:"action"`
+			Changes   map[string]map[string]any `json:"changes"`
+			RequestID string                    `json:"request_id"`
+		} `json:"records"`
+		NextCursor string `json:"next_cursor"`
+	}
+	json.Unmarshal(body, &audit)
+
+	s.Equal(http.StatusOK, status)
+	s.Len(audit.Records, 1)
+	s.NotEmpty(audit.NextCursor)
+	s.Equal("update", audit.Records[0].Action)
+	s.NotNil(audit.Records[0].ActorID)
+	s.NotEmpty(audit.Records[0].RequestID)
+	s.Equal(map[string]any{"before": true, "after": false}, audit.Records[0].Changes["is_active"])
+}
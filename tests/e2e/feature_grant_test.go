@@ -0,0 +1,86 @@
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type FeatureGrantSuite struct {
+	suite.Suite
+	*fixture
+	TestUserID    string
+	TestUserToken string
+}
+
+func TestFeatureGrantSuite(t *testing.T) {
+	suite.Run(t, new(FeatureGrantSuite))
+}
+
+func (suite *FeatureGrantSuite) SetupSuite() {
+	suite.fixture = sharedFixture()
+	suite.TestUserID, suite.TestUserToken = suite.createUser("granteduser", "grantedpass")
+}
+
+func (s *FeatureGrantSuite) TestFeatureGrants_ScopedEditor() {
+	grantsPath := fmt.Sprintf("/v1/user/%s/feature_grants", s.TestUserID)
+
+	status, _ := s.do(s.AdminToken, http.MethodPost, "/v1/banner/", `{"tag_ids": [27], "feature_id": 14, "content": {"grant": 14}, "is_active": false}`)
+	s.Require().Equal(http.StatusCreated, status)
+
+	status, _ = s.do(s.TestUserToken, http.MethodGet, "/v1/banner/", "")
+	s.Equal(http.StatusForbidden, status)
+
+	status, _ = s.do(s.AdminToken, http.MethodPost, grantsPath, `{"feature_id": 13, "role": "admin"}`)
+	s.Equal(http.StatusBadRequest, status) // admin role has global permissions
+	status, _ = s.do(s.AdminToken, http.MethodPost, grantsPath, `{"feature_id": 13, "role": "editor"}`)
+	s.Require().Equal(http.StatusNoContent, status)
+
+	status, body := s.do(s.AdminToken, http.MethodGet, grantsPath, "")
+	s.Equal(http.StatusOK, status)
+	s.Contains(string(body), `"role":"editor"`)
+
+	status, _ = s.do(s.TestUserToken, http.MethodPost, "/v1/banner/", `{"tag_ids": [27], "feature_id": 13, "content": {"grant": 13}, "is_active": false}`)
+	s.Equal(http.StatusCreated, status)
+	status, _ = s.do(s.TestUserToken, http.MethodPost, "/v1/banner/", `{"tag_ids": [28], "feature_id": 14, "content": {"grant": 14}, "is_active": false}`)
+	s.Equal(http.StatusForbidden, status)
+
+	status, body = s.do(s.TestUserToken, http.MethodGet, "/v1/banner/", "")
+	s.Require().Equal(http.StatusOK, status)
+	var banners []struct {
+		FeatureID int `json:"feature_id"`
+	}
+	s.Require().NoError(json.Unmarshal(body, &banners))
+	s.NotEmpty(banners)
+	for _, banner := range banners {
+		s.Equal(13, banner.FeatureID)
+	}
+
+	// deletion job status is visible only to users allowed to enqueue the same deletion
+	status, body = s.do(s.AdminToken, http.MethodDelete, "/v1/banner/?feature_id=11", "")
+	s.Require().Equal(http.StatusAccepted, status)
+	var job struct {
+		JobID string `json:"job_id"`
+	}
+	s.Require().NoError(json.Unmarshal(body, &job))
+	status, _ = s.do(s.TestUserToken, http.MethodGet, "/v1/banner/jobs/"+job.JobID, "")
+	s.Equal(http.StatusForbidden, status)
+	status, _ = s.do(s.AdminToken, http.MethodGet, "/v1/banner/jobs/"+job.JobID, "")
+	s.Equal(http.StatusOK, status)
+
+	status, body = s.do(s.TestUserToken, http.MethodDelete, "/v1/banner/?feature_id=13", "")
+	s.Require().Equal(http.StatusAccepted, status)
+	s.Require().NoError(json.Unmarshal(body, &job))
+	status, _ = s.do(s.TestUserToken, http.MethodGet, "/v1/banner/jobs/"+job.JobID, "")
+	s.Equal(http.StatusOK, status)
+
+	status, _ = s.do(s.AdminToken, http.MethodDelete, grantsPath+"/13/editor", "")
+	s.Equal(http.StatusNoContent, status)
+	status, _ = s.do(s.AdminToken, http.MethodDelete, grantsPath+"/13/editor", "")
+	s.Equal(http.StatusNotFound, status)
+	status, _ = s.do(s.TestUserToken, http.MethodGet, "/v1/banner/", "")
+	s.Equal(http.StatusForbidden, status)
+}
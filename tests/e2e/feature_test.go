@@ -0,0 +1,71 @@
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type FeatureSuite struct {
+	suite.Suite
+	*fixture
+}
+
+func TestFeatureSuite(t *testing.T) {
+	suite.Run(t, new(FeatureSuite))
+}
+
+func (suite *FeatureSuite) SetupSuite() {
+	suite.fixture = sharedFixture()
+}
+
+func (s *FeatureSuite) TestFeatureRoutes_CRUD() {
+	status, body := s.do(s.AdminToken, http.MethodPost, "/v1/feature/", `{"name": "onboarding", "description": "first launch"}`)
+	var resBody struct {
+		FeatureID int `json:"feature_id"`
+	}
+	json.Unmarshal(body, &resBody)
+	s.Equal(http.StatusCreated, status)
+	s.Greater(resBody.FeatureID, 19)
+
+	featureUrl := fmt.Sprintf("/v1/feature/%d", resBody.FeatureID)
+
+	status, _ = s.do(s.AdminToken, http.MethodPatch, featureUrl, `{"name": "onboarding v2"}`)
+	s.Equal(http.StatusOK, status)
+
+	status, body = s.do(s.AdminToken, http.MethodGet, featureUrl, "")
+	s.Equal(http.StatusOK, status)
+	s.Contains(string(body), `"name":"onboarding v2"`)
+	s.Contains(string(body), `"description":"first launch"`)
+
+	status, _ = s.do(s.AdminToken, http.MethodPost, "/v1/banner/",
+		fmt.Sprintf(`{"tag_ids": [20], "feature_id": %d, "content": {"a": 1}, "is_active": true}`, resBody.FeatureID))
+	s.Equal(http.StatusCreated, status)
+
+	status, _ = s.do(s.AdminToken, http.MethodDelete, featureUrl, "")
+	s.Equal(http.StatusConflict, status)
+
+	status, _ = s.do(s.AdminToken, http.MethodDelete, featureUrl+"?cascade=true", "")
+	s.Equal(http.StatusNoContent, status)
+
+	status, _ = s.do(s.AdminToken, http.MethodGet, featureUrl, "")
+	s.Equal(http.StatusNotFound, status)
+
+	status, body = s.do(s.AdminToken, http.MethodGet, fmt.Sprintf("/v1/audit/?feature_id=%d", resBody.FeatureID), "")
+	var audit struct {
+		Records []struct {
+			Action  string                    `json:"action"`
+			Changes map[string]map[string]any `json:"changes"`
+		} `json:"records"`
+	}
+	json.Unmarshal(body, &audit)
+	s.Equal(http.StatusOK, status)
+	s.Require().Len(audit.Records, 3)
+	s.Equal("delete", audit.Records[0].Action)
+	s.Equal("update", audit.Records[1].Action)
+	s.Equal(map[string]any{"before": "onboarding", "after": "onboarding v2"}, audit.Records[1].Changes["name"])
+	s.Equal("create", audit.Records[2].Action)
+}
@@ -0,0 +1,180 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/NikolaB131-org/banner-service/config"
+	"github.com/NikolaB131-org/banner-service/internal/app/access"
+	"github.com/NikolaB131-org/banner-service/internal/app/jwt"
+	"github.com/NikolaB131-org/banner-service/internal/app/locale"
+	"github.com/NikolaB131-org/banner-service/internal/app/password"
+	memoryRepo "github.com/NikolaB131-org/banner-service/internal/repository/memory"
+	postgresRepo "github.com/NikolaB131-org/banner-service/internal/repository/postgres"
+	redisRepo "github.com/NikolaB131-org/banner-service/internal/repository/redis"
+	"github.com/NikolaB131-org/banner-service/internal/service"
+	"github.com/NikolaB131-org/banner-service/pkg/postgres"
+	"github.com/NikolaB131-org/banner-service/pkg/redis"
+)
+
+// fixture is wiring shared by all suites: services are used to prepare data directly
+// and HTTP helpers send requests to the server under test
+type fixture struct {
+	Config                  *config.Config
+	ServerUrl               string
+	UserRepository          *postgresRepo.UserRepository
+	PasswordResetRepository *postgresRepo.PasswordResetRepository
+	KeySet                  *jwt.KeySet
+	AuthService             *service.Auth
+	AuditService            *service.Audit
+	APIKeyService           *service.APIKey
+	RoleService             *service.Role
+	BannerService           *service.Banner
+	DeletionJobRepository   *postgresRepo.BannerDeletionJobRepository
+	AdminID                 string
+	AdminToken              string
+}
+
+// sharedFixture is built once per test run, so suites share connections and background workers
+var sharedFixture = sync.OnceValue(newFixture)
+
+func newFixture() *fixture {
+	ctx := context.Background()
+	configPath := "/app/config.yml"
+	config, err := config.NewConfig(&configPath)
+	if err != nil {
+		panic(err)
+	}
+	pg, err := postgres.New(config.DB.Url)
+	if err != nil {
+		panic(err)
+	}
+	redisClient, err := redis.New(config.Redis.Url)
+	if err != nil {
+		panic(err)
+	}
+	userRepository := postgresRepo.NewUserRepository(pg)
+	// Wrapped into local cache to publish session invalidations to the server like another replica does
+	sessionRepository := memoryRepo.NewSessionRepository(
+		postgresRepo.NewSessionRepository(pg),
+		redisRepo.NewSessionInvalidationRepository(redisClient),
+		config.Auth.AccessCacheTTL,
+	)
+	bannerRepository := postgresRepo.NewBannerRepository(pg)
+	// Wrapped into local cache to publish invalidations to the server like another replica does
+	bannerCacheRepository := memoryRepo.NewBannerRepository(
+		redisRepo.NewBannerRepository(redisClient, config.Redis.BannerTTL, config.Redis.EarlyRefresh),
+		redisRepo.NewBannerInvalidationRepository(redisClient),
+		config.Redis.LocalCacheSize,
+		config.Redis.LocalCacheTTL,
+	)
+	tagRepository := postgresRepo.NewTagRepository(pg)
+	featureRepository := postgresRepo.NewFeatureRepository(pg)
+	auditRepository := postgresRepo.NewAuditRepository(pg)
+	roleRepository := memoryRepo.NewRoleRepository(postgresRepo.NewRoleRepository(pg), sessionRepository)
+	keySet, err := jwt.NewKeySet(config.Auth)
+	if err != nil {
+		panic(err)
+	}
+	passwordHasher, err := password.NewHasher(config.Auth.PasswordHashing)
+	if err != nil {
+		panic(err)
+	}
+	passwordPolicy, err := password.NewPolicy(config.Auth.PasswordPolicy)
+	if err != nil {
+		panic(err)
+	}
+	bannerLocales, err := locale.New(config.Locales)
+	if err != nil {
+		panic(err)
+	}
+	deletionJobRepository := postgresRepo.NewBannerDeletionJobRepository(pg)
+	authService := service.NewAuthService(userRepository, sessionRepository, keySet, passwordHasher, passwordPolicy, config.Auth.TokenTTL, config.Auth.RefreshTokenTTL)
+	auditService := service.NewAuditService(auditRepository)
+
+	f := &fixture{
+		Config:                  config,
+		ServerUrl:               fmt.Sprintf("http://localhost:%d", config.HTTP.Port),
+		UserRepository:          userRepository,
+		PasswordResetRepository: postgresRepo.NewPasswordResetRepository(pg),
+		KeySet:                  keySet,
+		AuthService:             authService,
+		AuditService:            auditService,
+		APIKeyService:           service.NewAPIKeyService(postgresRepo.NewAPIKeyRepository(pg), roleRepository, auditService),
+		RoleService:             service.NewRoleService(roleRepository, auditService),
+		BannerService:           service.NewBannerService(bannerRepository, bannerCacheRepository, tagRepository, featureRepository, auditService, bannerLocales, deletionJobRepository, config.Banner.DeletionWorkers, config.Banner.DeletionQueueSize, config.Banner.DeletionJobTTL, config.Banner.DeletionJobLease),
+		DeletionJobRepository:   deletionJobRepository,
+	}
+
+	admin, err := userRepository.User(ctx, "admin")
+	if err != nil {
+		panic(err)
+	}
+	f.AdminID = admin.ID
+	f.AdminToken = f.login("admin", "admin")
+
+	return f
+}
+
+// systemContext is context of trusted internal caller, services deny calls without access in context
+func systemContext() context.Context {
+	return access.NewContext(context.Background(), access.System())
+}
+
+// createUser creates user with default role and returns its id and authorization header value
+func (f *fixture) createUser(username string, password string) (string, string) {
+	userID, err := f.AuthService.CreateUser(context.Background(), username, password)
+	if err != nil {
+		panic(err)
+	}
+	return userID, f.login(username, password)
+}
+
+// login returns authorization header value of a new session
+func (f *fixture) login(username string, password string) string {
+	token, _, err := f.AuthService.Login(context.Background(), username, password)
+	if err != nil {
+		panic(err)
+	}
+	return fmt.Sprintf("Bearer %s", token)
+}
+
+// do sends JSON request authorized with token (authorization header value, skipped if empty)
+// to path of the server under test
+func (f *fixture) do(token string, method string, path string, body string) (int, []byte) {
+	header := http.Header{}
+	if token != "" {
+		header.Set("Authorization", token)
+	}
+	return f.request(method, path, body, header)
+}
+
+// status is do for requests whose response body is not checked
+func (f *fixture) status(token string, method string, path string, body string) int {
+	status, _ := f.do(token, method, path, body)
+	return status
+}
+
+func (f *fixture) request(method string, path string, body string, header http.Header) (int, []byte) {
+	req, err := http.NewRequest(method, f.ServerUrl+path, strings.NewReader(body))
+	if err != nil {
+		panic(err)
+	}
+	for key, values := range header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		panic(err)
+	}
+	defer res.Body.Close()
+	resBody, _ := io.ReadAll(res.Body)
+	return res.StatusCode, resBody
+}
@@ -0,0 +1,41 @@
+package v1
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/NikolaB131-org/banner-service/config"
+	"github.com/stretchr/testify/suite"
+)
+
+type HealthSuite struct {
+	suite.Suite
+	BaseUrl string
+}
+
+func TestHealthSuite(t *testing.T) {
+	suite.Run(t, new(HealthSuite))
+}
+
+func (suite *HealthSuite) SetupSuite() {
+	configPath := "/app/config.yml"
+	config, err := config.NewConfig(&configPath)
+	if err != nil {
+		panic(err)
+	}
+	suite.BaseUrl = fmt.Sprintf("http://localhost:%d", config.HTTP.Port)
+}
+
+func (s *HealthSuite) TestHealthRoutes() {
+	res, err := http.Get(s.BaseUrl + "/healthz")
+	s.Require().NoError(err)
+	s.Equal(http.StatusOK, res.StatusCode)
+
+	res, err = http.Get(s.BaseUrl + "/readyz")
+	s.Require().NoError(err)
+	body, _ := io.ReadAll(res.Body)
+	s.Equal(http.StatusOK, res.StatusCode)
+	s.JSONEq(`{"status": "ok", "checks": {"postgres": "ok", "redis": "ok"}}`, string(body))
+}
@@ -0,0 +1,96 @@
+package v1
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/NikolaB131-org/banner-service/config"
+	"github.com/NikolaB131-org/banner-service/internal/app/jwt"
+	"github.com/stretchr/testify/suite"
+)
+
+type JWKSSuite struct {
+	suite.Suite
+	BaseUrl string
+	Config  *config.Config
+}
+
+func TestJWKSSuite(t *testing.T) {
+	suite.Run(t, new(JWKSSuite))
+}
+
+func (suite *JWKSSuite) SetupSuite() {
+	configPath := "/app/config.yml"
+	config, err := config.NewConfig(&configPath)
+	if err != nil {
+		panic(err)
+	}
+	suite.BaseUrl = fmt.Sprintf("http://localhost:%d", config.HTTP.Port)
+	suite.Config = config
+}
+
+func (s *JWKSSuite) TestJWKSRoutes() {
+	res, err := http.Get(s.BaseUrl + "/.well-known/jwks.json")
+	s.Require().NoError(err)
+	s.Equal(http.StatusOK, res.StatusCode)
+	body, _ := io.ReadAll(res.Body)
+
+	var jwks jwt.JWKS
+	s.Require().NoError(json.Unmarshal(body, &jwks))
+	s.Len(jwks.Keys, len(s.Config.Auth.SigningKeys))
+	for i, key := range jwks.Keys {
+		s.Equal(s.Config.Auth.SigningKeys[i].ID, key.Kid)
+		s.Equal(s.Config.Auth.SigningKeys[i].Algorithm, key.Alg)
+	}
+}
+
+func (s *JWKSSuite) TestKeySet_SignSecret() {
+	_, err := jwt.NewKeySet(config.Auth{SignSecret: "abracadabra"})
+	s.ErrorIs(err, jwt.ErrExampleSecret)
+	_, err = jwt.NewKeySet(config.Auth{})
+	s.ErrorIs(err, jwt.ErrNoSigningKey)
+
+	legacyKeySet, err := jwt.NewKeySet(config.Auth{SignSecret: "legacy-secret"})
+	s.Require().NoError(err)
+	legacyToken, err := legacyKeySet.Generate(time.Hour, "id", "username", "sid")
+	s.Require().NoError(err)
+
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	s.Require().NoError(err)
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	s.Require().NoError(err)
+	keyFile := filepath.Join(s.T().TempDir(), "key.pem")
+	s.Require().NoError(os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600))
+	auth := config.Auth{
+		SignSecret:  "legacy-secret",
+		SigningKeys: []config.SigningKey{{ID: "test", Algorithm: "EdDSA", PrivateKeyFile: keyFile}},
+	}
+
+	// Sign secret verifies older tokens only if it is kept explicitly and until the configured time
+	keySet, err := jwt.NewKeySet(auth)
+	s.Require().NoError(err)
+	_, err = keySet.Parse(legacyToken)
+	s.Error(err)
+
+	auth.SignSecretUntil = time.Now().Add(time.Hour)
+	keySet, err = jwt.NewKeySet(auth)
+	s.Require().NoError(err)
+	_, err = keySet.Parse(legacyToken)
+	s.NoError(err)
+
+	auth.SignSecretUntil = time.Now().Add(-time.Hour)
+	keySet, err = jwt.NewKeySet(auth)
+	s.Require().NoError(err)
+	_, err = keySet.Parse(legacyToken)
+	s.Error(err)
+}
@@ -0,0 +1,117 @@
+package v1
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/NikolaB131-org/banner-service/internal/entity"
+	"github.com/NikolaB131-org/banner-service/internal/service"
+	"github.com/NikolaB131-org/banner-service/pkg/oidc"
+	"github.com/NikolaB131-org/banner-service/pkg/oidc/oidctest"
+	"github.com/stretchr/testify/suite"
+)
+
+// OIDCSuite runs login flow against in-process mock provider, service is built here since
+// server under test is started with identity provider disabled
+type OIDCSuite struct {
+	suite.Suite
+	*fixture
+	Provider    *oidctest.Server
+	OIDCService *service.OIDC
+}
+
+func TestOIDCSuite(t *testing.T) {
+	suite.Run(t, new(OIDCSuite))
+}
+
+func (suite *OIDCSuite) SetupSuite() {
+	ctx := context.Background()
+	suite.fixture = sharedFixture()
+
+	suite.Provider = oidctest.NewServer("banner-service")
+	client, err := oidc.New(ctx, oidc.Config{
+		Issuer:      suite.Provider.URL,
+		ClientID:    "banner-service",
+		RedirectURL: "http://localhost/v1/auth/oidc/callback",
+	})
+	if err != nil {
+		panic(err)
+	}
+	suite.OIDCService = service.NewOIDCService(
+		client,
+		suite.UserRepository,
+		suite.AuthService,
+		suite.RoleService,
+		"preferred_username",
+		"groups",
+		map[string]string{"banner-editors": entity.RoleEditor, "banner-viewers": entity.RoleViewer},
+		false,
+	)
+
+	_, err = suite.AuthService.CreateUser(ctx, "ssolocal", "ssolocal")
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (suite *OIDCSuite) TearDownSuite() {
+	suite.Provider.Close()
+}
+
+// login follows redirect of mock provider the way browser would
+func (s *OIDCSuite) login() (string, error) {
+	authURL, request, err := s.OIDCService.AuthURL()
+	s.Require().NoError(err)
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	res, err := client.Get(authURL)
+	s.Require().NoError(err)
+	s.Require().Equal(http.StatusFound, res.StatusCode)
+	callbackURL, err := url.Parse(res.Header.Get("Location"))
+	s.Require().NoError(err)
+	s.Require().Equal(request.State, callbackURL.Query().Get("state"))
+
+	token, _, err := s.OIDCService.Login(context.Background(), callbackURL.Query().Get("code"), request)
+	return token, err
+}
+
+func (s *OIDCSuite) TestOIDC_ProvisionAndSyncRoles() {
+	s.Provider.SetUser("sso-subject-1", map[string]any{"preferred_username": "ssouser", "groups": []string{"banner-editors", "other"}})
+	token, err := s.login()
+	s.Require().NoError(err)
+	claims, err := s.KeySet.Parse(token)
+	s.Require().NoError(err)
+	s.Equal("ssouser", claims.Username)
+
+	user, err := s.UserRepository.User(context.Background(), "ssouser")
+	s.Require().NoError(err)
+	s.Equal(claims.UserID, user.ID)
+	s.Empty(user.PasswordHash)
+	s.Equal([]string{entity.RoleEditor}, user.Roles)
+
+	// Same subject is the same user even if username changed, roles follow groups
+	s.Provider.SetUser("sso-subject-1", map[string]any{"preferred_username": "renamed", "groups": "banner-viewers"})
+	token, err = s.login()
+	s.Require().NoError(err)
+	claims, err = s.KeySet.Parse(token)
+	s.Require().NoError(err)
+	s.Equal(user.ID, claims.UserID)
+	user, err = s.UserRepository.UserByID(context.Background(), user.ID)
+	s.Require().NoError(err)
+	s.Equal([]string{entity.RoleViewer}, user.Roles)
+}
+
+func (s *OIDCSuite) TestOIDC_Errors() {
+	s.Provider.SetUser("sso-subject-2", map[string]any{"preferred_username": "ssolocal"})
+	_, err := s.login()
+	s.ErrorIs(err, service.ErrOIDCUsernameTaken)
+
+	s.Provider.SetUser("sso-subject-3", map[string]any{})
+	_, err = s.login()
+	s.ErrorIs(err, service.ErrOIDCInvalidUsername)
+
+	_, _, err = s.OIDCService.Login(context.Background(), "unknown-code", entity.OIDCAuthRequest{})
+	s.ErrorIs(err, service.ErrOIDCExchange)
+}
@@ -0,0 +1,120 @@
+package v1
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type PasswordSuite struct {
+	suite.Suite
+	*fixture
+}
+
+func TestPasswordSuite(t *testing.T) {
+	suite.Run(t, new(PasswordSuite))
+}
+
+func (suite *PasswordSuite) SetupSuite() {
+	suite.fixture = sharedFixture()
+}
+
+func (s *PasswordSuite) TestPasswordRoutes_ChangePassword() {
+	_, token := s.createUser("passworduser", "oldpass")
+
+	status, _ := s.do(token, http.MethodPost, "/v1/auth/password", `{"current_password": "wrong", "new_password": "new-secret-42"}`)
+	s.Equal(http.StatusForbidden, status)
+
+	status, body := s.do(token, http.MethodPost, "/v1/auth/password", `{"current_password": "oldpass", "new_password": "new-secret-42"}`)
+	s.Equal(http.StatusOK, status)
+	var tokens struct {
+		Token string `json:"token"`
+	}
+	json.Unmarshal(body, &tokens)
+	s.NotEmpty(tokens.Token)
+
+	// sessions created with the old password are revoked
+	status, _ = s.do(token, http.MethodPost, "/v1/auth/logout", "")
+	s.Equal(http.StatusUnauthorized, status)
+	status, _ = s.do("Bearer "+tokens.Token, http.MethodPost, "/v1/auth/logout", "")
+	s.Equal(http.StatusNoContent, status)
+
+	status, _ = s.do("", http.MethodPost, "/v1/auth/login", `{"username": "passworduser", "password": "oldpass"}`)
+	s.Equal(http.StatusUnauthorized, status)
+	status, _ = s.do("", http.MethodPost, "/v1/auth/login", `{"username": "passworduser", "password": "new-secret-42"}`)
+	s.Equal(http.StatusOK, status)
+}
+
+func (s *PasswordSuite) TestPasswordRoutes_ResetPassword() {
+	userID, token := s.createUser("resetuser", "oldpass")
+
+	// reset is accepted for unknown users too, so existing accounts can not be found out
+	status, _ := s.do("", http.MethodPost, "/v1/auth/password_reset", `{"username": "resetuser"}`)
+	s.Equal(http.StatusAccepted, status)
+	status, _ = s.do("", http.MethodPost, "/v1/auth/password_reset", `{"username": "unknownresetuser"}`)
+	s.Equal(http.StatusAccepted, status)
+
+	// token sent by notifier is not accessible here, so a known one is saved directly
+	resetToken := "e2e-reset-token"
+	tokenHash := sha256.Sum256([]byte(resetToken))
+	err := s.PasswordResetRepository.SavePasswordResetToken(context.Background(), userID, tokenHash[:], time.Now().Add(time.Hour))
+	s.Require().NoError(err)
+	expiredToken := "e2e-expired-reset-token"
+	expiredTokenHash := sha256.Sum256([]byte(expiredToken))
+	err = s.PasswordResetRepository.SavePasswordResetToken(context.Background(), userID, expiredTokenHash[:], time.Now().Add(-time.Minute))
+	s.Require().NoError(err)
+
+	status, _ = s.do("", http.MethodPost, "/v1/auth/password_reset/confirm", fmt.Sprintf(`{"token": "%s", "new_password": "reset-secret-42"}`, expiredToken))
+	s.Equal(http.StatusBadRequest, status)
+
+	status, _ = s.do("", http.MethodPost, "/v1/auth/password_reset/confirm", fmt.Sprintf(`{"token": "%s", "new_password": "reset-secret-42"}`, resetToken))
+	s.Equal(http.StatusNoContent, status)
+
+	// token is single-use
+	status, _ = s.do("", http.MethodPost, "/v1/auth/password_reset/confirm", fmt.Sprintf(`{"token": "%s", "new_password": "otherpass"}`, resetToken))
+	s.Equal(http.StatusBadRequest, status)
+
+	status, _ = s.do(token, http.MethodPost, "/v1/auth/logout", "")
+	s.Equal(http.StatusUnauthorized, status)
+	status, _ = s.do("", http.MethodPost, "/v1/auth/login", `{"username": "resetuser", "password": "reset-secret-42"}`)
+	s.Equal(http.StatusOK, status)
+}
+
+func (s *PasswordSuite) TestPasswordRoutes_ChangeToWeakPassword() {
+	_, token := s.createUser("weakchangeuser", "old-secret-42")
+
+	status, _ := s.do(token, http.MethodPost, "/v1/auth/password", `{"current_password": "old-secret-42", "new_password": "qwerty123"}`)
+	s.Equal(http.StatusBadRequest, status)
+
+	// password is not changed, session is kept
+	status, _ = s.do(token, http.MethodPost, "/v1/auth/logout", "")
+	s.Equal(http.StatusNoContent, status)
+}
+
+func (s *PasswordSuite) TestPasswordRoutes_Throttling() {
+	_, token := s.createUser("passwordthrottleuser", "old-secret-42")
+
+	for range s.Config.Auth.LoginThrottle.MaxUsernameFailures {
+		status, _ := s.do(token, http.MethodPost, "/v1/auth/password", `{"current_password": "wrong", "new_password": "new-secret-42"}`)
+		s.Equal(http.StatusForbidden, status)
+	}
+	// correct password is rejected too until lockout ends
+	status, _ := s.do(token, http.MethodPost, "/v1/auth/password", `{"current_password": "old-secret-42", "new_password": "new-secret-42"}`)
+	s.Equal(http.StatusTooManyRequests, status)
+
+	// reset requests are limited the same way for existing and unknown users
+	for _, username := range []string{"passwordthrottleuser", "unknownthrottleuser"} {
+		for range s.Config.Auth.LoginThrottle.MaxPasswordResetRequests {
+			status, _ := s.do("", http.MethodPost, "/v1/auth/password_reset", fmt.Sprintf(`{"username": "%s"}`, username))
+			s.Equal(http.StatusAccepted, status)
+		}
+		status, _ := s.do("", http.MethodPost, "/v1/auth/password_reset", fmt.Sprintf(`{"username": "%s"}`, username))
+		s.Equal(http.StatusTooManyRequests, status)
+	}
+}
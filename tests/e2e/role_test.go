@@ -0,0 +1,67 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type RoleSuite struct {
+	suite.Suite
+	*fixture
+	TestUserID    string
+	TestUserToken string
+}
+
+func TestRoleSuite(t *testing.T) {
+	suite.Run(t, new(RoleSuite))
+}
+
+func (suite *RoleSuite) SetupSuite() {
+	suite.fixture = sharedFixture()
+	suite.TestUserID, suite.TestUserToken = suite.createUser("rbacuser", "rbacpass")
+}
+
+func (s *RoleSuite) TestRoleRoutes_Permissions() {
+	rolesPath := fmt.Sprintf("/v1/user/%s/roles", s.TestUserID)
+
+	s.Equal(http.StatusForbidden, s.status(s.TestUserToken, http.MethodGet, "/v1/banner/", ""))
+	s.Equal(http.StatusForbidden, s.status(s.TestUserToken, http.MethodGet, "/v1/role/", ""))
+
+	s.Equal(http.StatusNoContent, s.status(s.AdminToken, http.MethodPost, rolesPath, `{"role": "editor"}`))
+	s.Equal(http.StatusNotFound, s.status(s.AdminToken, http.MethodPost, rolesPath, `{"role": "unknown"}`))
+
+	s.Equal(http.StatusOK, s.status(s.TestUserToken, http.MethodGet, "/v1/banner/", ""))
+	s.Equal(http.StatusForbidden, s.status(s.TestUserToken, http.MethodPost, "/v1/banner/",
+		`{"tag_ids": [26], "feature_id": 12, "content": {"rbac": 1}, "is_active": true}`)) // publishing requires banner:publish
+	s.Equal(http.StatusCreated, s.status(s.TestUserToken, http.MethodPost, "/v1/banner/",
+		`{"tag_ids": [26], "feature_id": 12, "content": {"rbac": 1}, "is_active": false}`))
+
+	s.Equal(http.StatusNoContent, s.status(s.AdminToken, http.MethodDelete, rolesPath+"/editor", ""))
+	s.Equal(http.StatusNotFound, s.status(s.AdminToken, http.MethodDelete, rolesPath+"/editor", ""))
+	s.Equal(http.StatusForbidden, s.status(s.TestUserToken, http.MethodGet, "/v1/banner/", ""))
+
+	s.Equal(http.StatusConflict, s.status(s.AdminToken, http.MethodPut, "/v1/role/admin/permissions", `{"permissions": []}`))
+	s.Equal(http.StatusBadRequest, s.status(s.AdminToken, http.MethodPut, "/v1/role/viewer/permissions", `{"permissions": ["banner:fly"]}`))
+	s.Equal(http.StatusBadRequest, s.status(s.AdminToken, http.MethodGet, "/v1/user/not-a-uuid/roles", ""))
+}
+
+func (s *RoleSuite) TestRoleRoutes_AccessCacheInvalidation() {
+	userID, token := s.createUser("rbaccacheuser", "rbaccachepass")
+
+	s.Equal(http.StatusForbidden, s.status(token, http.MethodGet, "/v1/banner/", ""))
+
+	// role changes made by another instance must reach the server cache without waiting for ttl
+	s.Require().NoError(s.RoleService.AssignRole(systemContext(), userID, "viewer", s.AdminID))
+	s.Eventually(func() bool {
+		return s.status(token, http.MethodGet, "/v1/banner/", "") == http.StatusOK
+	}, time.Second, 50*time.Millisecond)
+
+	s.Require().NoError(s.RoleService.RevokeRole(systemContext(), userID, "viewer", s.AdminID))
+	s.Eventually(func() bool {
+		return s.status(token, http.MethodGet, "/v1/banner/", "") == http.StatusForbidden
+	}, time.Second, 50*time.Millisecond)
+}
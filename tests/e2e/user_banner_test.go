@@ -1,29 +1,24 @@
 package v1
 
 import (
-	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
 	"testing"
+	"time"
 
-	"github.com/NikolaB131-org/banner-service/config"
-	postgresRepo "github.com/NikolaB131-org/banner-service/internal/repository/postgres"
-	redisRepo "github.com/NikolaB131-org/banner-service/internal/repository/redis"
-	"github.com/NikolaB131-org/banner-service/internal/service"
-	"github.com/NikolaB131-org/banner-service/pkg/postgres"
-	"github.com/NikolaB131-org/banner-service/pkg/redis"
+	"github.com/NikolaB131-org/banner-service/internal/entity"
 	"github.com/stretchr/testify/suite"
 )
 
 type UserBannerSuite struct {
 	suite.Suite
-	BannerService  *service.Banner
-	BaseUrl        string
-	TestUserToken  string
-	TestAdminToken string
+	*fixture
+	BaseUrl       string
+	BannerUrl     string
+	TestUserToken string
 }
 
 func TestUserBannerSuite(t *testing.T) {
@@ -31,50 +26,17 @@ func TestUserBannerSuite(t *testing.T) {
 }
 
 func (suite *UserBannerSuite) SetupSuite() {
-	ctx := context.Background()
-	configPath := "/app/config.yml"
-	config, err := config.NewConfig(&configPath)
-	if err != nil {
-		panic(err)
-	}
-	suite.BaseUrl = fmt.Sprintf("http://localhost:%d/v1/user_banner", config.HTTP.Port)
-	pg, err := postgres.New(config.DB.Url)
-	if err != nil {
-		panic(err)
-	}
-	redisClient, err := redis.New(config.Redis.Url)
-	if err != nil {
-		panic(err)
-	}
-	userRepository := postgresRepo.NewUserRepository(pg)
-	bannerRepository := postgresRepo.NewBannerRepository(pg)
-	bannerCacheRepository := redisRepo.NewBannerRepository(redisClient, config.Redis.BannerTTL)
-	tagRepository := postgresRepo.NewTagRepository(pg)
-	featureRepository := postgresRepo.NewFeatureRepository(pg)
-	authService := service.NewAuthService(userRepository, config.Auth.SignSecret, config.Auth.TokenTTL)
-	bannerService := service.NewBannerService(bannerRepository, bannerCacheRepository, tagRepository, featureRepository)
-	suite.BannerService = bannerService
-
-	_, err = authService.RegisterUser(ctx, "testuser", "testpass")
-	if err != nil {
-		panic(err)
-	}
-	token, err := authService.Login(ctx, "testuser", "testpass")
-	if err != nil {
-		panic(err)
-	}
-	suite.TestUserToken = fmt.Sprintf("Bearer %s", token)
-	token, err = authService.Login(ctx, "admin", "admin")
-	if err != nil {
-		panic(err)
-	}
-	suite.TestAdminToken = fmt.Sprintf("Bearer %s", token)
+	ctx := systemContext()
+	suite.fixture = sharedFixture()
+	suite.BaseUrl = suite.ServerUrl + "/v1/user_banner"
+	suite.BannerUrl = suite.ServerUrl + "/v1/banner"
+	_, suite.TestUserToken = suite.createUser("testuser", "testpass")
 
-	_, err = bannerService.Create(ctx, []int{20, 21}, 10, map[string]any{"info": "123"}, true) // active banner
+	_, err := suite.BannerService.Create(ctx, []int{20, 21}, 10, map[string]any{"info": "123"}, true, nil, nil, "") // active banner
 	if err != nil {
 		panic(err)
 	}
-	_, err = bannerService.Create(ctx, []int{25}, 10, map[string]any{"memes_counter": 25}, false) // inactive banner
+	_, err = suite.BannerService.Create(ctx, []int{25}, 10, map[string]any{"memes_counter": 25}, false, nil, nil, "") // inactive banner
 	if err != nil {
 		panic(err)
 	}
@@ -133,7 +95,7 @@ func (s *UserBannerSuite) TestUserBannerRoutes_GetBanner() {
 			resStatusCode: http.StatusForbidden,
 		},
 		{
-			reqHeaders:    map[string]string{"Authorization": s.TestAdminToken},
+			reqHeaders:    map[string]string{"Authorization": s.AdminToken},
 			reqQuery:      "?tag_id=25&feature_id=10",
 			resStatusCode: http.StatusOK,
 			resBody:       `{"memes_counter": 25}`,
@@ -161,7 +123,7 @@ func (s *UserBannerSuite) TestUserBannerRoutes_GetBanner() {
 }
 
 func (s *UserBannerSuite) TestUserBannerRoutes_GetBannerLastRevision() {
-	bannerID, err := s.BannerService.Create(context.Background(), []int{27}, 15, map[string]any{"company": "Avito"}, true)
+	bannerID, err := s.BannerService.Create(systemContext(), []int{27}, 15, map[string]any{"company": "Avito"}, true, nil, nil, "")
 	if err != nil {
 		panic(err)
 	}
@@ -178,7 +140,7 @@ func (s *UserBannerSuite) TestUserBannerRoutes_GetBannerLastRevision() {
 	s.Equal(http.StatusOK, res.StatusCode)
 	s.JSONEq(`{"company": "Avito"}`, string(parsedBody))
 
-	err = s.BannerService.Update(context.Background(), bannerID, nil, nil, map[string]any{"job": "Avito"}, nil)
+	err = s.BannerService.Update(systemContext(), bannerID, nil, nil, map[string]any{"job": "Avito"}, nil, entity.Nullable[time.Time]{}, entity.Nullable[time.Time]{}, "")
 	if err != nil {
 		panic(err)
 	}
@@ -193,7 +155,7 @@ func (s *UserBannerSuite) TestUserBannerRoutes_GetBannerLastRevision() {
 	json.Unmarshal(parsedBody, &resBody2)
 
 	s.Equal(http.StatusOK, res.StatusCode)
-	s.JSONEq(`{"company": "Avito"}`, string(parsedBody))
+	s.JSONEq(`{"job": "Avito"}`, string(parsedBody)) // cache is invalidated on update
 
 	req, _ = http.NewRequest(http.MethodGet, s.BaseUrl+"?tag_id=27&feature_id=15&use_last_revision=true", nil)
 	req.Header.Add("Authorization", s.TestUserToken)
@@ -207,3 +169,231 @@ func (s *UserBannerSuite) TestUserBannerRoutes_GetBannerLastRevision() {
 	s.Equal(http.StatusOK, res.StatusCode)
 	s.JSONEq(`{"job": "Avito"}`, string(parsedBody))
 }
+
+func (s *UserBannerSuite) TestUserBannerRoutes_GetBannerActivationWindow() {
+	activeFrom := time.Now().Add(time.Hour)
+	_, err := s.BannerService.Create(systemContext(), []int{28}, 17, map[string]any{"sale": "soon"}, true, &activeFrom, nil, "")
+	if err != nil {
+		panic(err)
+	}
+	activeUntil := time.Now().Add(-time.Hour)
+	_, err = s.BannerService.Create(systemContext(), []int{29}, 17, map[string]any{"sale": "over"}, true, nil, &activeUntil, "")
+	if err != nil {
+		panic(err)
+	}
+
+	for _, query := range []string{"?tag_id=28&feature_id=17", "?tag_id=29&feature_id=17"} {
+		req, _ := http.NewRequest(http.MethodGet, s.BaseUrl+query, nil)
+		req.Header.Add("Authorization", s.TestUserToken)
+		res, _ := http.DefaultClient.Do(req)
+		s.Equal(http.StatusForbidden, res.StatusCode)
+
+		req, _ = http.NewRequest(http.MethodGet, s.BaseUrl+query, nil)
+		req.Header.Add("Authorization", s.AdminToken)
+		res, _ = http.DefaultClient.Do(req)
+		s.Equal(http.StatusOK, res.StatusCode)
+	}
+}
+
+func (s *UserBannerSuite) TestUserBannerRoutes_GetBannerExperiment() {
+	bannerID, err := s.BannerService.Create(systemContext(), []int{20}, 18, map[string]any{"title": "base"}, true, nil, nil, "")
+	if err != nil {
+		panic(err)
+	}
+	experimentUrl := fmt.Sprintf("%s/%d/experiment", s.BannerUrl, bannerID)
+
+	setExperiment := func(body string) *http.Response {
+		req, _ := http.NewRequest(http.MethodPut, experimentUrl, strings.NewReader(body))
+		req.Header.Add("Authorization", s.AdminToken)
+		res, _ := http.DefaultClient.Do(req)
+		return res
+	}
+	getBanner := func() (*http.Response, string) {
+		req, _ := http.NewRequest(http.MethodGet, s.BaseUrl+"?tag_id=20&feature_id=18", nil)
+		req.Header.Add("Authorization", s.TestUserToken)
+		res, _ := http.DefaultClient.Do(req)
+		parsedBody, _ := io.ReadAll(res.Body)
+		return res, string(parsedBody)
+	}
+
+	res, body := getBanner()
+	s.Equal(http.StatusOK, res.StatusCode)
+	s.JSONEq(`{"title": "base"}`, body)
+
+	res = setExperiment(`{"name": "title_test", "variants": [
+		{"name": "a", "content": {"title": "A"}, "weight": 1},
+		{"name": "b", "content": {"title": "B"}, "weight": 0}
+	]}`)
+	s.Equal(http.StatusOK, res.StatusCode)
+
+	res, body = getBanner()
+	s.Equal(http.StatusOK, res.StatusCode)
+	s.JSONEq(`{"title": "A"}`, body)
+	s.Equal("title_test", res.Header.Get("X-Banner-Experiment"))
+	s.Equal("a", res.Header.Get("X-Banner-Variant"))
+
+	res = setExperiment(`{"name": "title_test", "variants": [
+		{"name": "a", "content": {"title": "A"}, "weight": 0},
+		{"name": "b", "content": {"title": "B"}, "weight": 1}
+	]}`)
+	s.Equal(http.StatusOK, res.StatusCode)
+
+	res, body = getBanner()
+	s.Equal(http.StatusOK, res.StatusCode)
+	s.JSONEq(`{"title": "B"}`, body) // cache is invalidated on experiment change
+	s.Equal("b", res.Header.Get("X-Banner-Variant"))
+
+	res = setExperiment(`{"name": "title_test", "variants": [{"name": "a", "content": {"title": "A"}, "weight": 0}]}`)
+	s.Equal(http.StatusBadRequest, res.StatusCode)
+	res = setExperiment(`{"name": "title_test", "variants": [
+		{"name": "a", "content": {"title": "A"}, "weight": 1},
+		{"name": "a", "content": {"title": "B"}, "weight": 1}
+	]}`)
+	s.Equal(http.StatusBadRequest, res.StatusCode)
+
+	req, _ := http.NewRequest(http.MethodGet, experimentUrl, nil)
+	req.Header.Add("Authorization", s.AdminToken)
+	res, _ = http.DefaultClient.Do(req)
+	parsedBody, _ := io.ReadAll(res.Body)
+	s.Equal(http.StatusOK, res.StatusCode)
+	s.JSONEq(`{"name": "title_test", "variants": [
+		{"name": "a", "content": {"title": "A"}, "weight": 0},
+		{"name": "b", "content": {"title": "B"}, "weight": 1}
+	]}`, string(parsedBody))
+
+	req, _ = http.NewRequest(http.MethodDelete, experimentUrl, nil)
+	req.Header.Add("Authorization", s.AdminToken)
+	res, _ = http.DefaultClient.Do(req)
+	s.Equal(http.StatusNoContent, res.StatusCode)
+
+	res, body = getBanner()
+	s.Equal(http.StatusOK, res.StatusCode)
+	s.JSONEq(`{"title": "base"}`, body)
+	s.Empty(res.Header.Get("X-Banner-Variant"))
+
+	req, _ = http.NewRequest(http.MethodGet, experimentUrl, nil)
+	req.Header.Add("Authorization", s.AdminToken)
+	res, _ = http.DefaultClient.Do(req)
+	s.Equal(http.StatusNotFound, res.StatusCode)
+}
+
+func (s *UserBannerSuite) TestUserBannerRoutes_Stats() {
+	bannerID, err := s.BannerService.Create(systemContext(), []int{21}, 18, map[string]any{"title": "stats"}, true, nil, nil, "")
+	if err != nil {
+		panic(err)
+	}
+
+	for range 2 {
+		req, _ := http.NewRequest(http.MethodGet, s.BaseUrl+"?tag_id=21&feature_id=18", nil)
+		req.Header.Add("Authorization", s.TestUserToken)
+		res, _ := http.DefaultClient.Do(req)
+		s.Equal(http.StatusOK, res.StatusCode)
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, s.BaseUrl+"/click", strings.NewReader(`{"feature_id": 18, "tag_id": 21}`))
+	req.Header.Add("Authorization", s.TestUserToken)
+	res, _ := http.DefaultClient.Do(req)
+	s.Equal(http.StatusNoContent, res.StatusCode)
+
+	req, _ = http.NewRequest(http.MethodPost, s.BaseUrl+"/click", strings.NewReader(`{"feature_id": 18, "tag_id": 999}`))
+	req.Header.Add("Authorization", s.TestUserToken)
+	res, _ = http.DefaultClient.Do(req)
+	s.Equal(http.StatusNotFound, res.StatusCode)
+
+	req, _ = http.NewRequest(http.MethodGet, fmt.Sprintf("%s/%d/stats", s.BannerUrl, bannerID), nil)
+	req.Header.Add("Authorization", s.TestUserToken)
+	res, _ = http.DefaultClient.Do(req)
+	s.Equal(http.StatusForbidden, res.StatusCode)
+
+	today := time.Now().UTC().Format(time.DateOnly)
+	statsUrl := fmt.Sprintf("%s/%d/stats?from=%s&to=%s", s.BannerUrl, bannerID, today, today)
+	var stats struct {
+		Days []struct {
+			Date        string  `json:"date"`
+			Impressions int     `json:"impressions"`
+			Clicks      int     `json:"clicks"`
+			CTR         float64 `json:"ctr"`
+		} `json:"days"`
+	}
+	// Counters are flushed to database in background
+	s.Require().Eventually(func() bool {
+		req, _ := http.NewRequest(http.MethodGet, statsUrl, nil)
+		req.Header.Add("Authorization", s.AdminToken)
+		res, _ := http.DefaultClient.Do(req)
+		parsedBody, _ := io.ReadAll(res.Body)
+		json.Unmarshal(parsedBody, &stats)
+		return res.StatusCode == http.StatusOK && len(stats.Days) == 1 && stats.Days[0].Impressions == 2
+	}, 15*time.Second, 500*time.Millisecond)
+	s.Equal(today, stats.Days[0].Date)
+	s.Equal(1, stats.Days[0].Clicks)
+	s.Equal(0.5, stats.Days[0].CTR)
+
+	req, _ = http.NewRequest(http.MethodGet, fmt.Sprintf("%s/%d/stats?from=2024-05-02&to=2024-05-01", s.BannerUrl, bannerID), nil)
+	req.Header.Add("Authorization", s.AdminToken)
+	res, _ = http.DefaultClient.Do(req)
+	s.Equal(http.StatusBadRequest, res.StatusCode)
+}
+
+func (s *UserBannerSuite) TestUserBannerRoutes_GetBannerLocale() {
+	bannerID, err := s.BannerService.Create(systemContext(), []int{22}, 18, map[string]any{"title": "base"}, true, nil, nil, "")
+	if err != nil {
+		panic(err)
+	}
+	localesUrl := fmt.Sprintf("%s/%d/locales/", s.BannerUrl, bannerID)
+
+	adminRequest := func(method string, locale string, body string) *http.Response {
+		req, _ := http.NewRequest(method, localesUrl+locale, strings.NewReader(body))
+		req.Header.Add("Authorization", s.AdminToken)
+		res, _ := http.DefaultClient.Do(req)
+		return res
+	}
+	getBanner := func(query string, acceptLanguage string) (*http.Response, string) {
+		req, _ := http.NewRequest(http.MethodGet, s.BaseUrl+"?tag_id=22&feature_id=18"+query, nil)
+		req.Header.Add("Authorization", s.TestUserToken)
+		if acceptLanguage != "" {
+			req.Header.Add("Accept-Language", acceptLanguage)
+		}
+		res, _ := http.DefaultClient.Do(req)
+		parsedBody, _ := io.ReadAll(res.Body)
+		return res, string(parsedBody)
+	}
+
+	res, body := getBanner("&locale=ru", "")
+	s.Equal(http.StatusOK, res.StatusCode)
+	s.JSONEq(`{"title": "base"}`, body)
+
+	res = adminRequest(http.MethodPut, "ru", `{"title": "ru"}`)
+	s.Equal(http.StatusOK, res.StatusCode)
+	res = adminRequest(http.MethodPut, "xx-YY", `{"title": "unsupported"}`)
+	s.Equal(http.StatusBadRequest, res.StatusCode)
+
+	testCases := []struct {
+		query           string
+		acceptLanguage  string
+		resBody         string
+		contentLanguage string
+	}{
+		{query: "&locale=ru", resBody: `{"title": "ru"}`, contentLanguage: "ru"}, // cache is invalidated on locale change
+		{query: "&locale=ru_RU", resBody: `{"title": "ru"}`, contentLanguage: "ru"},
+		{acceptLanguage: "de-DE, ru;q=0.9, en;q=0.8", resBody: `{"title": "ru"}`, contentLanguage: "ru"},
+		{query: "&locale=kk", resBody: `{"title": "ru"}`, contentLanguage: "ru"}, // configured fallback
+		{query: "&locale=en", acceptLanguage: "ru", resBody: `{"title": "base"}`},
+		{query: "&locale=de", acceptLanguage: "ru", resBody: `{"title": "ru"}`, contentLanguage: "ru"},
+		{resBody: `{"title": "base"}`},
+	}
+	for _, testCase := range testCases {
+		res, body := getBanner(testCase.query, testCase.acceptLanguage)
+		s.Equal(http.StatusOK, res.StatusCode)
+		s.JSONEq(testCase.resBody, body)
+		s.Equal(testCase.contentLanguage, res.Header.Get("Content-Language"))
+	}
+
+	res = adminRequest(http.MethodDelete, "ru", "")
+	s.Equal(http.StatusNoContent, res.StatusCode)
+	res = adminRequest(http.MethodDelete, "ru", "")
+	s.Equal(http.StatusNotFound, res.StatusCode)
+
+	res, body = getBanner("&locale=ru", "")
+	s.Equal(http.StatusOK, res.StatusCode)
+	s.JSONEq(`{"title": "base"}`, body)
+}
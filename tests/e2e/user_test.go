@@ -0,0 +1,76 @@
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type UserSuite struct {
+	suite.Suite
+	*fixture
+	TestUserID    string
+	TestUserToken string
+}
+
+func TestUserSuite(t *testing.T) {
+	suite.Run(t, new(UserSuite))
+}
+
+func (suite *UserSuite) SetupSuite() {
+	suite.fixture = sharedFixture()
+	suite.TestUserID, suite.TestUserToken = suite.createUser("manageduser", "managedpass")
+}
+
+func (s *UserSuite) login() int {
+	status, _ := s.do("", http.MethodPost, "/v1/auth/login", `{"username": "manageduser", "password": "managedpass"}`)
+	return status
+}
+
+func (s *UserSuite) TestUserRoutes_Lifecycle() {
+	userPath := "/v1/user/" + s.TestUserID
+
+	status, body := s.do(s.AdminToken, http.MethodGet, "/v1/user/?limit=1000", "")
+	s.Require().Equal(http.StatusOK, status)
+	s.Contains(string(body), s.TestUserID)
+	s.NotContains(string(body), "password")
+
+	status, body = s.do(s.AdminToken, http.MethodGet, userPath, "")
+	s.Require().Equal(http.StatusOK, status)
+	var user struct {
+		Username string   `json:"username"`
+		Roles    []string `json:"roles"`
+	}
+	s.Require().NoError(json.Unmarshal(body, &user))
+	s.Equal("manageduser", user.Username)
+	s.Empty(user.Roles)
+
+	status, _ = s.do(s.TestUserToken, http.MethodGet, "/v1/user/", "")
+	s.Equal(http.StatusForbidden, status)
+
+	status, _ = s.do(s.AdminToken, http.MethodPost, "/v1/user/"+s.AdminID+"/disable", "")
+	s.Equal(http.StatusConflict, status)
+
+	status, _ = s.do(s.AdminToken, http.MethodPost, userPath+"/disable", "")
+	s.Require().Equal(http.StatusNoContent, status)
+	status, _ = s.do(s.TestUserToken, http.MethodGet, "/v1/user_banner?tag_id=20&feature_id=10", "")
+	s.Equal(http.StatusUnauthorized, status)
+	s.Equal(http.StatusForbidden, s.login())
+
+	status, _ = s.do(s.AdminToken, http.MethodPost, userPath+"/enable", "")
+	s.Require().Equal(http.StatusNoContent, status)
+	s.Equal(http.StatusOK, s.login())
+
+	status, _ = s.do(s.AdminToken, http.MethodPost, userPath+"/force_password_reset", "")
+	s.Require().Equal(http.StatusNoContent, status)
+	s.Equal(http.StatusForbidden, s.login())
+
+	status, _ = s.do(s.AdminToken, http.MethodDelete, userPath, "")
+	s.Require().Equal(http.StatusNoContent, status)
+	status, _ = s.do(s.AdminToken, http.MethodGet, userPath, "")
+	s.Equal(http.StatusNotFound, status)
+	status, _ = s.do(s.AdminToken, http.MethodDelete, userPath, "")
+	s.Equal(http.StatusNotFound, status)
+}